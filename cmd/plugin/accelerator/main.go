@@ -62,8 +62,17 @@ func main() {
 		commands.GenerateCmd(),
 		commands.PushCmd(ctx, c),
 		commands.ApplyCmd(ctx, c),
+		commands.ExportCmd(ctx, c),
+		commands.SyncCmd(ctx, c),
+		commands.ValidateCmd(ctx, c),
+		commands.ValidateLocalCmd(ctx, c),
+		commands.InitCmd(ctx, c),
+		commands.PublishCmd(ctx, c),
 		commands.FragmentCmd(ctx, c),
 		commands.LocalGenerateCmd(),
+		commands.GenerateBatchCmd(),
+		commands.AcceleratorTestCmd(),
+		commands.CacheCmd(),
 	)
 
 	p.Cmd.PersistentFlags().StringVar(&c.KubeConfigFile, "kubeconfig", "", "kubeconfig `file` (default is $HOME/.kube/config)")
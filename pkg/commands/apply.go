@@ -24,47 +24,24 @@ import (
 func ApplyCmd(ctx context.Context, c *cli.Config) *cobra.Command {
 	opts := ApplyOptions{}
 	cmd := &cobra.Command{
-		Use:     "apply",
-		Short:   "Apply accelerator resource",
-		Long:    "Create or update accelerator resource using specified manifest file.",
+		Use:   "apply",
+		Short: "Apply accelerator resource",
+		Long: `Create or update accelerator and accelerator fragment resources using the specified manifest file.
+
+The manifest file can contain multiple resources separated by "---", like the manifests written by the export command.
+`,
 		Example: "tanzu accelerator apply --filename <path-to-resource-manifest>",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			var fileObj runtime.RawExtension
-			err := loadResourceFromFile(opts.FileName, &fileObj)
+			documents, err := loadResourcesFromFile(opts.FileName)
 			if err != nil {
 				fmt.Fprintf(cmd.OutOrStderr(), "Error loading file %s\n", opts.FileName)
 				return err
 			}
-			obj, _, err := unstructured.UnstructuredJSONScheme.Decode(fileObj.Raw, nil, nil)
-			if err != nil {
-				fmt.Fprintf(cmd.OutOrStderr(), "Error decoding file %s\n", opts.FileName)
-				return err
-			}
-
-			if obj.GetObjectKind().GroupVersionKind().Kind == "Accelerator" {
-				providedResource := acceleratorv1alpha1.Accelerator{}
-				_, _, err := unstructured.UnstructuredJSONScheme.Decode(fileObj.Raw, nil, &providedResource)
+			for _, fileObj := range documents {
+				err = applyResource(ctx, c, fileObj, opts, cmd)
 				if err != nil {
-					fmt.Fprintf(cmd.OutOrStderr(), "Error decoding Accelerator resource from file %s\n", opts.FileName)
 					return err
 				}
-				err = saveAcceleratorResource(ctx, c, providedResource, opts, cmd)
-				if err != nil {
-					return err
-				}
-			} else if obj.GetObjectKind().GroupVersionKind().Kind == "Fragment" {
-				providedResource := acceleratorv1alpha1.Fragment{}
-				_, _, err := unstructured.UnstructuredJSONScheme.Decode(fileObj.Raw, nil, &providedResource)
-				if err != nil {
-					fmt.Fprintf(cmd.OutOrStderr(), "Error decoding Fragment resource from file %s\n", opts.FileName)
-					return err
-				}
-				err = saveFragmentResource(ctx, c, providedResource, opts, cmd)
-				if err != nil {
-					return err
-				}
-			} else {
-				return fmt.Errorf("the resource kind \"%s\" in the provided file \"%s\" does not match \"Accelerator\" or \"Fragment\"", obj.GetObjectKind().GroupVersionKind().Kind, opts.FileName)
 			}
 			return nil
 		},
@@ -73,33 +50,59 @@ func ApplyCmd(ctx context.Context, c *cli.Config) *cobra.Command {
 	return cmd
 }
 
-func loadResourceFromFile(file string, a *runtime.RawExtension) error {
-	f, err := os.Open(file)
+func applyResource(ctx context.Context, c *cli.Config, fileObj runtime.RawExtension, opts ApplyOptions, cmd *cobra.Command) error {
+	obj, _, err := unstructured.UnstructuredJSONScheme.Decode(fileObj.Raw, nil, nil)
 	if err != nil {
+		fmt.Fprintf(cmd.OutOrStderr(), "Error decoding file %s\n", opts.FileName)
 		return err
 	}
+
+	if obj.GetObjectKind().GroupVersionKind().Kind == "Accelerator" {
+		providedResource := acceleratorv1alpha1.Accelerator{}
+		_, _, err := unstructured.UnstructuredJSONScheme.Decode(fileObj.Raw, nil, &providedResource)
+		if err != nil {
+			fmt.Fprintf(cmd.OutOrStderr(), "Error decoding Accelerator resource from file %s\n", opts.FileName)
+			return err
+		}
+		return saveAcceleratorResource(ctx, c, providedResource, opts, cmd)
+	} else if obj.GetObjectKind().GroupVersionKind().Kind == "Fragment" {
+		providedResource := acceleratorv1alpha1.Fragment{}
+		_, _, err := unstructured.UnstructuredJSONScheme.Decode(fileObj.Raw, nil, &providedResource)
+		if err != nil {
+			fmt.Fprintf(cmd.OutOrStderr(), "Error decoding Fragment resource from file %s\n", opts.FileName)
+			return err
+		}
+		return saveFragmentResource(ctx, c, providedResource, opts, cmd)
+	}
+	return fmt.Errorf("the resource kind \"%s\" in the provided file \"%s\" does not match \"Accelerator\" or \"Fragment\"", obj.GetObjectKind().GroupVersionKind().Kind, opts.FileName)
+}
+
+func loadResourcesFromFile(file string) ([]runtime.RawExtension, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
 	defer f.Close()
 
 	d := yamlutil.NewYAMLOrJSONDecoder(f, 4096)
-	documents := 0
+	documents := []runtime.RawExtension{}
 	for {
-		accelerator := &runtime.RawExtension{}
-		if err := d.Decode(&accelerator); err != nil {
+		resource := &runtime.RawExtension{}
+		if err := d.Decode(&resource); err != nil {
 			if err == io.EOF {
 				break
 			}
-			return errors.New(fmt.Sprintf("%s does not contain valid YAML", file))
+			return nil, errors.New(fmt.Sprintf("%s does not contain valid YAML", file))
 		}
-		if accelerator == nil {
+		if resource == nil || len(resource.Raw) == 0 {
 			continue
 		}
-		if documents > 0 {
-			return fmt.Errorf("files containing multiple resources are not supported")
-		}
-		accelerator.DeepCopyInto(a)
-		documents++
+		documents = append(documents, *resource)
 	}
-	return nil
+	if len(documents) == 0 {
+		return nil, fmt.Errorf("%s does not contain any resources", file)
+	}
+	return documents, nil
 }
 
 func saveAcceleratorResource(ctx context.Context, c *cli.Config, providedResource acceleratorv1alpha1.Accelerator, opts ApplyOptions, cmd *cobra.Command) error {
@@ -125,8 +128,20 @@ func saveAcceleratorResource(ctx context.Context, c *cli.Config, providedResourc
 		fmt.Fprintf(cmd.OutOrStdout(), "created accelerator %s in namespace %s\n", providedResource.Name, providedResource.Namespace)
 		return nil
 	} else {
-		mergo.Merge(currentAcc, providedResource, mergo.WithOverride)
-		c.Update(ctx, currentAcc)
+		// a resourceVersion in the provided file would keep conflicting, the live resourceVersion is used instead
+		providedResource.ObjectMeta.ResourceVersion = ""
+		key := client.ObjectKey{Namespace: acceleratorNamespace, Name: acceleratorName}
+		newAccelerator := func() client.Object {
+			currentAcc = &acceleratorv1alpha1.Accelerator{}
+			return currentAcc
+		}
+		err = updateWithRetry(ctx, c, "accelerator", key, newAccelerator, func() error {
+			return mergo.Merge(currentAcc, providedResource, mergo.WithOverride)
+		})
+		if err != nil {
+			fmt.Fprintf(cmd.OutOrStderr(), "Error updating accelerator %s\n", providedResource.Name)
+			return err
+		}
 		fmt.Fprintf(cmd.OutOrStdout(), "updated accelerator %s in namespace %s\n", currentAcc.Name, currentAcc.Namespace)
 		return nil
 	}
@@ -155,8 +170,20 @@ func saveFragmentResource(ctx context.Context, c *cli.Config, providedResource a
 		fmt.Fprintf(cmd.OutOrStdout(), "created accelerator fragment %s in namespace %s\n", providedResource.Name, providedResource.Namespace)
 		return nil
 	} else {
-		mergo.Merge(currentAcc, providedResource, mergo.WithOverride)
-		c.Update(ctx, currentAcc)
+		// a resourceVersion in the provided file would keep conflicting, the live resourceVersion is used instead
+		providedResource.ObjectMeta.ResourceVersion = ""
+		key := client.ObjectKey{Namespace: fragmentNamespace, Name: fragmentName}
+		newFragment := func() client.Object {
+			currentAcc = &acceleratorv1alpha1.Fragment{}
+			return currentAcc
+		}
+		err = updateWithRetry(ctx, c, "accelerator fragment", key, newFragment, func() error {
+			return mergo.Merge(currentAcc, providedResource, mergo.WithOverride)
+		})
+		if err != nil {
+			fmt.Fprintf(cmd.OutOrStderr(), "Error updating accelerator fragment %s\n", providedResource.Name)
+			return err
+		}
 		fmt.Fprintf(cmd.OutOrStdout(), "updated accelerator fragment %s in namespace %s\n", currentAcc.Name, currentAcc.Namespace)
 		return nil
 	}
@@ -151,6 +151,142 @@ func TestApplyCommand(t *testing.T) {
 			},
 			ExpectOutput: "updated accelerator fragment test-fragment in namespace accelerator-system\n",
 		},
+		{
+			Name: "Update Accelerator retries on conflict",
+			Args: []string{acceleratorName, "--filename", acceleratorFilename},
+			WithReactors: []clitesting.ReactionFunc{
+				induceConflictOnce("Accelerator"),
+			},
+			GivenObjects: []client.Object{
+				&acceleratorv1alpha1.Accelerator{
+					ObjectMeta: v1.ObjectMeta{
+						Name:      acceleratorName,
+						Namespace: namespace,
+					},
+					Spec: acceleratorv1alpha1.AcceleratorSpec{
+						Git: &acceleratorv1alpha1.Git{
+							URL: gitRepoUrl,
+							Reference: &v1beta2.GitRepositoryRef{
+								Branch: "not-main",
+							},
+						},
+					},
+				},
+			},
+			ExpectUpdates: []client.Object{
+				&acceleratorv1alpha1.Accelerator{
+					ObjectMeta: v1.ObjectMeta{
+						Name:      acceleratorName,
+						Namespace: namespace,
+					},
+					Spec: acceleratorv1alpha1.AcceleratorSpec{
+						Git: &acceleratorv1alpha1.Git{
+							URL: gitRepoUrl,
+							Reference: &v1beta2.GitRepositoryRef{
+								Branch: gitBranch,
+							},
+						},
+					},
+				},
+				&acceleratorv1alpha1.Accelerator{
+					ObjectMeta: v1.ObjectMeta{
+						Name:      acceleratorName,
+						Namespace: namespace,
+					},
+					Spec: acceleratorv1alpha1.AcceleratorSpec{
+						Git: &acceleratorv1alpha1.Git{
+							URL: gitRepoUrl,
+							Reference: &v1beta2.GitRepositoryRef{
+								Branch: gitBranch,
+							},
+						},
+					},
+				},
+			},
+			ExpectOutput: "updated accelerator test-accelerator in namespace accelerator-system\n",
+		},
+		{
+			Name: "Error updating Accelerator",
+			Args: []string{acceleratorName, "--filename", acceleratorFilename},
+			WithReactors: []clitesting.ReactionFunc{
+				clitesting.InduceFailure("update", "Accelerator"),
+			},
+			GivenObjects: []client.Object{
+				&acceleratorv1alpha1.Accelerator{
+					ObjectMeta: v1.ObjectMeta{
+						Name:      acceleratorName,
+						Namespace: namespace,
+					},
+					Spec: acceleratorv1alpha1.AcceleratorSpec{
+						Git: &acceleratorv1alpha1.Git{
+							URL: gitRepoUrl,
+							Reference: &v1beta2.GitRepositoryRef{
+								Branch: "not-main",
+							},
+						},
+					},
+				},
+			},
+			ExpectUpdates: []client.Object{
+				&acceleratorv1alpha1.Accelerator{
+					ObjectMeta: v1.ObjectMeta{
+						Name:      acceleratorName,
+						Namespace: namespace,
+					},
+					Spec: acceleratorv1alpha1.AcceleratorSpec{
+						Git: &acceleratorv1alpha1.Git{
+							URL: gitRepoUrl,
+							Reference: &v1beta2.GitRepositoryRef{
+								Branch: gitBranch,
+							},
+						},
+					},
+				},
+			},
+			ShouldError:  true,
+			ExpectOutput: "Error updating accelerator test-accelerator\n",
+		},
+		{
+			Name: "Error updating Fragment",
+			Args: []string{fragmentName, "--filename", fragmentFilename},
+			WithReactors: []clitesting.ReactionFunc{
+				clitesting.InduceFailure("update", "Fragment"),
+			},
+			GivenObjects: []client.Object{
+				&acceleratorv1alpha1.Fragment{
+					ObjectMeta: v1.ObjectMeta{
+						Name:      fragmentName,
+						Namespace: namespace,
+					},
+					Spec: acceleratorv1alpha1.FragmentSpec{
+						Git: &acceleratorv1alpha1.Git{
+							URL: gitRepoUrl,
+							Reference: &v1beta2.GitRepositoryRef{
+								Branch: "not-main",
+							},
+						},
+					},
+				},
+			},
+			ExpectUpdates: []client.Object{
+				&acceleratorv1alpha1.Fragment{
+					ObjectMeta: v1.ObjectMeta{
+						Name:      fragmentName,
+						Namespace: namespace,
+					},
+					Spec: acceleratorv1alpha1.FragmentSpec{
+						Git: &acceleratorv1alpha1.Git{
+							URL: gitRepoUrl,
+							Reference: &v1beta2.GitRepositoryRef{
+								Branch: gitBranch,
+							},
+						},
+					},
+				},
+			},
+			ShouldError:  true,
+			ExpectOutput: "Error updating accelerator fragment test-fragment\n",
+		},
 	}
 	table.Run(t, scheme, ApplyCmd)
 }
@@ -0,0 +1,313 @@
+/*
+Copyright 2022-2023 VMware, Inc. All Rights Reserved.
+*/
+package commands
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// defaultCacheMaxSize is the size the cache of generated projects is limited to unless another size is provided
+const defaultCacheMaxSize = "1Gi"
+
+// generateCache stores the generated projects as zip files in a directory, the least recently used projects are
+// removed when the size of the directory exceeds the limit
+type generateCache struct {
+	directory string
+	maxSize   int64
+}
+
+// generateCacheKey identifies a generated project by everything that it depends on
+type generateCacheKey struct {
+	ServerUrl string `json:"serverUrl"`
+	// Accelerator is the name of the registered accelerator and AcceleratorRevision its archive URL, which changes
+	// with every revision of the accelerator
+	Accelerator         string `json:"accelerator,omitempty"`
+	AcceleratorRevision string `json:"acceleratorRevision,omitempty"`
+	// AcceleratorDigest is the digest of the package of a local accelerator
+	AcceleratorDigest string            `json:"acceleratorDigest,omitempty"`
+	FragmentNames     []string          `json:"fragmentNames,omitempty"`
+	FragmentDigests   map[string]string `json:"fragmentDigests,omitempty"`
+	// Options are normalized by encoding them, the keys of JSON objects are sorted
+	Options map[string]interface{} `json:"options"`
+}
+
+// hash returns the name of the cache entry of the key
+func (k generateCacheKey) hash() (string, error) {
+	sortedNames := append([]string{}, k.FragmentNames...)
+	sort.Strings(sortedNames)
+	k.FragmentNames = sortedNames
+	encoded, err := json.Marshal(k)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(encoded)), nil
+}
+
+// generateCacheFlags are the flags of the commands generating projects that use the cache
+type generateCacheFlags struct {
+	cache   bool
+	noCache bool
+	maxSize string
+}
+
+func (f *generateCacheFlags) define(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&f.cache, "cache", false, "use the cache of generated projects, it can also be enabled by setting the ACC_GENERATE_CACHE environment variable to true")
+	cmd.Flags().BoolVar(&f.noCache, "no-cache", false, "don't use the cache of generated projects, even when the ACC_GENERATE_CACHE environment variable is set")
+	cmd.Flags().StringVar(&f.maxSize, "cache-max-size", defaultCacheMaxSize, "the size the cache of generated projects is limited to, the least recently used projects are removed first")
+	cmd.MarkFlagsMutuallyExclusive("cache", "no-cache")
+}
+
+// open returns the cache, or nil if it is not enabled
+func (f *generateCacheFlags) open() (*generateCache, error) {
+	if f.noCache || !(f.cache || strings.EqualFold(EnvVar("ACC_GENERATE_CACHE", ""), "true")) {
+		return nil, nil
+	}
+	maxSize, err := parseCacheSize(f.maxSize)
+	if err != nil {
+		return nil, err
+	}
+	directory, err := cacheDirectory()
+	if err != nil {
+		return nil, err
+	}
+	return &generateCache{directory: directory, maxSize: maxSize}, nil
+}
+
+func parseCacheSize(size string) (int64, error) {
+	quantity, err := resource.ParseQuantity(size)
+	if err != nil || quantity.Sign() < 0 {
+		return 0, fmt.Errorf("invalid cache size %q, use a size like 500Mi or 2Gi", size)
+	}
+	return quantity.Value(), nil
+}
+
+// cacheDirectory returns the directory of the cache, set with the ACC_CACHE_DIR environment variable or in the cache
+// directory of the user
+func cacheDirectory() (string, error) {
+	if directory := EnvVar("ACC_CACHE_DIR", ""); directory != "" {
+		return directory, nil
+	}
+	userCache, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("could not determine the cache directory, set the ACC_CACHE_DIR environment variable: %v", err)
+	}
+	return filepath.Join(userCache, "tanzu-accelerator", "generate"), nil
+}
+
+// get returns the path of the cached zip file of the key, marking it as recently used
+func (c *generateCache) get(key string) (string, bool) {
+	path := filepath.Join(c.directory, key+".zip")
+	if _, err := os.Stat(path); err != nil {
+		return "", false
+	}
+	now := time.Now()
+	os.Chtimes(path, now, now)
+	return path, true
+}
+
+// put stores the zip file of the key and removes the least recently used entries when the cache is too large
+func (c *generateCache) put(key string, zip io.Reader) error {
+	if err := os.MkdirAll(c.directory, 0755); err != nil {
+		return err
+	}
+	// the file is renamed once complete, so that concurrent runs never read a partial entry
+	tmp, err := os.CreateTemp(c.directory, key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, zip); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(c.directory, key+".zip")); err != nil {
+		return err
+	}
+	_, _, err = c.prune(c.maxSize, 0)
+	return err
+}
+
+// prune removes the entries that weren't used for longer than olderThan, when it is not zero, and then the least
+// recently used entries until the cache is not larger than maxSize. The number of removed entries and their size are
+// returned.
+func (c *generateCache) prune(maxSize int64, olderThan time.Duration) (int, int64, error) {
+	entries, err := os.ReadDir(c.directory)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, 0, nil
+	} else if err != nil {
+		return 0, 0, err
+	}
+	files := []os.FileInfo{}
+	var size int64
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".zip" {
+			continue
+		}
+		fi, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, fi)
+		size += fi.Size()
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].ModTime().Before(files[j].ModTime())
+	})
+
+	removed := 0
+	var freed int64
+	for _, fi := range files {
+		unused := olderThan > 0 && time.Since(fi.ModTime()) > olderThan
+		if !unused && size <= maxSize {
+			continue
+		}
+		if err := os.Remove(filepath.Join(c.directory, fi.Name())); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return removed, freed, err
+		}
+		removed++
+		freed += fi.Size()
+		size -= fi.Size()
+	}
+	return removed, freed, nil
+}
+
+// localGenerateCacheKey returns the cache key of a project generated from local and registered accelerators and
+// fragments
+func localGenerateCacheKey(serverUrl string, apiPrefix string, localAccelerator kvPair, acceleratorName string, fragmentNames []string, localFragments map[string]string, options map[string]interface{}, packaging packageOptions) (string, error) {
+	key := generateCacheKey{
+		ServerUrl:       serverUrl,
+		FragmentNames:   fragmentNames,
+		FragmentDigests: map[string]string{},
+		Options:         options,
+	}
+	if !localAccelerator.isEmpty() {
+		digest, err := packageDigest(localAccelerator.value, packaging)
+		if err != nil {
+			return "", err
+		}
+		key.Accelerator = localAccelerator.key
+		key.AcceleratorDigest = digest
+	} else if acceleratorName != "" {
+		revision, err := acceleratorRevision(serverUrl, apiPrefix, acceleratorName)
+		if err != nil {
+			return "", err
+		}
+		key.Accelerator = acceleratorName
+		key.AcceleratorRevision = revision
+	}
+	for fragmentName, fragmentFolderName := range localFragments {
+		digest, err := packageDigest(fragmentFolderName, packaging)
+		if err != nil {
+			return "", err
+		}
+		key.FragmentDigests[fragmentName] = digest
+	}
+	return key.hash()
+}
+
+// acceleratorRevision returns the archive URL of the registered accelerator, it changes with every revision of the
+// accelerator
+func acceleratorRevision(serverUrl string, apiPrefix string, name string) (string, error) {
+	resp, err := http.Get(fmt.Sprintf("%s/%s/accelerators", serverUrl, apiPrefix))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return "", fmt.Errorf("the accelerators could not be listed, the server response code was: \"%v\"", resp.StatusCode)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	var uiResponse UiAcceleratorsApiResponse
+	if err := json.Unmarshal(body, &uiResponse); err != nil {
+		return "", err
+	}
+	for _, accelerator := range uiResponse.Emdedded.Accelerators {
+		if accelerator.Name == name {
+			if accelerator.ArchiveUrl == "" {
+				return "", fmt.Errorf("the revision of accelerator %s is not known yet", name)
+			}
+			return accelerator.ArchiveUrl, nil
+		}
+	}
+	return "", fmt.Errorf("accelerator %s not found", name)
+}
+
+func CacheCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "cache",
+		Short:   "Cache commands",
+		Long:    "Commands to manage the cache of generated projects",
+		Example: "tanzu accelerator cache --help",
+	}
+	cmd.AddCommand(CachePruneCmd())
+	return cmd
+}
+
+func CachePruneCmd() *cobra.Command {
+	var all bool
+	var olderThan time.Duration
+	var maxSize string
+	var pruneCmd = &cobra.Command{
+		Use:   "prune",
+		Short: "Remove projects from the cache of generated projects",
+		Long: `Remove projects from the cache of generated projects.
+
+The cache is used by generate and generate-from-local with --cache or when the ACC_GENERATE_CACHE environment variable
+is set to true. The projects are stored in the directory set with the ACC_CACHE_DIR environment variable, or in the
+tanzu-accelerator/generate directory of the cache directory of the user.
+
+The least recently used projects are removed until the cache is not larger than --max-size. Use --older-than to also
+remove the projects that weren't used for that long, or --all to empty the cache.
+`,
+		Example: `tanzu accelerator cache prune
+tanzu accelerator cache prune --older-than 168h --max-size 500Mi
+tanzu accelerator cache prune --all`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			size, err := parseCacheSize(maxSize)
+			if err != nil {
+				return err
+			}
+			if all {
+				size = 0
+			}
+			directory, err := cacheDirectory()
+			if err != nil {
+				return err
+			}
+			cache := &generateCache{directory: directory}
+			removed, freed, err := cache.prune(size, olderThan)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "removed %d cached project(s), freed %s\n", removed, formatSize(freed))
+			return nil
+		},
+	}
+	pruneCmd.Flags().BoolVar(&all, "all", false, "remove all the projects from the cache")
+	pruneCmd.Flags().DurationVar(&olderThan, "older-than", 0, "remove the projects that weren't used for longer than this duration")
+	pruneCmd.Flags().StringVar(&maxSize, "max-size", defaultCacheMaxSize, "remove the least recently used projects until the cache is not larger than this size")
+	pruneCmd.MarkFlagsMutuallyExclusive("all", "max-size")
+	pruneCmd.MarkFlagsMutuallyExclusive("all", "older-than")
+	return pruneCmd
+}
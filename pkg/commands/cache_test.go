@@ -0,0 +1,276 @@
+package commands
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("command run", func() {
+	var cacheDir string
+	var generated int32
+	var archiveUrl string
+	// broken makes the server respond with a truncated download or an invalid zip file
+	var broken string
+	var ts *httptest.Server
+
+	BeforeEach(func() {
+		var err error
+		cacheDir, err = os.MkdirTemp("", "cache")
+		Expect(err).NotTo(HaveOccurred())
+		os.Setenv("ACC_CACHE_DIR", cacheDir)
+		atomic.StoreInt32(&generated, 0)
+		archiveUrl = "http://source-controller/test-acc/1234.tar.gz"
+		broken = ""
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("/api/about", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(200)
+			w.Write([]byte("{}"))
+		})
+		mux.HandleFunc("/api/accelerators", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"_embedded":{"accelerators":[{"name":"test-acc","archiveUrl":%q}]}}`, archiveUrl)
+		})
+		mux.HandleFunc("/api/accelerators/zip", func(w http.ResponseWriter, r *http.Request) {
+			count := atomic.AddInt32(&generated, 1)
+			switch broken {
+			case "truncated":
+				w.Header().Set("Content-Length", "1000")
+				w.Write([]byte("PK"))
+				return
+			case "invalid":
+				w.Write([]byte("not a zip file"))
+				return
+			}
+			zipWriter := zip.NewWriter(w)
+			entry, _ := zipWriter.Create("test/README.md")
+			fmt.Fprintf(entry, "generated %d", count)
+			zipWriter.Close()
+		})
+		mux.HandleFunc("/api/accelerators/invoked", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(200)
+		})
+		ts = httptest.NewServer(mux)
+	})
+
+	AfterEach(func() {
+		ts.Close()
+		os.Unsetenv("ACC_CACHE_DIR")
+		os.Unsetenv("ACC_GENERATE_CACHE")
+		os.RemoveAll(cacheDir)
+	})
+
+	Context("LocalGenerateCmd() with the cache", func() {
+		var accDir string
+		var outputDir string
+
+		BeforeEach(func() {
+			var err error
+			accDir, err = os.MkdirTemp("", "acc")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(os.WriteFile(filepath.Join(accDir, "accelerator.yaml"), []byte("accelerator: {}\n"), 0644)).To(Succeed())
+			outputDir, err = os.MkdirTemp("", "generated")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(accDir)
+			os.RemoveAll(outputDir)
+		})
+
+		generate := func(args ...string) string {
+			generateCmd := LocalGenerateCmd()
+			b := new(bytes.Buffer)
+			generateCmd.SetOut(b)
+			generateCmd.SetErr(b)
+			generateCmd.SetArgs(append([]string{"--server-url", ts.URL, "--output-dir", outputDir, "--force"}, args...))
+			Expect(generateCmd.Execute()).To(Succeed())
+			return b.String()
+		}
+		readme := func() string {
+			content, err := os.ReadFile(filepath.Join(outputDir, "README.md"))
+			Expect(err).NotTo(HaveOccurred())
+			return string(content)
+		}
+
+		It("Should generate the project once for the same local files and options", func() {
+			Expect(generate("--accelerator-path", "test="+accDir, "--options", `{"a":1,"b":"x"}`, "--cache")).To(Equal("generated project test\n"))
+			Expect(generate("--accelerator-path", "test="+accDir, "--options", `{ "b": "x", "a": 1.0 }`, "--cache")).To(Equal("generated project test from the cache\n"))
+			Expect(readme()).To(Equal("generated 1"))
+			Expect(atomic.LoadInt32(&generated)).To(Equal(int32(1)))
+
+			Expect(generate("--accelerator-path", "test="+accDir, "--options", `{"a":2,"b":"x"}`, "--cache")).To(Equal("generated project test\n"))
+			Expect(os.WriteFile(filepath.Join(accDir, "README.md"), []byte("changed"), 0644)).To(Succeed())
+			Expect(generate("--accelerator-path", "test="+accDir, "--options", `{"a":2,"b":"x"}`, "--cache")).To(Equal("generated project test\n"))
+			Expect(readme()).To(Equal("generated 3"))
+			Expect(atomic.LoadInt32(&generated)).To(Equal(int32(3)))
+		})
+
+		It("Should use the revision of a registered accelerator", func() {
+			os.Setenv("ACC_GENERATE_CACHE", "true")
+			Expect(generate("--accelerator-name", "test-acc")).To(Equal("generated project test-acc\n"))
+			Expect(generate("--accelerator-name", "test-acc")).To(Equal("generated project test-acc from the cache\n"))
+			archiveUrl = "http://source-controller/test-acc/5678.tar.gz"
+			Expect(generate("--accelerator-name", "test-acc")).To(Equal("generated project test-acc\n"))
+			Expect(atomic.LoadInt32(&generated)).To(Equal(int32(2)))
+		})
+
+		It("Should not use the cache with --no-cache", func() {
+			os.Setenv("ACC_GENERATE_CACHE", "true")
+			Expect(generate("--accelerator-path", "test="+accDir)).To(Equal("generated project test\n"))
+			Expect(generate("--accelerator-path", "test="+accDir, "--no-cache")).To(Equal("generated project test\n"))
+			Expect(readme()).To(Equal("generated 2"))
+		})
+
+		It("Should not use the cache when the revision of the accelerator is unknown", func() {
+			Expect(generate("--accelerator-name", "missing", "--cache")).To(Equal("not using the cache of generated projects: accelerator missing not found\ngenerated project missing\n"))
+			entries, err := os.ReadDir(cacheDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(entries).To(BeEmpty())
+		})
+	})
+
+	Context("GenerateCmd() with the cache", func() {
+		It("Should write the cached zip file", func() {
+			outputDir, err := os.MkdirTemp("", "generated")
+			Expect(err).NotTo(HaveOccurred())
+			defer os.RemoveAll(outputDir)
+			generate := func() string {
+				generateCmd := GenerateCmd()
+				b := new(bytes.Buffer)
+				generateCmd.SetOut(b)
+				generateCmd.SetErr(b)
+				generateCmd.SetArgs([]string{"test-acc", "--server-url", ts.URL, "--output-dir", outputDir, "--cache"})
+				Expect(generateCmd.Execute()).To(Succeed())
+				return b.String()
+			}
+			zipFile := filepath.Join(outputDir, "test-acc.zip")
+			Expect(generate()).To(Equal("zip file " + zipFile + " created\n"))
+			Expect(os.Remove(zipFile)).To(Succeed())
+			Expect(generate()).To(Equal("zip file " + zipFile + " created from the cache\n"))
+			Expect(atomic.LoadInt32(&generated)).To(Equal(int32(1)))
+			zipReader, err := zip.OpenReader(zipFile)
+			Expect(err).NotTo(HaveOccurred())
+			defer zipReader.Close()
+			Expect(zipReader.File).To(HaveLen(1))
+		})
+	})
+
+	Context("GenerateCmd() with the cache and a broken download", func() {
+		var outputDir string
+
+		BeforeEach(func() {
+			var err error
+			outputDir, err = os.MkdirTemp("", "generated")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(outputDir)
+		})
+
+		generate := func() (string, error) {
+			generateCmd := GenerateCmd()
+			b := new(bytes.Buffer)
+			generateCmd.SetOut(b)
+			generateCmd.SetErr(b)
+			generateCmd.SetArgs([]string{"test-acc", "--server-url", ts.URL, "--output-dir", outputDir, "--cache"})
+			err := generateCmd.Execute()
+			return b.String(), err
+		}
+		cached := func() []string {
+			entries, _ := filepath.Glob(filepath.Join(cacheDir, "*.zip"))
+			return entries
+		}
+
+		It("Should fail and not store a truncated download", func() {
+			broken = "truncated"
+			_, err := generate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(HavePrefix("error downloading the generated project: "))
+			Expect(cached()).To(BeEmpty())
+		})
+
+		It("Should not store an invalid zip file", func() {
+			broken = "invalid"
+			out, err := generate()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(out).To(HavePrefix("could not store the generated project in the cache: zip: not a valid zip file\n"))
+			Expect(cached()).To(BeEmpty())
+
+			broken = ""
+			_, err = generate()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(atomic.LoadInt32(&generated)).To(Equal(int32(2)))
+		})
+	})
+
+	Context("CachePruneCmd()", func() {
+		// the entries are used one hour apart, the first one is the least recently used
+		writeEntries := func(sizes ...int) {
+			for i, size := range sizes {
+				path := filepath.Join(cacheDir, fmt.Sprintf("entry-%d.zip", i))
+				Expect(os.WriteFile(path, make([]byte, size), 0644)).To(Succeed())
+				used := time.Now().Add(time.Duration(i-len(sizes)) * time.Hour)
+				Expect(os.Chtimes(path, used, used)).To(Succeed())
+			}
+		}
+		prune := func(args ...string) string {
+			pruneCmd := CachePruneCmd()
+			b := new(bytes.Buffer)
+			pruneCmd.SetOut(b)
+			pruneCmd.SetErr(b)
+			pruneCmd.SetArgs(append([]string{}, args...))
+			Expect(pruneCmd.Execute()).To(Succeed())
+			return b.String()
+		}
+		remaining := func() []string {
+			names := []string{}
+			entries, err := os.ReadDir(cacheDir)
+			Expect(err).NotTo(HaveOccurred())
+			for _, entry := range entries {
+				names = append(names, entry.Name())
+			}
+			return names
+		}
+
+		It("Should remove the least recently used projects until the cache is small enough", func() {
+			writeEntries(1024, 1024, 1024)
+			Expect(prune("--max-size", "2Ki")).To(Equal("removed 1 cached project(s), freed 1.0 KiB\n"))
+			Expect(remaining()).To(ConsistOf("entry-1.zip", "entry-2.zip"))
+		})
+
+		It("Should remove the projects not used recently", func() {
+			writeEntries(1024, 1024, 1024)
+			Expect(prune("--older-than", "90m")).To(Equal("removed 2 cached project(s), freed 2.0 KiB\n"))
+			Expect(remaining()).To(ConsistOf("entry-2.zip"))
+		})
+
+		It("Should remove all the projects", func() {
+			writeEntries(1024, 2048)
+			Expect(prune("--all")).To(Equal("removed 2 cached project(s), freed 3.0 KiB\n"))
+			Expect(remaining()).To(BeEmpty())
+		})
+
+		It("Should not fail when there is no cache", func() {
+			os.Setenv("ACC_CACHE_DIR", filepath.Join(cacheDir, "missing"))
+			Expect(prune()).To(Equal("removed 0 cached project(s), freed 0 B\n"))
+		})
+
+		It("Should limit the size of the cache when storing projects", func() {
+			writeEntries(1024, 1024)
+			cache := &generateCache{directory: cacheDir, maxSize: 2560}
+			Expect(cache.put("new", bytes.NewReader(make([]byte, 1024)))).To(Succeed())
+			Expect(remaining()).To(ConsistOf("entry-1.zip", "new.zip"))
+		})
+	})
+})
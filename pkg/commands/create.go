@@ -29,6 +29,7 @@ func CreateCmd(ctx context.Context, c *cli.Config) *cobra.Command {
 Accelerator configuration options include:
 - Git repository URL and branch/tag where accelerator code and metadata is defined
 - Metadata like description, display-name, tags and icon-url
+- Labels and annotations for the accelerator resource
 
 The Git repository option is required. Metadata options are optional and will override any values for
 the same options specified in the accelerator metadata retrieved from the Git repository.
@@ -54,14 +55,25 @@ the same options specified in the accelerator metadata retrieved from the Git re
 				return errors.New("you must provide --source-image when using --local-path")
 			}
 
+			labels, err := applyMetadataChanges(nil, opts.Labels, "label")
+			if err != nil {
+				return err
+			}
+			annotations, err := applyMetadataChanges(nil, opts.Annotations, "annotation")
+			if err != nil {
+				return err
+			}
+
 			acc := &acceleratorv1alpha1.Accelerator{
 				TypeMeta: v1.TypeMeta{
 					APIVersion: "accelerator.tanzu.vmware.com/v1alpha1",
 					Kind:       "Accelerator",
 				},
 				ObjectMeta: v1.ObjectMeta{
-					Namespace: opts.Namespace,
-					Name:      args[0],
+					Namespace:   opts.Namespace,
+					Name:        args[0],
+					Labels:      labels,
+					Annotations: annotations,
 				},
 				Spec: acceleratorv1alpha1.AcceleratorSpec{
 					DisplayName: opts.DisplayName,
@@ -97,7 +109,10 @@ the same options specified in the accelerator metadata retrieved from the Git re
 			}
 
 			if opts.Interval != "" {
-				duration, _ := time.ParseDuration(opts.Interval)
+				duration, err := time.ParseDuration(opts.Interval)
+				if err != nil {
+					return fmt.Errorf("invalid interval %q: %v", opts.Interval, err)
+				}
 				interval := v1.Duration{
 					Duration: duration,
 				}
@@ -125,7 +140,7 @@ the same options specified in the accelerator metadata retrieved from the Git re
 				}
 			}
 
-			err := c.Create(ctx, acc)
+			err = c.Create(ctx, acc)
 			if err != nil {
 				fmt.Fprintf(cmd.OutOrStderr(), "Error creating accelerator %s\n", args[0])
 				return err
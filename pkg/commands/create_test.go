@@ -151,6 +151,49 @@ func TestCreateCommand(t *testing.T) {
 			},
 			ExpectOutput: "created accelerator test-accelerator in namespace accelerator-system\n",
 		},
+		{
+			Name: "Create Accelerator with labels and annotations",
+			Args: []string{acceleratorName, "--git-repository", gitRepoUrl, "--label", "team=alpha", "--label", "lifecycle=experimental", "--annotation", "owner=alpha-team@example.com"},
+			ExpectCreates: []client.Object{
+				&acceleratorv1alpha1.Accelerator{
+					ObjectMeta: v1.ObjectMeta{
+						Namespace: namespace,
+						Name:      acceleratorName,
+						Labels: map[string]string{
+							"team":      "alpha",
+							"lifecycle": "experimental",
+						},
+						Annotations: map[string]string{
+							"owner": "alpha-team@example.com",
+						},
+					},
+					Spec: acceleratorv1alpha1.AcceleratorSpec{
+						Git: &acceleratorv1alpha1.Git{
+							URL: gitRepoUrl,
+							Reference: &v1beta2.GitRepositoryRef{
+								Branch: gitBranch,
+							},
+						},
+					},
+				},
+			},
+			ExpectOutput: "created accelerator test-accelerator in namespace accelerator-system\n",
+		},
+		{
+			Name:        "Error creating Accelerator with invalid label",
+			Args:        []string{acceleratorName, "--git-repository", gitRepoUrl, "--label", "team"},
+			ShouldError: true,
+		},
+		{
+			Name:        "Error creating Accelerator with invalid label value",
+			Args:        []string{acceleratorName, "--git-repository", gitRepoUrl, "--label", "team=alpha team"},
+			ShouldError: true,
+		},
+		{
+			Name:        "Error creating Accelerator with invalid interval",
+			Args:        []string{acceleratorName, "--git-repository", gitRepoUrl, "--interval", "often"},
+			ShouldError: true,
+		},
 	}
 	table.Run(t, scheme, CreateCmd)
 }
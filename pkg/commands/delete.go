@@ -18,29 +18,93 @@ func DeleteCmd(ctx context.Context, c *cli.Config) *cobra.Command {
 	opts := DeleteOptions{}
 	var deleteCmd = &cobra.Command{
 		Use:   "delete",
-		Short: "Delete an accelerator",
-		Long:  `Delete the accelerator resource with the specified name.`,
+		Short: "Delete one or more accelerators",
+		Long: `Delete the accelerator resources with the specified names.
+
+Instead of providing names you can select the accelerators to delete using the --all flag, a label --selector
+or --tags. When the deletion involves more than a single named accelerator, the accelerators that will be
+deleted are listed and you are asked to confirm. Use --yes to skip the confirmation and --dry-run to only
+list the accelerators that would be deleted.
+`,
 		Args: func(cmd *cobra.Command, args []string) error {
-			if len(args) < 1 {
+			selecting := opts.All || opts.Selector != "" || len(opts.Tags) > 0
+			if len(args) < 1 && !selecting {
 				return errors.New("you must specify the name of the accelerator")
 			}
+			if len(args) > 0 && selecting {
+				return errors.New("you may not specify accelerator names together with --all, --selector or --tags")
+			}
+			if opts.All && (opts.Selector != "" || len(opts.Tags) > 0) {
+				return errors.New("you may not use --all together with --selector or --tags")
+			}
 			return nil
 		},
 		ValidArgsFunction: SuggestAcceleratorNamesFromConfig(context.Background(), c),
-		Example:           "tanzu accelerator delete <accelerator-name>",
+		Example: `tanzu accelerator delete <accelerator-name>
+tanzu accelerator delete <accelerator-name> <another-accelerator-name>
+tanzu accelerator delete --selector team=alpha --yes
+tanzu accelerator delete --tags hackathon --dry-run`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			accelerator := &acceleratorv1alpha1.Accelerator{}
-			err := c.Get(ctx, client.ObjectKey{Namespace: opts.Namespace, Name: args[0]}, accelerator)
-			if err != nil {
-				fmt.Fprintf(cmd.OutOrStderr(), "accelerator %s not found\n", args[0])
-				return err
-			}
-			err = c.Delete(ctx, accelerator)
-			if err != nil {
-				fmt.Fprintf(cmd.OutOrStderr(), "There was a problem trying to delete accelerator %s\n", args[0])
-				return err
-			}
-			fmt.Fprintf(cmd.OutOrStdout(), "deleted accelerator %s in namespace %s\n", args[0], opts.Namespace)
+			accelerators := []acceleratorv1alpha1.Accelerator{}
+			if len(args) > 0 {
+				for _, name := range args {
+					accelerator := acceleratorv1alpha1.Accelerator{}
+					err := c.Get(ctx, client.ObjectKey{Namespace: opts.Namespace, Name: name}, &accelerator)
+					if err != nil {
+						fmt.Fprintf(cmd.OutOrStderr(), "accelerator %s not found\n", name)
+						return err
+					}
+					accelerators = append(accelerators, accelerator)
+				}
+			} else {
+				listOpts, err := selectorListOptions(opts.Namespace, opts.Selector)
+				if err != nil {
+					return err
+				}
+				acceleratorList := &acceleratorv1alpha1.AcceleratorList{}
+				err = c.List(ctx, acceleratorList, listOpts...)
+				if err != nil {
+					fmt.Fprintf(cmd.OutOrStderr(), "There was an error listing accelerators\n")
+					return err
+				}
+				for _, accelerator := range acceleratorList.Items {
+					if len(opts.Tags) == 0 || contains(accelerator.Status.Tags, opts.Tags) {
+						accelerators = append(accelerators, accelerator)
+					}
+				}
+				if len(accelerators) == 0 {
+					c.Infof("No accelerators found.\n")
+					return nil
+				}
+			}
+
+			names := []string{}
+			for _, accelerator := range accelerators {
+				names = append(names, accelerator.Name)
+			}
+
+			if opts.DryRun {
+				for _, name := range names {
+					fmt.Fprintf(cmd.OutOrStdout(), "accelerator %s in namespace %s would be deleted (dry run)\n", name, opts.Namespace)
+				}
+				return nil
+			}
+
+			if !opts.Yes && len(args) != 1 {
+				if !confirmDeletion(c, cmd, "accelerator", opts.Namespace, names) {
+					c.Infof("Skipping deletion of accelerators in namespace %s\n", opts.Namespace)
+					return nil
+				}
+			}
+
+			for i := range accelerators {
+				err := c.Delete(ctx, &accelerators[i])
+				if err != nil {
+					fmt.Fprintf(cmd.OutOrStderr(), "There was a problem trying to delete accelerator %s\n", accelerators[i].Name)
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "deleted accelerator %s in namespace %s\n", accelerators[i].Name, opts.Namespace)
+			}
 			return nil
 		},
 	}
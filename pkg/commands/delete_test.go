@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"strings"
 	"testing"
 
 	acceleratorv1alpha1 "github.com/pivotal/acc-controller/api/v1alpha1"
@@ -22,6 +23,35 @@ func TestDeleteCommand(t *testing.T) {
 	gitBranch := "main"
 	namespace := "accelerator-system"
 
+	newAccelerator := func(name string, labels map[string]string, tags []string) *acceleratorv1alpha1.Accelerator {
+		return &acceleratorv1alpha1.Accelerator{
+			ObjectMeta: v1.ObjectMeta{
+				Namespace: namespace,
+				Name:      name,
+				Labels:    labels,
+			},
+			Spec: acceleratorv1alpha1.AcceleratorSpec{
+				Git: &acceleratorv1alpha1.Git{
+					URL: gitRepoUrl,
+					Reference: &v1beta2.GitRepositoryRef{
+						Branch: gitBranch,
+					},
+				},
+			},
+			Status: acceleratorv1alpha1.AcceleratorStatus{
+				Tags: tags,
+			},
+		}
+	}
+	deleteRef := func(name string) rtesting.DeleteRef {
+		return rtesting.DeleteRef{
+			Group:     "accelerator.apps.tanzu.vmware.com",
+			Kind:      "Accelerator",
+			Namespace: namespace,
+			Name:      name,
+		}
+	}
+
 	table := clitesting.CommandTestSuite{
 		{
 			Name:        "Missing args",
@@ -111,6 +141,114 @@ func TestDeleteCommand(t *testing.T) {
 				},
 			},
 		},
+		{
+			Name:        "Error names together with --all",
+			Args:        []string{acceleratorName, "--all"},
+			ShouldError: true,
+		},
+		{
+			Name:        "Error invalid selector",
+			Args:        []string{"--selector", "team in (alpha"},
+			ShouldError: true,
+		},
+		{
+			Name: "Delete multiple Accelerators by name",
+			Args: []string{"first", "second", "--yes"},
+			GivenObjects: []client.Object{
+				newAccelerator("first", nil, nil),
+				newAccelerator("second", nil, nil),
+				newAccelerator("third", nil, nil),
+			},
+			ExpectDeletes: []rtesting.DeleteRef{
+				deleteRef("first"),
+				deleteRef("second"),
+			},
+			ExpectOutput: `
+deleted accelerator first in namespace accelerator-system
+deleted accelerator second in namespace accelerator-system
+`,
+		},
+		{
+			Name: "Error multiple Accelerators when one is not found",
+			Args: []string{"first", acceleratorNotFound, "--yes"},
+			GivenObjects: []client.Object{
+				newAccelerator("first", nil, nil),
+			},
+			ExpectOutput: "accelerator non-existent not found\n",
+			ShouldError:  true,
+		},
+		{
+			Name: "Delete Accelerators matching tags",
+			Args: []string{"--tags", "java,hackathon", "--yes"},
+			GivenObjects: []client.Object{
+				newAccelerator("first", nil, []string{"java", "hackathon"}),
+				newAccelerator("second", nil, []string{"java"}),
+			},
+			ExpectDeletes: []rtesting.DeleteRef{
+				deleteRef("first"),
+			},
+			ExpectOutput: "deleted accelerator first in namespace accelerator-system\n",
+		},
+		{
+			Name: "Delete Accelerators matching selector after confirmation",
+			Args: []string{"--selector", "team=alpha"},
+			GivenObjects: []client.Object{
+				newAccelerator("first", map[string]string{"team": "alpha"}, nil),
+				newAccelerator("second", map[string]string{"team": "beta"}, nil),
+				newAccelerator("third", map[string]string{"team": "alpha"}, nil),
+			},
+			Stdin: []byte("y\n"),
+			ExpectDeletes: []rtesting.DeleteRef{
+				deleteRef("first"),
+				deleteRef("third"),
+			},
+			Verify: func(t *testing.T, output string, err error) {
+				for _, expected := range []string{
+					"The following accelerators in namespace accelerator-system will be deleted:\n  first\n  third\n",
+					"Really delete 2 accelerator(s)?",
+					"deleted accelerator first in namespace accelerator-system\n",
+					"deleted accelerator third in namespace accelerator-system\n",
+				} {
+					if !strings.Contains(output, expected) {
+						t.Errorf("expected output to contain %q, got %q", expected, output)
+					}
+				}
+			},
+		},
+		{
+			Name: "Skip deleting all Accelerators when not confirmed",
+			Args: []string{"--all"},
+			GivenObjects: []client.Object{
+				newAccelerator("first", nil, nil),
+				newAccelerator("second", nil, nil),
+			},
+			Stdin: []byte("n\n"),
+			Verify: func(t *testing.T, output string, err error) {
+				if !strings.Contains(output, "Skipping deletion of accelerators in namespace accelerator-system\n") {
+					t.Errorf("expected deletion to be skipped, got %q", output)
+				}
+			},
+		},
+		{
+			Name: "Dry run of deleting all Accelerators",
+			Args: []string{"--all", "--dry-run"},
+			GivenObjects: []client.Object{
+				newAccelerator("first", nil, nil),
+				newAccelerator("second", nil, nil),
+			},
+			ExpectOutput: `
+accelerator first in namespace accelerator-system would be deleted (dry run)
+accelerator second in namespace accelerator-system would be deleted (dry run)
+`,
+		},
+		{
+			Name: "No Accelerators matching selector",
+			Args: []string{"--selector", "team=gamma", "--yes"},
+			GivenObjects: []client.Object{
+				newAccelerator("first", map[string]string{"team": "alpha"}, nil),
+			},
+			ExpectOutput: "No accelerators found.\n",
+		},
 	}
 	table.Run(t, scheme, DeleteCmd)
 
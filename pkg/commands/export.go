@@ -0,0 +1,216 @@
+/*
+Copyright 2021-2023 VMware, Inc. All Rights Reserved.
+*/
+package commands
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	acceleratorv1alpha1 "github.com/pivotal/acc-controller/api/v1alpha1"
+	"github.com/spf13/cobra"
+	cli "github.com/vmware-tanzu/apps-cli-plugin/pkg/cli-runtime"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+type exportedResource struct {
+	Kind     string
+	Prefix   string
+	Name     string
+	Manifest []byte
+}
+
+func ExportCmd(ctx context.Context, c *cli.Config) *cobra.Command {
+	opts := ExportOptions{}
+	var exportCmd = &cobra.Command{
+		Use:   "export",
+		Short: "Export accelerators and fragments as manifests",
+		Long: `Export accelerator and accelerator fragment resources as manifests that can be used with the apply command.
+
+The resources are read from the namespace and can be limited to the specified names, a label --selector or
+--tags. Since accelerator fragments don't have tags, only accelerators are exported when --tags is used. Nothing is
+exported when no resource matches one of the specified names.
+
+The status and any fields that are set by the cluster, like the namespace, resourceVersion, uid and managedFields,
+are removed from the exported manifests, as well as the last-applied-configuration annotation of kubectl and the
+requestedAt annotation set by --reconcile. The manifests are written to stdout as a multi-document YAML manifest,
+or using --file to a file. Use --output-dir to write one file per resource instead.
+`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if opts.Kind != "all" && opts.Kind != "accelerator" && opts.Kind != "fragment" {
+				return fmt.Errorf("invalid kind %q, must be one of accelerator, fragment or all", opts.Kind)
+			}
+			if opts.Kind == "fragment" && len(opts.Tags) > 0 {
+				return fmt.Errorf("you may not use --tags when exporting accelerator fragments")
+			}
+			return nil
+		},
+		ValidArgsFunction: SuggestAcceleratorNamesFromConfig(context.Background(), c),
+		Example: `tanzu accelerator export
+tanzu accelerator export <accelerator-name> <fragment-name> --file catalog.yaml
+tanzu accelerator export --selector team=alpha --output-dir ./accelerators`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			listOpts, err := selectorListOptions(opts.Namespace, opts.Selector)
+			if err != nil {
+				return err
+			}
+
+			resources := []exportedResource{}
+			found := map[string]bool{}
+			if opts.Kind != "accelerator" && len(opts.Tags) == 0 {
+				fragmentList := &acceleratorv1alpha1.FragmentList{}
+				err = c.List(ctx, fragmentList, listOpts...)
+				if err != nil {
+					fmt.Fprintf(cmd.OutOrStderr(), "There was an error listing accelerator fragments\n")
+					return err
+				}
+				for i := range fragmentList.Items {
+					fragment := &fragmentList.Items[i]
+					if len(args) > 0 && !contains(args, []string{fragment.Name}) {
+						continue
+					}
+					manifest, err := exportManifest(fragment, "Fragment")
+					if err != nil {
+						return err
+					}
+					resources = append(resources, exportedResource{Kind: "accelerator fragment", Prefix: "fragment", Name: fragment.Name, Manifest: manifest})
+					found[fragment.Name] = true
+				}
+			}
+			if opts.Kind != "fragment" {
+				acceleratorList := &acceleratorv1alpha1.AcceleratorList{}
+				err = c.List(ctx, acceleratorList, listOpts...)
+				if err != nil {
+					fmt.Fprintf(cmd.OutOrStderr(), "There was an error listing accelerators\n")
+					return err
+				}
+				for i := range acceleratorList.Items {
+					accelerator := &acceleratorList.Items[i]
+					if len(args) > 0 && !contains(args, []string{accelerator.Name}) {
+						continue
+					}
+					if len(opts.Tags) > 0 && !contains(accelerator.Status.Tags, opts.Tags) {
+						continue
+					}
+					manifest, err := exportManifest(accelerator, "Accelerator")
+					if err != nil {
+						return err
+					}
+					resources = append(resources, exportedResource{Kind: "accelerator", Prefix: "accelerator", Name: accelerator.Name, Manifest: manifest})
+					found[accelerator.Name] = true
+				}
+			}
+
+			// nothing is exported when one of the requested resources is missing, a misspelled name would go unnoticed
+			missing := []string{}
+			for _, name := range args {
+				if !found[name] && !containsString(missing, name) {
+					missing = append(missing, name)
+				}
+			}
+			if len(missing) > 0 {
+				kind := "accelerator or accelerator fragment"
+				if opts.Kind == "accelerator" || len(opts.Tags) > 0 {
+					kind = "accelerator"
+				} else if opts.Kind == "fragment" {
+					kind = "accelerator fragment"
+				}
+				return fmt.Errorf("no %s named %s found in namespace %s", kind, strings.Join(missing, ", "), opts.Namespace)
+			}
+
+			if len(resources) == 0 {
+				c.Infof("No accelerators or accelerator fragments found.\n")
+				return nil
+			}
+
+			if opts.OutputDir != "" {
+				err = os.MkdirAll(opts.OutputDir, 0755)
+				if err != nil {
+					return err
+				}
+				for _, resource := range resources {
+					path := filepath.Join(opts.OutputDir, fmt.Sprintf("%s-%s.yaml", resource.Prefix, resource.Name))
+					err = os.WriteFile(path, resource.Manifest, 0644)
+					if err != nil {
+						fmt.Fprintf(cmd.OutOrStderr(), "Error writing file %s\n", path)
+						return err
+					}
+					fmt.Fprintf(cmd.OutOrStdout(), "exported %s %s to %s\n", resource.Kind, resource.Name, path)
+				}
+				return nil
+			}
+
+			manifests := [][]byte{}
+			for _, resource := range resources {
+				manifests = append(manifests, resource.Manifest)
+			}
+			content := bytes.Join(manifests, []byte("---\n"))
+			if opts.File == "" {
+				cmd.OutOrStdout().Write(content)
+				return nil
+			}
+			err = os.WriteFile(opts.File, content, 0644)
+			if err != nil {
+				fmt.Fprintf(cmd.OutOrStderr(), "Error writing file %s\n", opts.File)
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "exported %d resource(s) to %s\n", len(resources), opts.File)
+			return nil
+		},
+	}
+	opts.DefineFlags(ctx, exportCmd, c)
+	return exportCmd
+}
+
+// exportedAnnotationsToRemove are the annotations set by kubectl and by --reconcile that are specific to the cluster
+// the resources are exported from
+var exportedAnnotationsToRemove = []string{
+	"kubectl.kubernetes.io/last-applied-configuration",
+	"reconcile.accelerator.apps.tanzu.vmware.com/requestedAt",
+}
+
+// withoutClusterAnnotations returns a copy of the annotations without the annotations that are specific to the cluster,
+// or nil when no annotation is left
+func withoutClusterAnnotations(annotations map[string]string) map[string]string {
+	var result map[string]string
+	for name, value := range annotations {
+		if containsString(exportedAnnotationsToRemove, name) {
+			continue
+		}
+		if result == nil {
+			result = map[string]string{}
+		}
+		result[name] = value
+	}
+	return result
+}
+
+// exportManifest converts the resource to a YAML manifest without the status and the fields and annotations that are
+// set by the cluster
+func exportManifest(obj client.Object, kind string) ([]byte, error) {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	manifest := &unstructured.Unstructured{Object: content}
+	manifest.SetAPIVersion(acceleratorv1alpha1.GroupVersion.String())
+	manifest.SetKind(kind)
+	for _, field := range []string{"namespace", "resourceVersion", "uid", "managedFields", "generation", "creationTimestamp", "selfLink"} {
+		unstructured.RemoveNestedField(manifest.Object, "metadata", field)
+	}
+	annotations := withoutClusterAnnotations(manifest.GetAnnotations())
+	if len(annotations) == 0 {
+		unstructured.RemoveNestedField(manifest.Object, "metadata", "annotations")
+	} else {
+		manifest.SetAnnotations(annotations)
+	}
+	unstructured.RemoveNestedField(manifest.Object, "status")
+	return yaml.Marshal(manifest.Object)
+}
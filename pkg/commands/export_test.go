@@ -0,0 +1,271 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	acceleratorv1alpha1 "github.com/pivotal/acc-controller/api/v1alpha1"
+	"github.com/pivotal/acc-controller/fluxcd/api/v1beta2"
+	clitesting "github.com/vmware-tanzu/apps-cli-plugin/pkg/cli-runtime/testing"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestExportCommand(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = acceleratorv1alpha1.AddToScheme(scheme)
+
+	namespace := "accelerator-system"
+	outputDir := t.TempDir()
+
+	accelerator := &acceleratorv1alpha1.Accelerator{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "test-accelerator",
+			Namespace:       namespace,
+			ResourceVersion: "42",
+			UID:             "d3b07384-d113-4ec6-a5d8-0f7f2a0b8a6c",
+			Labels:          map[string]string{"team": "alpha"},
+			ManagedFields: []metav1.ManagedFieldsEntry{
+				{Manager: "kubectl", Operation: metav1.ManagedFieldsOperationApply},
+			},
+		},
+		Spec: acceleratorv1alpha1.AcceleratorSpec{
+			Description: "Lorem Ipsum",
+			Tags:        []string{"java"},
+			Git: &acceleratorv1alpha1.Git{
+				URL: "https://www.test.com",
+				Reference: &v1beta2.GitRepositoryRef{
+					Branch: "main",
+				},
+				SecretRef: &meta.LocalObjectReference{
+					Name: "mysecret",
+				},
+			},
+		},
+		Status: acceleratorv1alpha1.AcceleratorStatus{
+			Tags: []string{"java"},
+		},
+	}
+	fragment := &acceleratorv1alpha1.Fragment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-fragment",
+			Namespace: namespace,
+		},
+		Spec: acceleratorv1alpha1.FragmentSpec{
+			DisplayName: "Test Fragment",
+			Git: &acceleratorv1alpha1.Git{
+				URL: "https://www.test.com",
+				Reference: &v1beta2.GitRepositoryRef{
+					Branch: "main",
+				},
+			},
+		},
+	}
+
+	table := clitesting.CommandTestSuite{
+		{
+			Name: "Nothing to export",
+			Args: []string{},
+			ExpectOutput: `
+No accelerators or accelerator fragments found.
+`,
+		},
+		{
+			Name:        "Error exporting unknown kind",
+			Args:        []string{"--kind", "workload"},
+			ShouldError: true,
+		},
+		{
+			Name: "Error listing accelerators",
+			Args: []string{},
+			WithReactors: []clitesting.ReactionFunc{
+				clitesting.InduceFailure("list", "AcceleratorList"),
+			},
+			ShouldError:  true,
+			ExpectOutput: "There was an error listing accelerators\n",
+		},
+		{
+			Name:         "Export accelerators and fragments",
+			Args:         []string{},
+			GivenObjects: []client.Object{accelerator, fragment},
+			ExpectOutput: `
+apiVersion: accelerator.apps.tanzu.vmware.com/v1alpha1
+kind: Fragment
+metadata:
+  name: test-fragment
+spec:
+  displayName: Test Fragment
+  git:
+    ref:
+      branch: main
+    url: https://www.test.com
+---
+apiVersion: accelerator.apps.tanzu.vmware.com/v1alpha1
+kind: Accelerator
+metadata:
+  labels:
+    team: alpha
+  name: test-accelerator
+spec:
+  description: Lorem Ipsum
+  git:
+    ref:
+      branch: main
+    secretRef:
+      name: mysecret
+    url: https://www.test.com
+  tags:
+  - java
+`,
+		},
+		{
+			Name:         "Export accelerators by tag",
+			Args:         []string{"--tags", "java"},
+			GivenObjects: []client.Object{accelerator, fragment},
+			Verify: func(t *testing.T, output string, err error) {
+				if strings.Contains(output, "test-fragment") || !strings.Contains(output, "name: test-accelerator") {
+					t.Errorf("expected only the accelerator to be exported, got %q", output)
+				}
+			},
+		},
+		{
+			Name:         "Export fragments by name",
+			Args:         []string{"test-fragment"},
+			GivenObjects: []client.Object{accelerator, fragment},
+			Verify: func(t *testing.T, output string, err error) {
+				if strings.Contains(output, "test-accelerator") || !strings.Contains(output, "name: test-fragment") {
+					t.Errorf("expected only the fragment to be exported, got %q", output)
+				}
+			},
+		},
+		{
+			Name:         "Error exporting unknown names",
+			Args:         []string{"test-fragment", "test-acelerator", "missing"},
+			GivenObjects: []client.Object{accelerator, fragment},
+			ShouldError:  true,
+			Verify: func(t *testing.T, output string, err error) {
+				if expected := "no accelerator or accelerator fragment named test-acelerator, missing found in namespace accelerator-system"; err == nil || err.Error() != expected {
+					t.Errorf("expected error %q, got %v", expected, err)
+				}
+				if output != "" {
+					t.Errorf("expected nothing to be exported, got %q", output)
+				}
+			},
+		},
+		{
+			Name:         "Error exporting accelerators by unknown name",
+			Args:         []string{"test-fragment", "--kind", "accelerator"},
+			GivenObjects: []client.Object{accelerator, fragment},
+			ShouldError:  true,
+			Verify: func(t *testing.T, output string, err error) {
+				if expected := "no accelerator named test-fragment found in namespace accelerator-system"; err == nil || err.Error() != expected {
+					t.Errorf("expected error %q, got %v", expected, err)
+				}
+			},
+		},
+		{
+			Name:         "Export resources to directory",
+			Args:         []string{"--output-dir", outputDir},
+			GivenObjects: []client.Object{accelerator, fragment},
+			ExpectOutput: "exported accelerator fragment test-fragment to " + filepath.Join(outputDir, "fragment-test-fragment.yaml") + "\n" +
+				"exported accelerator test-accelerator to " + filepath.Join(outputDir, "accelerator-test-accelerator.yaml") + "\n",
+			Verify: func(t *testing.T, output string, err error) {
+				content, err := os.ReadFile(filepath.Join(outputDir, "accelerator-test-accelerator.yaml"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, field := range []string{"status:", "resourceVersion:", "uid:", "managedFields:", "namespace:"} {
+					if strings.Contains(string(content), field) {
+						t.Errorf("expected %s to be removed from the exported manifest", field)
+					}
+				}
+			},
+		},
+	}
+	table.Run(t, scheme, ExportCmd)
+}
+
+func TestExportRoundTripsWithApply(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = acceleratorv1alpha1.AddToScheme(scheme)
+
+	namespace := "accelerator-system"
+	manifestFile := filepath.Join(t.TempDir(), "catalog.yaml")
+
+	accelerator := &acceleratorv1alpha1.Accelerator{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-accelerator",
+			Namespace: namespace,
+			Labels:    map[string]string{"team": "alpha"},
+			Annotations: map[string]string{
+				"owner": "alpha-team@example.com",
+				"kubectl.kubernetes.io/last-applied-configuration": `{"kind":"Accelerator"}`,
+			},
+		},
+		Spec: acceleratorv1alpha1.AcceleratorSpec{
+			Description: "Lorem Ipsum",
+			Git: &acceleratorv1alpha1.Git{
+				URL: "https://www.test.com",
+				Reference: &v1beta2.GitRepositoryRef{
+					Branch: "main",
+				},
+			},
+		},
+	}
+	fragment := &acceleratorv1alpha1.Fragment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-fragment",
+			Namespace: namespace,
+			Annotations: map[string]string{
+				"kubectl.kubernetes.io/last-applied-configuration":        `{"kind":"Fragment"}`,
+				"reconcile.accelerator.apps.tanzu.vmware.com/requestedAt": "2023-05-01T10:00:00Z",
+			},
+		},
+		Spec: acceleratorv1alpha1.FragmentSpec{
+			DisplayName: "Test Fragment",
+			Git: &acceleratorv1alpha1.Git{
+				URL: "https://www.test.com",
+				Reference: &v1beta2.GitRepositoryRef{
+					Branch: "main",
+				},
+			},
+		},
+	}
+
+	clitesting.CommandTestSuite{
+		{
+			Name:         "Export resources to file",
+			Args:         []string{"--file", manifestFile},
+			GivenObjects: []client.Object{accelerator, fragment},
+			ExpectOutput: "exported 2 resource(s) to " + manifestFile + "\n",
+		},
+	}.Run(t, scheme, ExportCmd)
+
+	manifest, err := os.ReadFile(manifestFile)
+	if err != nil {
+		t.Fatalf("error reading exported manifest: %v", err)
+	}
+	if strings.Count(string(manifest), "annotations:") != 1 || strings.Contains(string(manifest), "last-applied-configuration") || strings.Contains(string(manifest), "requestedAt") {
+		t.Errorf("expected the cluster specific annotations to be removed, got:\n%s", manifest)
+	}
+
+	// the annotations set by the cluster are not applied, the other annotations are
+	exportedAccelerator := accelerator.DeepCopy()
+	exportedAccelerator.Annotations = map[string]string{"owner": "alpha-team@example.com"}
+	exportedFragment := fragment.DeepCopy()
+	exportedFragment.Annotations = nil
+
+	clitesting.CommandTestSuite{
+		{
+			Name:          "Apply exported resources",
+			Args:          []string{"--filename", manifestFile},
+			ExpectCreates: []client.Object{exportedFragment, exportedAccelerator},
+			ExpectOutput: "created accelerator fragment test-fragment in namespace accelerator-system\n" +
+				"created accelerator test-accelerator in namespace accelerator-system\n",
+		},
+	}.Run(t, scheme, ApplyCmd)
+}
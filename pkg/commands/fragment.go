@@ -23,6 +23,7 @@ func FragmentCmd(ctx context.Context, c *cli.Config) *cobra.Command {
 	cmd.AddCommand(FragmentGetCmd(ctx, c))
 	cmd.AddCommand(FragmentUpdateCmd(ctx, c))
 	cmd.AddCommand(FragmentDeleteCmd(ctx, c))
+	cmd.AddCommand(FragmentInitCmd(ctx, c))
 
 	return cmd
 }
@@ -119,6 +119,35 @@ func TestFragmentCreateCommand(t *testing.T) {
 			},
 			ExpectOutput: "created accelerator fragment test-fragment in namespace accelerator-system\n",
 		},
+		{
+			Name: "Create accelerator fragment with labels",
+			Args: []string{fragmentName, "--git-repository", gitRepoUrl, "--label", "team=alpha"},
+			ExpectCreates: []client.Object{
+				&acceleratorv1alpha1.Fragment{
+					ObjectMeta: v1.ObjectMeta{
+						Namespace: namespace,
+						Name:      fragmentName,
+						Labels: map[string]string{
+							"team": "alpha",
+						},
+					},
+					Spec: acceleratorv1alpha1.FragmentSpec{
+						Git: &acceleratorv1alpha1.Git{
+							URL: gitRepoUrl,
+							Reference: &v1beta2.GitRepositoryRef{
+								Branch: gitBranch,
+							},
+						},
+					},
+				},
+			},
+			ExpectOutput: "created accelerator fragment test-fragment in namespace accelerator-system\n",
+		},
+		{
+			Name:        "Error creating Fragment with invalid interval",
+			Args:        []string{fragmentName, "--git-repository", gitRepoUrl, "--interval", "often"},
+			ShouldError: true,
+		},
 	}
 	table.Run(t, scheme, FragmentCreateCmd)
 }
@@ -15,32 +15,91 @@ import (
 )
 
 func FragmentDeleteCmd(ctx context.Context, c *cli.Config) *cobra.Command {
-	opts := DeleteOptions{}
+	opts := FragmentDeleteOptions{}
 	var deleteCmd = &cobra.Command{
 		Use:   "delete",
-		Short: "Delete an accelerator fragment",
-		Long:  `Delete the accelerator fragment resource with the specified name.`,
+		Short: "Delete one or more accelerator fragments",
+		Long: `Delete the accelerator fragment resources with the specified names.
+
+Instead of providing names you can select the accelerator fragments to delete using the --all flag or a label
+--selector. When the deletion involves more than a single named accelerator fragment, the accelerator fragments
+that will be deleted are listed and you are asked to confirm. Use --yes to skip the confirmation and --dry-run
+to only list the accelerator fragments that would be deleted.
+`,
 		Args: func(cmd *cobra.Command, args []string) error {
-			if len(args) < 1 {
+			selecting := opts.All || opts.Selector != ""
+			if len(args) < 1 && !selecting {
 				return errors.New("you must specify the name of the accelerator fragment")
 			}
+			if len(args) > 0 && selecting {
+				return errors.New("you may not specify accelerator fragment names together with --all or --selector")
+			}
+			if opts.All && opts.Selector != "" {
+				return errors.New("you may not use --all together with --selector")
+			}
 			return nil
 		},
 		ValidArgsFunction: SuggestFragmentNamesFromConfig(context.Background(), c),
-		Example:           "tanzu accelerator fragment delete <fragment-name>",
+		Example: `tanzu accelerator fragment delete <fragment-name>
+tanzu accelerator fragment delete <fragment-name> <another-fragment-name>
+tanzu accelerator fragment delete --all --dry-run`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			fragment := &acceleratorv1alpha1.Fragment{}
-			err := c.Get(ctx, client.ObjectKey{Namespace: opts.Namespace, Name: args[0]}, fragment)
-			if err != nil {
-				fmt.Fprintf(cmd.OutOrStderr(), "accelerator fragment %s not found\n", args[0])
-				return err
-			}
-			err = c.Delete(ctx, fragment)
-			if err != nil {
-				fmt.Fprintf(cmd.OutOrStderr(), "There was a problem trying to delete accelerator fragment %s\n", args[0])
-				return err
-			}
-			fmt.Fprintf(cmd.OutOrStdout(), "deleted accelerator fragment %s in namespace %s\n", args[0], opts.Namespace)
+			fragments := []acceleratorv1alpha1.Fragment{}
+			if len(args) > 0 {
+				for _, name := range args {
+					fragment := acceleratorv1alpha1.Fragment{}
+					err := c.Get(ctx, client.ObjectKey{Namespace: opts.Namespace, Name: name}, &fragment)
+					if err != nil {
+						fmt.Fprintf(cmd.OutOrStderr(), "accelerator fragment %s not found\n", name)
+						return err
+					}
+					fragments = append(fragments, fragment)
+				}
+			} else {
+				listOpts, err := selectorListOptions(opts.Namespace, opts.Selector)
+				if err != nil {
+					return err
+				}
+				fragmentList := &acceleratorv1alpha1.FragmentList{}
+				err = c.List(ctx, fragmentList, listOpts...)
+				if err != nil {
+					fmt.Fprintf(cmd.OutOrStderr(), "There was an error listing accelerator fragments\n")
+					return err
+				}
+				fragments = fragmentList.Items
+				if len(fragments) == 0 {
+					c.Infof("No accelerator fragments found.\n")
+					return nil
+				}
+			}
+
+			names := []string{}
+			for _, fragment := range fragments {
+				names = append(names, fragment.Name)
+			}
+
+			if opts.DryRun {
+				for _, name := range names {
+					fmt.Fprintf(cmd.OutOrStdout(), "accelerator fragment %s in namespace %s would be deleted (dry run)\n", name, opts.Namespace)
+				}
+				return nil
+			}
+
+			if !opts.Yes && len(args) != 1 {
+				if !confirmDeletion(c, cmd, "accelerator fragment", opts.Namespace, names) {
+					c.Infof("Skipping deletion of accelerator fragments in namespace %s\n", opts.Namespace)
+					return nil
+				}
+			}
+
+			for i := range fragments {
+				err := c.Delete(ctx, &fragments[i])
+				if err != nil {
+					fmt.Fprintf(cmd.OutOrStderr(), "There was a problem trying to delete accelerator fragment %s\n", fragments[i].Name)
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "deleted accelerator fragment %s in namespace %s\n", fragments[i].Name, opts.Namespace)
+			}
 			return nil
 		},
 	}
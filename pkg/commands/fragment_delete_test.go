@@ -22,6 +22,32 @@ func TestFragmentDeleteCommand(t *testing.T) {
 	gitBranch := "main"
 	namespace := "accelerator-system"
 
+	newFragment := func(name string, labels map[string]string) *acceleratorv1alpha1.Fragment {
+		return &acceleratorv1alpha1.Fragment{
+			ObjectMeta: v1.ObjectMeta{
+				Namespace: namespace,
+				Name:      name,
+				Labels:    labels,
+			},
+			Spec: acceleratorv1alpha1.FragmentSpec{
+				Git: &acceleratorv1alpha1.Git{
+					URL: gitRepoUrl,
+					Reference: &v1beta2.GitRepositoryRef{
+						Branch: gitBranch,
+					},
+				},
+			},
+		}
+	}
+	deleteRef := func(name string) rtesting.DeleteRef {
+		return rtesting.DeleteRef{
+			Group:     "accelerator.apps.tanzu.vmware.com",
+			Kind:      "Fragment",
+			Namespace: namespace,
+			Name:      name,
+		}
+	}
+
 	table := clitesting.CommandTestSuite{
 		{
 			Name:        "Missing args",
@@ -111,6 +137,35 @@ func TestFragmentDeleteCommand(t *testing.T) {
 				},
 			},
 		},
+		{
+			Name:        "Error names together with --selector",
+			Args:        []string{fragmentName, "--selector", "team=alpha"},
+			ShouldError: true,
+		},
+		{
+			Name: "Delete Fragments matching selector",
+			Args: []string{"--selector", "team=alpha", "--yes"},
+			GivenObjects: []client.Object{
+				newFragment("first", map[string]string{"team": "alpha"}),
+				newFragment("second", map[string]string{"team": "beta"}),
+			},
+			ExpectDeletes: []rtesting.DeleteRef{
+				deleteRef("first"),
+			},
+			ExpectOutput: "deleted accelerator fragment first in namespace accelerator-system\n",
+		},
+		{
+			Name: "Dry run of deleting multiple Fragments by name",
+			Args: []string{"first", "second", "--dry-run"},
+			GivenObjects: []client.Object{
+				newFragment("first", nil),
+				newFragment("second", nil),
+			},
+			ExpectOutput: `
+accelerator fragment first in namespace accelerator-system would be deleted (dry run)
+accelerator fragment second in namespace accelerator-system would be deleted (dry run)
+`,
+		},
 	}
 	table.Run(t, scheme, FragmentDeleteCmd)
 
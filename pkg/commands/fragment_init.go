@@ -0,0 +1,108 @@
+/*
+Copyright 2022-2023 VMware, Inc. All Rights Reserved.
+*/
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	acceleratorv1alpha1 "github.com/pivotal/acc-controller/api/v1alpha1"
+	"github.com/spf13/cobra"
+	cli "github.com/vmware-tanzu/apps-cli-plugin/pkg/cli-runtime"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+func FragmentInitCmd(ctx context.Context, c *cli.Config) *cobra.Command {
+	opts := FragmentInitOptions{}
+	var initCmd = &cobra.Command{
+		Use:   "init",
+		Short: "Create the directory of a new accelerator fragment",
+		Long: `Create a directory with the files needed to start authoring a new accelerator fragment.
+
+The directory contains an annotated accelerator.yaml with a sample option for each data type, a .gitignore and a
+k8s-resource.yaml with the Fragment manifest. The names of the options are prefixed with the name of the fragment,
+so that they don't clash with the options of the accelerators importing it.
+
+The directory can be used with generate-from-local --fragment-paths right away. Once the files are pushed to the Git
+repository, the Fragment can be created with apply --filename <directory>/k8s-resource.yaml.
+`,
+		Example: `tanzu accelerator fragment init my-fragment
+tanzu accelerator fragment init my-fragment --directory ./fragments/my-fragment --git-repository https://github.com/example/fragments --git-sub-path my-fragment`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 1 {
+				return errors.New("you must pass the name of the accelerator fragment")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+			if msgs := validation.IsDNS1123Subdomain(name); len(msgs) > 0 {
+				return fmt.Errorf("invalid accelerator fragment name %q: %s", name, strings.Join(msgs, ", "))
+			}
+			directory := opts.Directory
+			if directory == "" {
+				directory = name
+			}
+			displayName := opts.DisplayName
+			if displayName == "" {
+				displayName = name
+			}
+
+			metadata, err := renderScaffold(scaffoldFragmentTemplate, scaffoldValues{
+				Name:         name,
+				OptionPrefix: optionPrefix(name),
+				ManifestFile: scaffoldManifestFile,
+			})
+			if err != nil {
+				return err
+			}
+
+			fragment := &acceleratorv1alpha1.Fragment{
+				ObjectMeta: v1.ObjectMeta{Name: name},
+				Spec: acceleratorv1alpha1.FragmentSpec{
+					DisplayName: displayName,
+					Git:         scaffoldGit(name, opts.GitRepoUrl, opts.GitBranch, opts.GitSubPath),
+				},
+			}
+			manifest, err := exportManifest(fragment, "Fragment")
+			if err != nil {
+				return err
+			}
+
+			err = writeScaffold(directory, opts.Force, map[string]string{
+				acceleratorMetadataFile: metadata,
+				scaffoldManifestFile:    string(manifest),
+				".gitignore":            scaffoldGitignore,
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "created accelerator fragment %s in %s\n", name, directory)
+			printScaffoldNextSteps(cmd, name, directory, opts.GitRepoUrl == "")
+			return nil
+		},
+	}
+	opts.DefineFlags(ctx, initCmd, c)
+	return initCmd
+}
+
+// optionPrefix turns the name of the fragment into the camel case prefix of its option names, for example
+// java-version becomes javaVersion
+func optionPrefix(name string) string {
+	prefix := ""
+	for i, part := range strings.FieldsFunc(name, func(r rune) bool { return r == '-' || r == '.' }) {
+		if i > 0 {
+			part = strings.ToUpper(part[:1]) + part[1:]
+		}
+		prefix += part
+	}
+	if prefix[0] >= '0' && prefix[0] <= '9' {
+		// option names need to start with a letter
+		prefix = "fragment" + prefix
+	}
+	return prefix
+}
@@ -0,0 +1,80 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	acceleratorv1alpha1 "github.com/pivotal/acc-controller/api/v1alpha1"
+	clitesting "github.com/vmware-tanzu/apps-cli-plugin/pkg/cli-runtime/testing"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestFragmentInitCommand(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = acceleratorv1alpha1.AddToScheme(scheme)
+
+	directory := filepath.Join(t.TempDir(), "java-version")
+	accelerator := filepath.Join(t.TempDir(), "my-accelerator")
+
+	table := clitesting.CommandTestSuite{
+		{
+			Name:        "Missing args",
+			Args:        []string{},
+			ShouldError: true,
+		},
+		{
+			Name:        "Error invalid name",
+			Args:        []string{"Java Version", "--directory", directory},
+			ShouldError: true,
+		},
+		{
+			Name: "Init fragment",
+			Args: []string{"java-version", "--directory", directory, "--git-repository", "https://github.com/example/fragments", "--git-sub-path", "java-version"},
+			ExpectOutput: `
+created accelerator fragment java-version in ` + directory + `
+
+Next steps:
+  edit ` + filepath.Join(directory, "accelerator.yaml") + ` to define the options and the engine
+  push the files to the Git repository and run: tanzu accelerator apply --filename ` + filepath.Join(directory, "k8s-resource.yaml") + `
+`,
+			Verify: func(t *testing.T, output string, err error) {
+				content, _ := os.ReadFile(filepath.Join(directory, "accelerator.yaml"))
+				if !strings.Contains(string(content), "- name: javaVersionReplicas") {
+					t.Errorf("expected options prefixed with the fragment name, got %q", content)
+				}
+				if problems := validateMetadataFile(directory, map[string]bool{}, true); len(problems) > 0 {
+					t.Errorf("expected a valid accelerator.yaml, got %v", problems)
+				}
+				verifyScaffoldManifest(t, directory, "Fragment")
+				verifyScaffoldTarball(t, directory)
+
+				// an accelerator importing the fragment is valid when the fragment is provided locally
+				initCmd := InitCmd(nil, nil)
+				initCmd.SetArgs([]string{"my-accelerator", "--directory", accelerator, "--import", "java-version"})
+				initCmd.SetOut(&strings.Builder{})
+				if err := initCmd.Execute(); err != nil {
+					t.Fatal(err)
+				}
+				if problems := validateMetadataFile(accelerator, map[string]bool{"java-version": true}, false); len(problems) > 0 {
+					t.Errorf("expected a valid accelerator.yaml, got %v", problems)
+				}
+			},
+		},
+	}
+	table.Run(t, scheme, FragmentInitCmd)
+}
+
+func TestOptionPrefix(t *testing.T) {
+	for name, expected := range map[string]string{
+		"java":              "java",
+		"java-version":      "javaVersion",
+		"tap.workload-spec": "tapWorkloadSpec",
+		"1st-fragment":      "fragment1stFragment",
+	} {
+		if actual := optionPrefix(name); actual != expected {
+			t.Errorf("expected option prefix %q for %q, got %q", expected, name, actual)
+		}
+	}
+}
@@ -11,7 +11,6 @@ import (
 	acceleratorv1alpha1 "github.com/pivotal/acc-controller/api/v1alpha1"
 	"github.com/spf13/cobra"
 	cli "github.com/vmware-tanzu/apps-cli-plugin/pkg/cli-runtime"
-	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 func FragmentListCmd(ctx context.Context, c *cli.Config) *cobra.Command {
@@ -32,8 +31,12 @@ func FragmentListCmd(ctx context.Context, c *cli.Config) *cobra.Command {
 }
 
 func printFragmentListFromClient(ctx context.Context, c *cli.Config, opts FragmentListOptions, cmd *cobra.Command, w *tabwriter.Writer) error {
+	listOpts, err := selectorListOptions(opts.Namespace, opts.Selector)
+	if err != nil {
+		return err
+	}
 	fragments := &acceleratorv1alpha1.FragmentList{}
-	err := c.List(ctx, fragments, client.InNamespace(opts.Namespace))
+	err = c.List(ctx, fragments, listOpts...)
 	if err != nil {
 		fmt.Fprintf(cmd.OutOrStderr(), "There was an error listing accelerator fragments\n")
 		return err
@@ -153,6 +153,46 @@ test-fragment      unknown   https://www.test.com:main
 			ExpectOutput: `
 NAME            READY
 test-fragment   unknown
+`,
+		},
+		{
+			Name: "List accelerator fragments from context matching selector",
+			Args: []string{"--selector", "team!=alpha"},
+			GivenObjects: []client.Object{
+				&acceleratorv1alpha1.Fragment{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      fragmentName,
+						Namespace: namespace,
+						Labels:    map[string]string{"team": "alpha"},
+					},
+					Spec: acceleratorv1alpha1.FragmentSpec{
+						Git: &acceleratorv1alpha1.Git{
+							URL: "https://www.test.com",
+							Reference: &v1beta2.GitRepositoryRef{
+								Branch: "main",
+							},
+						},
+					},
+				},
+				&acceleratorv1alpha1.Fragment{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "another-fragment",
+						Namespace: namespace,
+						Labels:    map[string]string{"team": "beta"},
+					},
+					Spec: acceleratorv1alpha1.FragmentSpec{
+						Git: &acceleratorv1alpha1.Git{
+							URL: "https://www.test.com",
+							Reference: &v1beta2.GitRepositoryRef{
+								Branch: "main",
+							},
+						},
+					},
+				},
+			},
+			ExpectOutput: `
+NAME               READY
+another-fragment   unknown
 `,
 		},
 	}
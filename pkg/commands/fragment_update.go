@@ -10,16 +10,18 @@ import (
 	"time"
 
 	"github.com/fluxcd/pkg/apis/meta"
-	"github.com/imdario/mergo"
 	acceleratorv1alpha1 "github.com/pivotal/acc-controller/api/v1alpha1"
 	fluxcdv1beta1 "github.com/pivotal/acc-controller/fluxcd/api/v1beta2"
 	"github.com/pivotal/acc-controller/sourcecontroller/api/v1alpha1"
 	"github.com/spf13/cobra"
 	"github.com/vmware-tanzu/apps-cli-plugin/pkg/cli-runtime"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+var fragmentUnsetFields = []string{"display-name", "git-tag", "git-sub-path", "interval", "secret-ref"}
+
 func FragmentUpdateCmd(ctx context.Context, c *cli.Config) *cobra.Command {
 	opts := FragmentUpdateOptions{}
 	requestedAtAnnotation := "reconcile.accelerator.apps.tanzu.vmware.com/requestedAt"
@@ -31,6 +33,15 @@ func FragmentUpdateCmd(ctx context.Context, c *cli.Config) *cobra.Command {
 Accelerator configuration options include:
 - Git repository URL and branch/tag where accelerator code and metadata is defined
 - Metadata like display-name
+- Labels and annotations for the accelerator fragment resource
+
+Fields can be removed from the accelerator fragment using --unset, for example --unset git-sub-path,secret-ref.
+Supported fields are display-name, git-tag, git-sub-path, interval and secret-ref.
+
+Only the changed fields are sent to the cluster, so changes made by someone else to the other fields of the
+accelerator fragment are kept.
+When the accelerator fragment was changed by someone else while it was being updated, the update is retried using
+the latest version of the accelerator fragment.
 
 The update command also provides a --reoncile flag that will force the accelerator fragment to be refreshed
 with any changes made to the associated Git repository.
@@ -42,90 +53,122 @@ with any changes made to the associated Git repository.
 			}
 			return nil
 		},
-		Example: "tanzu accelerator update <accelerator-name> --description \"Lorem Ipsum\"",
+		Example: `tanzu accelerator fragment update <fragment-name> --display-name "Lorem Ipsum"
+tanzu accelerator fragment update <fragment-name> --unset git-sub-path,secret-ref`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-
-			fragment := &acceleratorv1alpha1.Fragment{}
-			err := c.Get(context.Background(), client.ObjectKey{Namespace: opts.Namespace, Name: args[0]}, fragment)
-			if err != nil {
-				fmt.Fprintf(cmd.OutOrStderr(), "accelerator fragment %s not found\n", args[0])
+			if err := validateUnset(cmd, opts.Unset, fragmentUnsetFields); err != nil {
 				return err
 			}
-			updatedFragment := &acceleratorv1alpha1.Fragment{
-				TypeMeta: v1.TypeMeta{
-					APIVersion: "accelerator.tanzu.vmware.com/v1alpha1",
-					Kind:       "Fragment",
-				},
-				ObjectMeta: v1.ObjectMeta{
-					Namespace: opts.Namespace,
-				},
-				Spec: acceleratorv1alpha1.FragmentSpec{
-					DisplayName: opts.DisplayName,
-				},
+			unset := func(field string) bool {
+				return contains(opts.Unset, []string{field})
 			}
 
-			if opts.GitRepoUrl != "" {
-				updatedFragment.Spec.Git = &acceleratorv1alpha1.Git{
-					URL: opts.GitRepoUrl,
-					Reference: &fluxcdv1beta1.GitRepositoryRef{
-						Branch: opts.GitBranch,
-						Tag:    opts.GitTag,
-					},
-				}
-				if opts.GitSubPath != "" {
-					updatedFragment.Spec.Git.SubPath = &opts.GitSubPath
+			var interval *v1.Duration
+			if opts.Interval != "" {
+				duration, err := time.ParseDuration(opts.Interval)
+				if err != nil {
+					return fmt.Errorf("invalid interval %q: %v", opts.Interval, err)
 				}
+				interval = &v1.Duration{Duration: duration}
 			}
 
-			if opts.SourceImage != "" {
-				updatedFragment.Spec.Source = &v1alpha1.ImageRepositorySpec{
-					Image: opts.SourceImage,
-				}
-				updatedFragment.Spec.Git = nil
+			var fragment *acceleratorv1alpha1.Fragment
+			key := client.ObjectKey{Namespace: opts.Namespace, Name: args[0]}
+			newFragment := func() client.Object {
+				fragment = &acceleratorv1alpha1.Fragment{}
+				return fragment
 			}
+			err := patchResource(ctx, c, "accelerator fragment", key, newFragment, func() error {
+				// the requested changes are applied to the live accelerator fragment and sent as a merge patch, so
+				// that anything that is unset is removed from the resource on the cluster as well
+				if opts.DisplayName != "" {
+					fragment.Spec.DisplayName = opts.DisplayName
+				}
+				if unset("display-name") {
+					fragment.Spec.DisplayName = ""
+				}
 
-			if opts.Reconcile {
-				if fragment.ObjectMeta.Annotations == nil {
-					fragment.ObjectMeta.Annotations = make(map[string]string)
+				if opts.GitRepoUrl != "" {
+					if fragment.Spec.Git == nil {
+						fragment.Spec.Git = &acceleratorv1alpha1.Git{}
+					}
+					fragment.Spec.Git.URL = opts.GitRepoUrl
+					if fragment.Spec.Git.Reference == nil {
+						fragment.Spec.Git.Reference = &fluxcdv1beta1.GitRepositoryRef{}
+					}
+					fragment.Spec.Source = nil
 				}
-				fragment.ObjectMeta.Annotations[requestedAtAnnotation] = time.Now().UTC().Format(time.RFC3339)
-			}
 
-			mergo.Merge(updatedFragment, *fragment)
+				if opts.SourceImage != "" {
+					if fragment.Spec.Source == nil {
+						fragment.Spec.Source = &v1alpha1.ImageRepositorySpec{}
+					}
+					fragment.Spec.Source.Image = opts.SourceImage
+					fragment.Spec.Git = nil
+				}
 
-			if opts.GitRepoUrl == "" && opts.GitBranch != "" {
-				updatedFragment.Spec.Git.Reference.Branch = opts.GitBranch
-			}
+				if opts.GitBranch != "" || opts.GitTag != "" || opts.GitSubPath != "" || unset("git-tag") || unset("git-sub-path") {
+					if fragment.Spec.Git == nil {
+						return fmt.Errorf("accelerator fragment %s does not use a Git repository, use --git-repository to provide one", args[0])
+					}
+					if fragment.Spec.Git.Reference == nil {
+						fragment.Spec.Git.Reference = &fluxcdv1beta1.GitRepositoryRef{}
+					}
+					if opts.GitBranch != "" {
+						fragment.Spec.Git.Reference.Branch = opts.GitBranch
+					}
+					if opts.GitTag != "" {
+						fragment.Spec.Git.Reference.Tag = opts.GitTag
+					}
+					if opts.GitSubPath != "" {
+						fragment.Spec.Git.SubPath = &opts.GitSubPath
+					}
+					if unset("git-tag") {
+						fragment.Spec.Git.Reference.Tag = ""
+					}
+					if unset("git-sub-path") {
+						fragment.Spec.Git.SubPath = nil
+					}
+				}
 
-			if opts.GitRepoUrl == "" && opts.GitTag != "" {
-				updatedFragment.Spec.Git.Reference.Tag = opts.GitTag
-			}
+				if fragment.Spec.Git != nil && (interval != nil || unset("interval")) {
+					fragment.Spec.Git.Interval = interval
+				}
 
-			if opts.GitRepoUrl == "" && opts.GitSubPath != "" {
-				updatedFragment.Spec.Git.SubPath = &opts.GitSubPath
-			}
+				if fragment.Spec.Git != nil && opts.SecretRef != "" {
+					fragment.Spec.Git.SecretRef = &meta.LocalObjectReference{
+						Name: opts.SecretRef,
+					}
+				}
+				if fragment.Spec.Git != nil && unset("secret-ref") {
+					fragment.Spec.Git.SecretRef = nil
+				}
 
-			if opts.Interval != "" {
-				duration, _ := time.ParseDuration(opts.Interval)
-				interval := v1.Duration{
-					Duration: duration,
+				labels, err := applyMetadataChanges(fragment.ObjectMeta.Labels, opts.Labels, "label")
+				if err != nil {
+					return err
 				}
+				fragment.ObjectMeta.Labels = labels
 
-				if updatedFragment.Spec.Git != nil {
-					updatedFragment.Spec.Git.Interval = &interval
+				annotations, err := applyMetadataChanges(fragment.ObjectMeta.Annotations, opts.Annotations, "annotation")
+				if err != nil {
+					return err
 				}
-			}
+				fragment.ObjectMeta.Annotations = annotations
 
-			if opts.SecretRef != "" {
-				if updatedFragment.Spec.Git != nil {
-					ref := meta.LocalObjectReference{
-						Name: opts.SecretRef,
+				if opts.Reconcile {
+					if fragment.ObjectMeta.Annotations == nil {
+						fragment.ObjectMeta.Annotations = make(map[string]string)
 					}
-					updatedFragment.Spec.Git.SecretRef = &ref
+					fragment.ObjectMeta.Annotations[requestedAtAnnotation] = time.Now().UTC().Format(time.RFC3339)
 				}
-			}
 
-			err = c.Update(ctx, updatedFragment)
+				return nil
+			})
+			if k8serrors.IsNotFound(err) {
+				fmt.Fprintf(cmd.OutOrStderr(), "accelerator fragment %s not found\n", args[0])
+				return err
+			}
 			if err != nil {
 				fmt.Fprintf(cmd.OutOrStderr(), "there was an error updating accelerator fragment %s\n", args[0])
 				return err
@@ -7,9 +7,11 @@ import (
 	"github.com/fluxcd/pkg/apis/meta"
 	acceleratorv1alpha1 "github.com/pivotal/acc-controller/api/v1alpha1"
 	"github.com/pivotal/acc-controller/fluxcd/api/v1beta2"
+	rtesting "github.com/vmware-labs/reconciler-runtime/testing"
 	clitesting "github.com/vmware-tanzu/apps-cli-plugin/pkg/cli-runtime/testing"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -25,7 +27,18 @@ func TestFragmentUpdateCmd(t *testing.T) {
 	scheme := runtime.NewScheme()
 	_ = acceleratorv1alpha1.AddToScheme(scheme)
 
-	table := clitesting.CommandTestSuite{
+	fragmentPatch := func(patch string) rtesting.PatchRef {
+		return rtesting.PatchRef{
+			Group:     "accelerator.apps.tanzu.vmware.com",
+			Kind:      "Fragment",
+			Namespace: namespace,
+			Name:      acceleratorName,
+			PatchType: types.MergePatchType,
+			Patch:     []byte(patch),
+		}
+	}
+
+	table := patchCommandTestSuite{
 		{
 			Name:        "Missing args",
 			Args:        []string{},
@@ -41,7 +54,7 @@ func TestFragmentUpdateCmd(t *testing.T) {
 			Name: "Error updating fragment",
 			Args: []string{acceleratorName},
 			WithReactors: []clitesting.ReactionFunc{
-				clitesting.InduceFailure("update", "Fragment"),
+				clitesting.InduceFailure("patch", "Fragment"),
 			},
 			GivenObjects: []client.Object{
 				&acceleratorv1alpha1.Fragment{
@@ -59,7 +72,16 @@ func TestFragmentUpdateCmd(t *testing.T) {
 					},
 				},
 			},
-			ExpectUpdates: []client.Object{
+			ExpectPatches: []rtesting.PatchRef{
+				fragmentPatch(`{"metadata":{"resourceVersion":"999"}}`),
+			},
+			ShouldError:  true,
+			ExpectOutput: "there was an error updating accelerator fragment test-fragment\n",
+		},
+		{
+			Name: "Updates fragment",
+			Args: []string{acceleratorName, "--interval", interval, "--secret-ref", secretRef},
+			GivenObjects: []client.Object{
 				&acceleratorv1alpha1.Fragment{
 					ObjectMeta: metav1.ObjectMeta{
 						Name:      acceleratorName,
@@ -75,17 +97,22 @@ func TestFragmentUpdateCmd(t *testing.T) {
 					},
 				},
 			},
-			ShouldError:  true,
-			ExpectOutput: "there was an error updating accelerator fragment test-fragment\n",
+			ExpectPatches: []rtesting.PatchRef{
+				fragmentPatch(`{"metadata":{"resourceVersion":"999"},"spec":{"git":{"interval":"2m0s","secretRef":{"name":"mysecret"}}}}`),
+			},
+			ExpectOutput: "accelerator fragment test-fragment updated successfully\n",
 		},
 		{
-			Name: "Updates fragment",
-			Args: []string{acceleratorName, "--interval", interval, "--secret-ref", secretRef},
+			Name: "Updates fragment labels",
+			Args: []string{acceleratorName, "--label", "team=beta", "--annotation", "owner-"},
 			GivenObjects: []client.Object{
 				&acceleratorv1alpha1.Fragment{
 					ObjectMeta: metav1.ObjectMeta{
 						Name:      acceleratorName,
 						Namespace: namespace,
+						Annotations: map[string]string{
+							"owner": "alpha-team@example.com",
+						},
 					},
 					Spec: acceleratorv1alpha1.FragmentSpec{
 						Git: &acceleratorv1alpha1.Git{
@@ -97,18 +124,28 @@ func TestFragmentUpdateCmd(t *testing.T) {
 					},
 				},
 			},
-			ExpectUpdates: []client.Object{
+			ExpectPatches: []rtesting.PatchRef{
+				fragmentPatch(`{"metadata":{"annotations":null,"labels":{"team":"beta"},"resourceVersion":"999"}}`),
+			},
+			ExpectOutput: "accelerator fragment test-fragment updated successfully\n",
+		},
+		{
+			Name: "Unsets fragment fields",
+			Args: []string{acceleratorName, "--unset", "git-sub-path,secret-ref,interval"},
+			GivenObjects: []client.Object{
 				&acceleratorv1alpha1.Fragment{
 					ObjectMeta: metav1.ObjectMeta{
 						Name:      acceleratorName,
 						Namespace: namespace,
 					},
 					Spec: acceleratorv1alpha1.FragmentSpec{
+						DisplayName: "Test Fragment",
 						Git: &acceleratorv1alpha1.Git{
 							URL: "https://www.test.com",
 							Reference: &v1beta2.GitRepositoryRef{
 								Branch: "main",
 							},
+							SubPath: &acceleratorName,
 							SecretRef: &meta.LocalObjectReference{
 								Name: secretRef,
 							},
@@ -117,6 +154,38 @@ func TestFragmentUpdateCmd(t *testing.T) {
 					},
 				},
 			},
+			ExpectPatches: []rtesting.PatchRef{
+				fragmentPatch(`{"metadata":{"resourceVersion":"999"},"spec":{"git":{"interval":null,"secretRef":null,"subPath":null}}}`),
+			},
+			ExpectOutput: "accelerator fragment test-fragment updated successfully\n",
+		},
+		{
+			Name:        "Error unsetting unsupported fragment field",
+			Args:        []string{acceleratorName, "--unset", "description"},
+			ShouldError: true,
+		},
+		{
+			Name: "Updates fragment secret ref",
+			Args: []string{acceleratorName, "--secret-ref", secretRef},
+			GivenObjects: []client.Object{
+				&acceleratorv1alpha1.Fragment{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      acceleratorName,
+						Namespace: namespace,
+					},
+					Spec: acceleratorv1alpha1.FragmentSpec{
+						Git: &acceleratorv1alpha1.Git{
+							URL: "https://www.test.com",
+							Reference: &v1beta2.GitRepositoryRef{
+								Branch: "main",
+							},
+						},
+					},
+				},
+			},
+			ExpectPatches: []rtesting.PatchRef{
+				fragmentPatch(`{"metadata":{"resourceVersion":"999"},"spec":{"git":{"secretRef":{"name":"mysecret"}}}}`),
+			},
 			ExpectOutput: "accelerator fragment test-fragment updated successfully\n",
 		},
 	}
@@ -4,6 +4,7 @@ Copyright 2021-2023 VMware, Inc. All Rights Reserved.
 package commands
 
 import (
+	"archive/zip"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -12,6 +13,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"os/user"
+	"path/filepath"
 	"strings"
 
 	"github.com/google/uuid"
@@ -46,6 +48,12 @@ func GenerateCmd() *cobra.Command {
 	var optionsString string
 	var filename string
 	var outputDir string
+	var cacheFlags generateCacheFlags
+	var gitFlags gitInitFlags
+	var forceOverwrite bool
+	var extract bool
+	var hookFlags postGenerateHookFlags
+	var previewOpts previewFlags
 	var generateCmd = &cobra.Command{
 		Use:   "generate",
 		Short: "Generate project from accelerator",
@@ -66,6 +74,46 @@ You can also provide a file that specifies the JSON string using the --options-f
 The generate command needs access to the Application Accelerator server. You can specify the --server-url flag or set
 an ACC_SERVER_URL environment variable. If you specify the --server-url flag it will override the ACC_SERVER_URL
 environment variable if it is set.
+
+With --cache, or when the ACC_GENERATE_CACHE environment variable is set to true, the generated projects are kept in a
+local cache and generating the same project again doesn't contact the server to generate it. The projects are cached
+by the revision of the accelerator and the options. Changes to the fragments imported by the accelerator are not
+detected, use --no-cache or "tanzu accelerator cache prune --all" after updating them. The cache is limited to
+--cache-max-size, the least recently used projects are removed first.
+
+With --extract the project is extracted to a directory named after the "projectName" in the output directory instead
+of being written as a ZIP file, use --force to overwrite an existing project directory.
+
+The extracted project can be turned into a Git repository: --git-init initializes a repository in it with an initial
+commit of the generated files, and implies --extract. The message of the commit includes the name of the accelerator
+and the options. With --git-push the initial commit is also pushed to the given remote repository, using any URL
+supported by git such as an SSH, HTTPS or file URL. The remote repository should be empty, and the credentials are the
+ones git uses for the URL.
+
+Post-generation hooks are shell commands run in the extracted project, before the Git repository is initialized. They
+are declared in the hooks file, set with the ACC_HOOKS_FILE environment variable or tanzu-accelerator/hooks.yaml in the
+configuration directory of the user, and with --post-hook. For example:
+
+    postGenerate:
+    - name: make the Maven wrapper executable
+      command: chmod +x mvnw
+      accelerators: [java-rest]
+    - command: go mod tidy
+      timeout: 2m
+      accelerators: [go-service]
+
+The hooks run in order, with the name of the accelerator in ACC_ACCELERATOR, the project directory in ACC_PROJECT_DIR,
+the options as a JSON object in ACC_OPTIONS and every option in an ACC_OPTION_ variable named after the option in upper
+snake case, for example ACC_OPTION_PROJECT_NAME. Each hook may run for --hook-timeout unless it sets its own timeout.
+The command fails at the first hook that fails. Use --no-hooks to skip the hooks. The hooks don't run when the project
+is written as a ZIP file, so --post-hook requires --extract, --git-init or --git-push.
+
+With --preview nothing is written: the files of the generated project are printed as a tree with their sizes, and
+the content of the files matching the --show patterns is printed after the tree. The patterns match the paths of the
+files in the project, "*" matches any part of a file or directory name and "**" any number of directories, for example
+--show README.md or --show 'src/**/*.java'. With --diff-against the generated project is compared with an existing
+directory instead: the files the generated project would add (+), change (~) or remove (-) are printed, with the
+differences of the changed text files. The .git directory is ignored.
 `,
 		ValidArgsFunction: SuggestAcceleratorNamesFromUiServer(context.Background()),
 		Args: func(cmd *cobra.Command, args []string) error {
@@ -74,8 +122,16 @@ environment variable if it is set.
 			}
 			return nil
 		},
-		Example: "tanzu accelerator generate <accelerator-name> --options '{\"projectName\":\"test\"}'",
+		Example: `tanzu accelerator generate <accelerator-name> --options '{"projectName":"test"}'
+tanzu accelerator generate <accelerator-name> --options '{"projectName":"test"}' --git-push git@github.com:example/test.git`,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := previewOpts.validate(); err != nil {
+				return err
+			}
+			// the hooks run in the extracted project, they would never run with a ZIP file
+			if len(hookFlags.commands) > 0 && !extract && !gitFlags.enabled() {
+				return errors.New("--post-hook requires --extract, --git-init or --git-push, the hooks run in the extracted project")
+			}
 			if !strings.HasSuffix(outputDir, "/") && outputDir != "" {
 				outputDir += "/"
 			}
@@ -117,43 +173,123 @@ environment variable if it is set.
 			provenanceId := uuid.New().String()
 
 			apiPrefix := DetermineApiServerPrefix(serverUrl)
-			proxyRequest, err := http.NewRequest("POST", fmt.Sprintf("%s/%s/accelerators/zip?name=%s&source=TanzuCLI&username=%s&id=%s", serverUrl, apiPrefix, args[0], osuser.Username, provenanceId), bytes.NewReader(JsonProxyBodyBytes))
-			proxyRequest.Header.Add("Content-Type", "application/json")
 			client := &http.Client{}
-			resp, err := client.Do(proxyRequest)
+
+			cache, err := cacheFlags.open()
 			if err != nil {
 				return err
 			}
+			var cacheKey string
+			if cache != nil {
+				revision, err := acceleratorRevision(serverUrl, apiPrefix, args[0])
+				if err == nil {
+					cacheKey, err = generateCacheKey{ServerUrl: serverUrl, Accelerator: args[0], AcceleratorRevision: revision, Options: options}.hash()
+				}
+				if err != nil {
+					fmt.Fprintf(cmd.OutOrStderr(), "not using the cache of generated projects: %v\n", err)
+					cache = nil
+				}
+			}
 
-			if resp.StatusCode >= 400 {
-				var errorMsg string
-				if resp.StatusCode == http.StatusNotFound {
-					errorMsg = fmt.Sprintf("accelerator %s not found\n", args[0])
-				} else {
-					var errorResponse UiErrorResponse
-					body, _ := ioutil.ReadAll(resp.Body)
-					json.Unmarshal(body, &errorResponse)
-					if errorResponse.Detail > "" {
-						errorMsg = fmt.Sprintf("there was an error generating the accelerator, the server response was: \"%s\"\n", errorResponse.Detail)
+			var body []byte
+			cachedFile, cached := "", false
+			if cache != nil {
+				cachedFile, cached = cache.get(cacheKey)
+			}
+			if cached {
+				body, err = ioutil.ReadFile(cachedFile)
+				if err != nil {
+					return err
+				}
+			} else {
+				proxyRequest, err := http.NewRequest("POST", fmt.Sprintf("%s/%s/accelerators/zip?name=%s&source=TanzuCLI&username=%s&id=%s", serverUrl, apiPrefix, args[0], osuser.Username, provenanceId), bytes.NewReader(JsonProxyBodyBytes))
+				if err != nil {
+					return err
+				}
+				proxyRequest.Header.Add("Content-Type", "application/json")
+				resp, err := client.Do(proxyRequest)
+				if err != nil {
+					return err
+				}
+
+				if resp.StatusCode >= 400 {
+					var errorMsg string
+					if resp.StatusCode == http.StatusNotFound {
+						errorMsg = fmt.Sprintf("accelerator %s not found\n", args[0])
 					} else {
-						errorMsg = fmt.Sprintf("there was an error generating the accelerator, the server response code was: \"%v\"\n", resp.StatusCode)
+						var errorResponse UiErrorResponse
+						body, _ := ioutil.ReadAll(resp.Body)
+						json.Unmarshal(body, &errorResponse)
+						if errorResponse.Detail > "" {
+							errorMsg = fmt.Sprintf("there was an error generating the accelerator, the server response was: \"%s\"\n", errorResponse.Detail)
+						} else {
+							errorMsg = fmt.Sprintf("there was an error generating the accelerator, the server response code was: \"%v\"\n", resp.StatusCode)
+						}
 					}
+					return fmt.Errorf(errorMsg)
 				}
-				return fmt.Errorf(errorMsg)
-			}
 
-			body, _ := ioutil.ReadAll(resp.Body)
-			zipfile := outputDir + options["projectName"].(string) + ".zip"
-			err = ioutil.WriteFile(zipfile, body, 0644)
-			if err != nil {
-				return err
+				body, err = ioutil.ReadAll(resp.Body)
+				if err != nil {
+					return fmt.Errorf("error downloading the generated project: %v", err)
+				}
+				// nothing is written when previewing, not even to the cache
+				if cache != nil && !previewOpts.enabled() {
+					// an invalid zip file would be served to all the later runs
+					if _, err := zip.NewReader(bytes.NewReader(body), int64(len(body))); err != nil {
+						fmt.Fprintf(cmd.OutOrStderr(), "could not store the generated project in the cache: %v\n", err)
+					} else if err := cache.put(cacheKey, bytes.NewReader(body)); err != nil {
+						fmt.Fprintf(cmd.OutOrStderr(), "could not store the generated project in the cache: %v\n", err)
+					}
+				}
+			}
+			if previewOpts.enabled() {
+				zipReader, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+				if err != nil {
+					return err
+				}
+				// the project isn't downloaded, so it is not registered as invoked
+				return previewOpts.print(cmd, zipReader, options["projectName"].(string), false)
+			}
+			if extract || gitFlags.enabled() {
+				projectDir := filepath.Join(outputDir, options["projectName"].(string))
+				zipReader, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+				if err != nil {
+					return err
+				}
+				if err := extractProject(zipReader, projectDir, forceOverwrite); err != nil {
+					return err
+				}
+				if cached {
+					fmt.Fprintf(cmd.OutOrStdout(), "project %s created from the cache\n", projectDir)
+				} else {
+					fmt.Fprintf(cmd.OutOrStdout(), "project %s created\n", projectDir)
+				}
+				if err := hookFlags.run(cmd.Context(), cmd, projectDir, args[0], options); err != nil {
+					return err
+				}
+				if gitFlags.enabled() {
+					if err := gitFlags.run(cmd.Context(), cmd, projectDir, args[0], options); err != nil {
+						return err
+					}
+				}
+			} else {
+				zipfile := outputDir + options["projectName"].(string) + ".zip"
+				err = ioutil.WriteFile(zipfile, body, 0644)
+				if err != nil {
+					return err
+				}
+				if cached {
+					fmt.Fprintf(cmd.OutOrStdout(), "zip file %s created from the cache\n", zipfile)
+				} else {
+					fmt.Fprintf(cmd.OutOrStdout(), "zip file %s created\n", zipfile)
+				}
 			}
-			fmt.Fprintf(cmd.OutOrStdout(), "zip file %s created\n", zipfile)
 			invokedRequest, err := http.NewRequest("POST", fmt.Sprintf("%s/%s/accelerators/invoked?type=download&name=%s&source=TanzuCLI&username=%s&id=%s", serverUrl, apiPrefix, args[0], osuser.Username, provenanceId), nil)
 			if err != nil {
 				return err
 			}
-			resp, err = client.Do(invokedRequest)
+			resp, err := client.Do(invokedRequest)
 			if err != nil {
 				return err
 			}
@@ -190,6 +326,18 @@ environment variable if it is set.
 	generateCmd.Flags().StringVar(&filename, "options-file", "", "path to file containing options JSON string")
 	generateCmd.Flags().StringVar(&outputDir, "output-dir", "", "directory that the zip file will be written to")
 	generateCmd.Flags().StringVar(&uiServer, "server-url", "", "the URL for the Application Accelerator server")
+	cacheFlags.define(generateCmd)
+	gitFlags.define(generateCmd)
+	generateCmd.Flags().BoolVar(&extract, "extract", false, "extract the project to a directory in the output directory instead of writing a zip file")
+	generateCmd.Flags().BoolVarP(&forceOverwrite, "force", "f", false, "force clean and rewrite of the project directory when extracting the project")
+	hookFlags.define(generateCmd)
+	previewOpts.define(generateCmd)
+	generateCmd.MarkFlagsMutuallyExclusive("preview", "extract")
+	generateCmd.MarkFlagsMutuallyExclusive("preview", "git-init")
+	generateCmd.MarkFlagsMutuallyExclusive("preview", "git-push")
+	generateCmd.MarkFlagsMutuallyExclusive("diff-against", "extract")
+	generateCmd.MarkFlagsMutuallyExclusive("diff-against", "git-init")
+	generateCmd.MarkFlagsMutuallyExclusive("diff-against", "git-push")
 	accServerUrl = EnvVar("ACC_SERVER_URL", "")
 	return generateCmd
 }
@@ -0,0 +1,261 @@
+/*
+Copyright 2022-2023 VMware, Inc. All Rights Reserved.
+*/
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+)
+
+// batchProject is an entry of the manifest of generate-batch
+type batchProject struct {
+	Name            string                 `json:"name,omitempty"`
+	Accelerator     string                 `json:"accelerator,omitempty"`
+	AcceleratorPath string                 `json:"acceleratorPath,omitempty"`
+	FragmentNames   []string               `json:"fragmentNames,omitempty"`
+	FragmentPaths   map[string]string      `json:"fragmentPaths,omitempty"`
+	Options         map[string]interface{} `json:"options,omitempty"`
+	OutputDir       string                 `json:"outputDir,omitempty"`
+}
+
+type batchResult struct {
+	project  batchProject
+	duration time.Duration
+	err      error
+}
+
+func GenerateBatchCmd() *cobra.Command {
+	var uiServer string
+	var accServerUrl string
+	var concurrency int
+	var forceOverwrite bool
+	var cacheFlags generateCacheFlags
+	var noHooks bool
+	var batchCmd = &cobra.Command{
+		Use:   "generate-batch <file>",
+		Short: "Generate several projects from a manifest",
+		Long: `Generate the projects listed in a YAML manifest, several at a time.
+
+Every entry of the manifest generates a project the same way as generate-from-local, from a registered accelerator
+or from a local accelerator directory, with registered and local fragments, for example:
+
+    - name: orders
+      accelerator: java-rest
+      fragmentNames: [tap-workload]
+      options:
+        projectName: orders
+      outputDir: services/orders
+    - name: payments
+      acceleratorPath: ../accelerators/go-service
+      fragmentPaths:
+        java-version: ../fragments/java-version
+      outputDir: services/payments
+
+Relative paths are relative to the directory of the manifest. The name is shown in the results and defaults to the
+"projectName" option, or to the name of the accelerator. The "projectName" option defaults to the name, and the
+outputDir defaults to the name too. Every project must use a different output directory. The post-generation hooks of
+the hooks file run in every generated project, unless --no-hooks is set.
+
+At most --concurrency projects are generated at the same time. Once all the projects are generated a table with the
+result of each project is shown and the command fails if any project failed.
+
+The generate-batch command needs access to the Application Accelerator server. You can specify the --server-url flag
+or set an ACC_SERVER_URL environment variable.
+`,
+		Example: `tanzu accelerator generate-batch projects.yaml --server-url https://accelerator.example.com
+tanzu accelerator generate-batch projects.yaml --concurrency 8 --force`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return errors.New("you must pass the path of the manifest listing the projects")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if concurrency < 1 {
+				return fmt.Errorf("invalid concurrency %d, must be at least 1", concurrency)
+			}
+			projects, err := readBatchManifest(args[0])
+			if err != nil {
+				return err
+			}
+			serverUrl := accServerUrl
+			if uiServer != "" {
+				serverUrl = uiServer
+			}
+			// the server, the cache and the hooks file are resolved once and shared by all the generations, which
+			// also share the connections to the server
+			server, err := newAcceleratorServer(serverUrl)
+			if err != nil {
+				return err
+			}
+			cache, err := cacheFlags.open()
+			if err != nil {
+				return err
+			}
+			hookFlags := postGenerateHookFlags{noHooks: noHooks, timeout: defaultHookTimeout}
+			if err := hookFlags.load(); err != nil {
+				return err
+			}
+			// the output of each generation is not shown, only the table of results
+			quiet := &cobra.Command{}
+			quiet.SetOut(io.Discard)
+			quiet.SetErr(io.Discard)
+
+			results := make([]batchResult, len(projects))
+			slots := make(chan struct{}, concurrency)
+			var wg sync.WaitGroup
+			for i := range projects {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					slots <- struct{}{}
+					defer func() { <-slots }()
+					start := time.Now()
+					err := generateBatchProject(cmd.Context(), quiet, server, cache, &hookFlags, projects[i], forceOverwrite)
+					results[i] = batchResult{project: projects[i], duration: time.Since(start), err: err}
+				}(i)
+			}
+			wg.Wait()
+
+			failed := printBatchResults(cmd, results)
+			if failed > 0 {
+				// the failures have been reported already, the usage would only hide them
+				cmd.SilenceUsage = true
+				return fmt.Errorf("%d of %d project(s) failed", failed, len(results))
+			}
+			return nil
+		},
+	}
+	batchCmd.Flags().StringVar(&uiServer, "server-url", "", "the URL for the Application Accelerator server")
+	batchCmd.Flags().IntVar(&concurrency, "concurrency", 4, "the maximum number of projects generated at the same time")
+	batchCmd.Flags().BoolVarP(&forceOverwrite, "force", "f", false, "force clean and rewrite of the output directories")
+	cacheFlags.define(batchCmd)
+	batchCmd.Flags().BoolVar(&noHooks, "no-hooks", false, "don't run the post-generation hooks of the hooks file")
+	accServerUrl = EnvVar("ACC_SERVER_URL", "")
+	return batchCmd
+}
+
+// readBatchManifest reads the projects of the manifest, resolving their paths from the directory of the manifest
+func readBatchManifest(file string) ([]batchProject, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	projects := []batchProject{}
+	if err := yaml.UnmarshalStrict(content, &projects); err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %v", file, err)
+	}
+	if len(projects) == 0 {
+		return nil, fmt.Errorf("no projects found in %s", file)
+	}
+
+	base := filepath.Dir(file)
+	resolve := func(path string) string {
+		if filepath.IsAbs(path) {
+			return path
+		}
+		return filepath.Join(base, path)
+	}
+	outputDirs := map[string]int{}
+	for i := range projects {
+		project := &projects[i]
+		if (project.Accelerator == "") == (project.AcceleratorPath == "") {
+			return nil, fmt.Errorf("invalid project %d in %s: exactly one of accelerator or acceleratorPath must be set", i+1, file)
+		}
+		if project.Name == "" {
+			if projectName, ok := project.Options["projectName"].(string); ok && projectName != "" {
+				project.Name = projectName
+			} else if project.Accelerator != "" {
+				project.Name = project.Accelerator
+			} else {
+				project.Name = filepath.Base(filepath.Clean(project.AcceleratorPath))
+			}
+		}
+		if project.AcceleratorPath != "" {
+			project.AcceleratorPath = resolve(project.AcceleratorPath)
+		}
+		for fragmentName, fragmentPath := range project.FragmentPaths {
+			project.FragmentPaths[fragmentName] = resolve(fragmentPath)
+		}
+		if project.OutputDir == "" {
+			project.OutputDir = project.Name
+		}
+		project.OutputDir = filepath.Clean(resolve(project.OutputDir))
+		if previous, found := outputDirs[project.OutputDir]; found {
+			return nil, fmt.Errorf("invalid project %d in %s: the output directory %s is already used by project %d", i+1, file, project.OutputDir, previous)
+		}
+		outputDirs[project.OutputDir] = i + 1
+	}
+	return projects, nil
+}
+
+// generateBatchProject generates the project the same way as generate-from-local and runs the post-generation hooks
+func generateBatchProject(ctx context.Context, cmd *cobra.Command, server acceleratorServer, cache *generateCache, hookFlags *postGenerateHookFlags, project batchProject, force bool) error {
+	options := map[string]interface{}{"projectName": project.Name}
+	for name, value := range project.Options {
+		options[name] = value
+	}
+	generated := localProject{
+		acceleratorName: project.Accelerator,
+		fragmentNames:   project.FragmentNames,
+		localFragments:  project.FragmentPaths,
+		options:         options,
+		targetDirectory: project.OutputDir,
+	}
+	accelerator := project.Accelerator
+	if project.AcceleratorPath != "" {
+		generated.localAccelerator = kvPair{project.Name, project.AcceleratorPath}
+		accelerator = project.Name
+	}
+	if err := checkLocalDirectories(generated.localAccelerator, generated.localFragments); err != nil {
+		return err
+	}
+	if err := generateLocalProject(cmd, server, generated, cache, &previewFlags{}, false, force); err != nil {
+		return err
+	}
+	return hookFlags.run(ctx, cmd, project.OutputDir, accelerator, options)
+}
+
+// printBatchResults prints a table with the result of each project followed by the errors, and returns the number of
+// failed projects
+func printBatchResults(cmd *cobra.Command, results []batchResult) int {
+	w := new(tabwriter.Writer)
+	w.Init(cmd.OutOrStdout(), 0, 8, 3, ' ', 0)
+	fmt.Fprintln(w, "PROJECT\tACCELERATOR\tOUTPUT\tRESULT\tDURATION")
+	failed := 0
+	for _, result := range results {
+		accelerator := result.project.Accelerator
+		if accelerator == "" {
+			accelerator = result.project.AcceleratorPath
+		}
+		status := "ok"
+		if result.err != nil {
+			status = "failed"
+			failed++
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%.2fs\n", result.project.Name, accelerator, result.project.OutputDir, status, result.duration.Seconds())
+	}
+	w.Flush()
+
+	if failed > 0 {
+		fmt.Fprintf(cmd.OutOrStdout(), "\n")
+		for _, result := range results {
+			if result.err != nil {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s: %s\n", result.project.Name, strings.TrimSpace(result.err.Error()))
+			}
+		}
+	}
+	return failed
+}
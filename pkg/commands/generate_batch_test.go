@@ -0,0 +1,162 @@
+package commands
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("command run", func() {
+	Context("GenerateBatchCmd()", func() {
+		var workDir string
+		var ts *httptest.Server
+		var inFlight, maxInFlight, aboutRequests int32
+
+		BeforeEach(func() {
+			var err error
+			workDir, err = os.MkdirTemp("", "batch")
+			Expect(err).NotTo(HaveOccurred())
+			atomic.StoreInt32(&inFlight, 0)
+			atomic.StoreInt32(&maxInFlight, 0)
+			atomic.StoreInt32(&aboutRequests, 0)
+
+			// the generated project has a README with the name of the project, the broken accelerator fails
+			mux := http.NewServeMux()
+			mux.HandleFunc("/api/about", func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&aboutRequests, 1)
+				w.WriteHeader(200)
+				w.Write([]byte("{}"))
+			})
+			mux.HandleFunc("/api/accelerators/zip", func(w http.ResponseWriter, r *http.Request) {
+				current := atomic.AddInt32(&inFlight, 1)
+				defer atomic.AddInt32(&inFlight, -1)
+				for {
+					previous := atomic.LoadInt32(&maxInFlight)
+					if current <= previous || atomic.CompareAndSwapInt32(&maxInFlight, previous, current) {
+						break
+					}
+				}
+				time.Sleep(50 * time.Millisecond)
+
+				r.ParseMultipartForm(100 << 20)
+				if r.FormValue("accelerator_name") == "broken" {
+					w.WriteHeader(http.StatusInternalServerError)
+					json.NewEncoder(w).Encode(UiErrorResponse{Detail: "broken accelerator"})
+					return
+				}
+				options := map[string]interface{}{}
+				json.Unmarshal([]byte(r.FormValue("options")), &options)
+				zipWriter := zip.NewWriter(w)
+				entry, _ := zipWriter.Create("project/README.md")
+				entry.Write([]byte(options["projectName"].(string)))
+				zipWriter.Close()
+			})
+			ts = httptest.NewServer(mux)
+
+			Expect(os.MkdirAll(filepath.Join(workDir, "local-acc"), 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(workDir, "local-acc", "accelerator.yaml"), []byte("accelerator: {}\n"), 0644)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			ts.Close()
+			os.RemoveAll(workDir)
+		})
+
+		writeManifest := func(content string) string {
+			manifest := filepath.Join(workDir, "projects.yaml")
+			Expect(os.WriteFile(manifest, []byte(content), 0644)).To(Succeed())
+			return manifest
+		}
+		readme := func(dir string) string {
+			content, err := os.ReadFile(filepath.Join(workDir, dir, "README.md"))
+			Expect(err).NotTo(HaveOccurred())
+			return string(content)
+		}
+
+		It("Should generate all the projects with bounded concurrency", func() {
+			manifest := writeManifest(`
+- accelerator: java-rest
+  options:
+    projectName: orders
+  outputDir: services/orders
+- name: payments
+  accelerator: java-rest
+  outputDir: services/payments
+- acceleratorPath: local-acc
+  outputDir: services/local
+- accelerator: java-rest
+  options:
+    projectName: shipping
+`)
+			batchCmd := GenerateBatchCmd()
+			b := new(bytes.Buffer)
+			batchCmd.SetOut(b)
+			batchCmd.SetErr(b)
+			batchCmd.SetArgs([]string{manifest, "--server-url", ts.URL, "--concurrency", "2"})
+			Expect(batchCmd.Execute()).To(Succeed())
+
+			Expect(b.String()).To(MatchRegexp(`^PROJECT\s+ACCELERATOR\s+OUTPUT\s+RESULT\s+DURATION\n`))
+			Expect(b.String()).To(MatchRegexp(`\norders\s+java-rest\s+` + filepath.Join(workDir, "services", "orders") + `\s+ok\s+\d+\.\d\ds\n`))
+			Expect(b.String()).To(MatchRegexp(`\npayments\s+java-rest\s+` + filepath.Join(workDir, "services", "payments") + `\s+ok\s+`))
+			Expect(b.String()).To(MatchRegexp(`\nlocal-acc\s+` + filepath.Join(workDir, "local-acc") + `\s+` + filepath.Join(workDir, "services", "local") + `\s+ok\s+`))
+			Expect(b.String()).To(MatchRegexp(`\nshipping\s+java-rest\s+` + filepath.Join(workDir, "shipping") + `\s+ok\s+`))
+
+			Expect(readme("services/orders")).To(Equal("orders"))
+			Expect(readme("services/payments")).To(Equal("payments"))
+			Expect(readme("services/local")).To(Equal("local-acc"))
+			Expect(readme("shipping")).To(Equal("shipping"))
+			Expect(atomic.LoadInt32(&maxInFlight)).To(Equal(int32(2)))
+			// the API prefix of the server is determined once for all the projects
+			Expect(atomic.LoadInt32(&aboutRequests)).To(Equal(int32(1)))
+		})
+
+		It("Should report the failed projects", func() {
+			manifest := writeManifest(`
+- accelerator: java-rest
+  outputDir: ok
+- accelerator: broken
+  outputDir: failed
+`)
+			batchCmd := GenerateBatchCmd()
+			b := new(bytes.Buffer)
+			batchCmd.SetOut(b)
+			batchCmd.SetErr(b)
+			batchCmd.SetArgs([]string{manifest, "--server-url", ts.URL})
+			err := batchCmd.Execute()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("1 of 2 project(s) failed"))
+			Expect(b.String()).To(MatchRegexp(`\njava-rest\s+java-rest\s+\S+\s+ok\s+`))
+			Expect(b.String()).To(MatchRegexp(`\nbroken\s+broken\s+\S+\s+failed\s+`))
+			Expect(b.String()).To(ContainSubstring("\n\nbroken: there was an error generating the accelerator, the server response was: \"broken accelerator\"\n"))
+			Expect(readme("ok")).To(Equal("java-rest"))
+		})
+
+		It("Should reject invalid manifests", func() {
+			for manifest, expected := range map[string]string{
+				"- outputDir: a\n": "invalid project 1 in " + filepath.Join(workDir, "projects.yaml") + ": exactly one of accelerator or acceleratorPath must be set",
+				"- accelerator: a\n  outputDir: out\n- accelerator: b\n  outputDir: out/\n": "invalid project 2 in " + filepath.Join(workDir, "projects.yaml") +
+					": the output directory " + filepath.Join(workDir, "out") + " is already used by project 1",
+				"[]\n":                             "no projects found in " + filepath.Join(workDir, "projects.yaml"),
+				"- accelerator: a\n  unknown: b\n": "invalid manifest " + filepath.Join(workDir, "projects.yaml") + ": error unmarshaling JSON: while decoding JSON: json: unknown field \"unknown\"",
+			} {
+				batchCmd := GenerateBatchCmd()
+				b := new(bytes.Buffer)
+				batchCmd.SetOut(b)
+				batchCmd.SetErr(b)
+				batchCmd.SetArgs([]string{writeManifest(manifest), "--server-url", ts.URL})
+				err := batchCmd.Execute()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal(expected))
+			}
+		})
+	})
+})
@@ -0,0 +1,75 @@
+/*
+Copyright 2022-2023 VMware, Inc. All Rights Reserved.
+*/
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// gitInitFlags are the flags of the commands generating projects that turn the generated project into a Git repository
+type gitInitFlags struct {
+	init    bool
+	pushUrl string
+	branch  string
+}
+
+func (f *gitInitFlags) define(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&f.init, "git-init", false, "initialize a Git repository in the generated project with an initial commit of its files")
+	cmd.Flags().StringVar(&f.pushUrl, "git-push", "", "URL of the remote Git repository the initial commit of the generated project is pushed to, implies --git-init")
+	cmd.Flags().StringVar(&f.branch, "git-branch", "main", "name of the branch of the initial commit when using --git-init or --git-push")
+}
+
+// enabled returns true if a Git repository must be initialized in the generated project
+func (f *gitInitFlags) enabled() bool {
+	return f.init || f.pushUrl != ""
+}
+
+// run initializes the Git repository in the directory of the generated project, commits its files and pushes them when
+// a remote is set
+func (f *gitInitFlags) run(ctx context.Context, cmd *cobra.Command, dir string, accelerator string, options map[string]interface{}) error {
+	message, err := initialCommitMessage(accelerator, options)
+	if err != nil {
+		return err
+	}
+	if _, err := runGit(ctx, dir, "init", "--quiet", "--initial-branch", f.branch); err != nil {
+		return err
+	}
+	if _, err := runGit(ctx, dir, "add", "--all"); err != nil {
+		return err
+	}
+	if _, err := runGit(ctx, dir, "commit", "--quiet", "--allow-empty", "--message", message); err != nil {
+		return err
+	}
+	commit, err := runGit(ctx, dir, "rev-parse", "--short", "HEAD")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "initialized Git repository in %s with commit %s\n", dir, commit)
+	if f.pushUrl == "" {
+		return nil
+	}
+	if _, err := runGit(ctx, dir, "remote", "add", "origin", f.pushUrl); err != nil {
+		return err
+	}
+	if _, err := runGit(ctx, dir, "push", "--quiet", "--set-upstream", "origin", f.branch); err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "pushed branch %s to %s\n", f.branch, f.pushUrl)
+	return nil
+}
+
+// initialCommitMessage returns the message of the initial commit of a generated project, listing the options used to
+// generate it
+func initialCommitMessage(accelerator string, options map[string]interface{}) (string, error) {
+	// the keys of JSON objects are sorted, so the message doesn't depend on the order of the options
+	encoded, err := json.MarshalIndent(options, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Generate project from accelerator %s\n\nOptions:\n%s\n", accelerator, encoded), nil
+}
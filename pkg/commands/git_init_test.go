@@ -0,0 +1,123 @@
+package commands
+
+import (
+	"archive/zip"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("command run", func() {
+	Context("generating into a Git repository", func() {
+		var workDir string
+		var repository string
+		var ts *httptest.Server
+
+		git := func(dir string, args ...string) string {
+			cmd := exec.Command("git", args...)
+			cmd.Dir = dir
+			out, err := cmd.CombinedOutput()
+			Expect(err).NotTo(HaveOccurred(), string(out))
+			return strings.TrimSpace(string(out))
+		}
+
+		BeforeEach(func() {
+			var err error
+			workDir, err = os.MkdirTemp("", "git-init")
+			Expect(err).NotTo(HaveOccurred())
+			for name, value := range map[string]string{
+				"GIT_AUTHOR_NAME":     "Test",
+				"GIT_AUTHOR_EMAIL":    "test@example.com",
+				"GIT_COMMITTER_NAME":  "Test",
+				"GIT_COMMITTER_EMAIL": "test@example.com",
+			} {
+				os.Setenv(name, value)
+			}
+			repository = filepath.Join(workDir, "repository.git")
+			git(workDir, "init", "--quiet", "--bare", repository)
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("/api/about", func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(200)
+				w.Write([]byte("{}"))
+			})
+			mux.HandleFunc("/api/accelerators/zip", func(w http.ResponseWriter, r *http.Request) {
+				zipWriter := zip.NewWriter(w)
+				entry, _ := zipWriter.Create("test/README.md")
+				entry.Write([]byte("generated"))
+				zipWriter.Create("test/src/")
+				zipWriter.Close()
+			})
+			mux.HandleFunc("/api/accelerators/invoked", func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(200)
+			})
+			ts = httptest.NewServer(mux)
+		})
+
+		AfterEach(func() {
+			ts.Close()
+			for _, name := range []string{"GIT_AUTHOR_NAME", "GIT_AUTHOR_EMAIL", "GIT_COMMITTER_NAME", "GIT_COMMITTER_EMAIL"} {
+				os.Unsetenv(name)
+			}
+			os.RemoveAll(workDir)
+		})
+
+		It("Should initialize a repository in the project generated from local files", func() {
+			outputDir := filepath.Join(workDir, "generated")
+			generateCmd := LocalGenerateCmd()
+			b := new(bytes.Buffer)
+			generateCmd.SetOut(b)
+			generateCmd.SetErr(b)
+			generateCmd.SetArgs([]string{"--server-url", ts.URL, "--accelerator-name", "java-rest", "--output-dir", outputDir,
+				"--options", `{"projectName":"orders","includeKubernetes":true}`, "--git-init"})
+			Expect(generateCmd.Execute()).To(Succeed())
+
+			commit := git(outputDir, "rev-parse", "--short", "HEAD")
+			Expect(b.String()).To(Equal("generated project orders\ninitialized Git repository in " + outputDir + " with commit " + commit + "\n"))
+			Expect(git(outputDir, "symbolic-ref", "--short", "HEAD")).To(Equal("main"))
+			Expect(git(outputDir, "log", "--format=%B", "-1")).To(Equal("Generate project from accelerator java-rest\n\nOptions:\n{\n  \"includeKubernetes\": true,\n  \"projectName\": \"orders\"\n}"))
+			Expect(git(outputDir, "ls-files")).To(Equal("README.md"))
+			Expect(git(outputDir, "status", "--porcelain")).To(BeEmpty())
+		})
+
+		It("Should push the generated project to the remote repository", func() {
+			generateCmd := GenerateCmd()
+			b := new(bytes.Buffer)
+			generateCmd.SetOut(b)
+			generateCmd.SetErr(b)
+			generateCmd.SetArgs([]string{"test-acc", "--server-url", ts.URL, "--output-dir", workDir, "--git-push", repository, "--git-branch", "develop"})
+			Expect(generateCmd.Execute()).To(Succeed())
+
+			projectDir := filepath.Join(workDir, "test-acc")
+			commit := git(projectDir, "rev-parse", "--short", "HEAD")
+			Expect(b.String()).To(Equal("project " + projectDir + " created\n" +
+				"initialized Git repository in " + projectDir + " with commit " + commit + "\n" +
+				"pushed branch develop to " + repository + "\n"))
+			Expect(filepath.Join(workDir, "test-acc.zip")).NotTo(BeAnExistingFile())
+			Expect(git(workDir, "--git-dir", repository, "rev-parse", "--short", "develop")).To(Equal(commit))
+			Expect(git(workDir, "--git-dir", repository, "show", "develop:README.md")).To(Equal("generated"))
+			Expect(git(projectDir, "rev-parse", "--abbrev-ref", "develop@{upstream}")).To(Equal("origin/develop"))
+		})
+
+		It("Should not overwrite an existing project", func() {
+			projectDir := filepath.Join(workDir, "test-acc")
+			Expect(os.MkdirAll(projectDir, 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(projectDir, "existing"), []byte{}, 0644)).To(Succeed())
+			generateCmd := GenerateCmd()
+			b := new(bytes.Buffer)
+			generateCmd.SetOut(b)
+			generateCmd.SetErr(b)
+			generateCmd.SetArgs([]string{"test-acc", "--server-url", ts.URL, "--output-dir", workDir, "--git-init"})
+			err := generateCmd.Execute()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("path " + projectDir + " is not empty, use --force to overwrite"))
+		})
+	})
+})
@@ -0,0 +1,251 @@
+/*
+Copyright 2022-2023 VMware, Inc. All Rights Reserved.
+*/
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+)
+
+// defaultHookTimeout is the time a post-generation hook may run unless another timeout is provided
+const defaultHookTimeout = 5 * time.Minute
+
+// hooksConfig is the content of the hooks file of the user
+type hooksConfig struct {
+	PostGenerate []postGenerateHook `json:"postGenerate,omitempty"`
+}
+
+// postGenerateHook is a shell command run in the directory of a generated project
+type postGenerateHook struct {
+	Name    string `json:"name,omitempty"`
+	Command string `json:"command"`
+	// Timeout is a duration like 30s or 2m, it defaults to --hook-timeout
+	Timeout string `json:"timeout,omitempty"`
+	// Accelerators limits the hook to the projects generated from these accelerators, all projects when empty
+	Accelerators []string `json:"accelerators,omitempty"`
+}
+
+// postGenerateHookFlags are the flags of the commands generating projects that run post-generation hooks
+type postGenerateHookFlags struct {
+	commands []string
+	noHooks  bool
+	timeout  time.Duration
+	// config is the hooks file read by load, it is read again for every generation otherwise
+	config *hooksConfig
+}
+
+func (f *postGenerateHookFlags) define(cmd *cobra.Command) {
+	cmd.Flags().StringArrayVar(&f.commands, "post-hook", []string{}, "shell command run in the generated project after the hooks of the hooks file (can be used multiple times)")
+	cmd.Flags().BoolVar(&f.noHooks, "no-hooks", false, "don't run the post-generation hooks of the hooks file and of --post-hook")
+	cmd.Flags().DurationVar(&f.timeout, "hook-timeout", defaultHookTimeout, "the time each post-generation hook may run, unless the hooks file sets another timeout")
+	cmd.MarkFlagsMutuallyExclusive("post-hook", "no-hooks")
+}
+
+// load reads the hooks file once for all the projects generated by the command
+func (f *postGenerateHookFlags) load() error {
+	if f.noHooks {
+		return nil
+	}
+	config, err := readHooksConfig()
+	if err != nil {
+		return err
+	}
+	f.config = &config
+	return nil
+}
+
+// hooks returns the hooks to run for the accelerator, the hooks of the hooks file first
+func (f *postGenerateHookFlags) hooks(accelerator string) ([]postGenerateHook, error) {
+	if f.noHooks {
+		return nil, nil
+	}
+	config := f.config
+	if config == nil {
+		read, err := readHooksConfig()
+		if err != nil {
+			return nil, err
+		}
+		config = &read
+	}
+	hooks := []postGenerateHook{}
+	for _, hook := range config.PostGenerate {
+		if len(hook.Accelerators) == 0 || containsString(hook.Accelerators, accelerator) {
+			hooks = append(hooks, hook)
+		}
+	}
+	for _, command := range f.commands {
+		hooks = append(hooks, postGenerateHook{Command: command})
+	}
+	return hooks, nil
+}
+
+// run runs the hooks in the directory of the generated project, stopping at the first hook that fails
+func (f *postGenerateHookFlags) run(ctx context.Context, cmd *cobra.Command, dir string, accelerator string, options map[string]interface{}) error {
+	hooks, err := f.hooks(accelerator)
+	if err != nil {
+		return err
+	}
+	if len(hooks) == 0 {
+		return nil
+	}
+	env, err := hookEnvironment(dir, accelerator, options)
+	if err != nil {
+		return err
+	}
+	for _, hook := range hooks {
+		name := hook.Name
+		if name == "" {
+			name = hook.Command
+		}
+		timeout := f.timeout
+		if hook.Timeout != "" {
+			// the timeouts of the hooks file are validated when it is read
+			timeout, _ = time.ParseDuration(hook.Timeout)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "running post-generation hook %q\n", name)
+		start := time.Now()
+		if err := runHook(ctx, cmd, dir, hook.Command, env, timeout); err != nil {
+			return fmt.Errorf("post-generation hook %q failed after %.2fs: %v, the project was generated in %s", name, time.Since(start).Seconds(), err, dir)
+		}
+	}
+	return nil
+}
+
+// readHooksConfig reads the hooks file set with the ACC_HOOKS_FILE environment variable or in the configuration
+// directory of the user, there are no hooks when it doesn't exist
+func readHooksConfig() (hooksConfig, error) {
+	config := hooksConfig{}
+	file := EnvVar("ACC_HOOKS_FILE", "")
+	if file == "" {
+		userConfig, err := os.UserConfigDir()
+		if err != nil {
+			return config, nil
+		}
+		file = filepath.Join(userConfig, "tanzu-accelerator", "hooks.yaml")
+	}
+	content, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return config, nil
+	} else if err != nil {
+		return config, err
+	}
+	if err := yaml.UnmarshalStrict(content, &config); err != nil {
+		return config, fmt.Errorf("invalid hooks file %s: %v", file, err)
+	}
+	for i, hook := range config.PostGenerate {
+		if strings.TrimSpace(hook.Command) == "" {
+			return config, fmt.Errorf("invalid hooks file %s: post-generation hook %d has no command", file, i+1)
+		}
+		if hook.Timeout != "" {
+			if timeout, err := time.ParseDuration(hook.Timeout); err != nil || timeout <= 0 {
+				return config, fmt.Errorf("invalid hooks file %s: invalid timeout %q of post-generation hook %d", file, hook.Timeout, i+1)
+			}
+		}
+	}
+	return config, nil
+}
+
+// hookEnvironment returns the environment of the hooks: the environment of the command, the name of the accelerator,
+// the directory of the project and the options. Every option is an ACC_OPTION_ variable named after the option in
+// upper snake case, strings are used as is and other values are JSON encoded. All the options are also available as a
+// JSON object in ACC_OPTIONS.
+func hookEnvironment(dir string, accelerator string, options map[string]interface{}) ([]string, error) {
+	projectDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	encoded, err := json.Marshal(options)
+	if err != nil {
+		return nil, err
+	}
+	env := append(os.Environ(),
+		"ACC_ACCELERATOR="+accelerator,
+		"ACC_PROJECT_DIR="+projectDir,
+		"ACC_OPTIONS="+string(encoded),
+	)
+	names := []string{}
+	for name := range options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value, ok := options[name].(string)
+		if !ok {
+			encoded, err := json.Marshal(options[name])
+			if err != nil {
+				return nil, err
+			}
+			value = string(encoded)
+		}
+		env = append(env, fmt.Sprintf("ACC_OPTION_%s=%s", optionEnvName(name), value))
+	}
+	return env, nil
+}
+
+// optionEnvName converts the name of an option like projectName or java-version to PROJECT_NAME or JAVA_VERSION
+func optionEnvName(name string) string {
+	var b strings.Builder
+	var previous rune
+	for i, r := range name {
+		switch {
+		case unicode.IsUpper(r) && i > 0 && (unicode.IsLower(previous) || unicode.IsDigit(previous)):
+			b.WriteRune('_')
+			b.WriteRune(r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(unicode.ToUpper(r))
+		default:
+			r = '_'
+			b.WriteRune(r)
+		}
+		previous = r
+	}
+	return b.String()
+}
+
+// runHook runs the command with the shell of the platform, its output is shown as it runs
+func runHook(ctx context.Context, cmd *cobra.Command, dir string, command string, env []string, timeout time.Duration) error {
+	var hookCmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		hookCmd = exec.Command("cmd", "/C", command)
+	} else {
+		hookCmd = exec.Command("sh", "-c", command)
+	}
+	hookCmd.Dir = dir
+	hookCmd.Env = env
+	hookCmd.Stdout = cmd.OutOrStdout()
+	hookCmd.Stderr = cmd.ErrOrStderr()
+	if err := hookCmd.Start(); err != nil {
+		return err
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- hookCmd.Wait()
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		// the processes started by the command may keep running, they are not waited for
+		hookCmd.Process.Kill()
+		return fmt.Errorf("timed out after %s", timeout)
+	case <-ctx.Done():
+		hookCmd.Process.Kill()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,153 @@
+package commands
+
+import (
+	"archive/zip"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("command run", func() {
+	Context("post-generation hooks", func() {
+		var workDir string
+		var outputDir string
+		var hooksFile string
+		var ts *httptest.Server
+
+		BeforeEach(func() {
+			var err error
+			workDir, err = os.MkdirTemp("", "hooks")
+			Expect(err).NotTo(HaveOccurred())
+			outputDir = filepath.Join(workDir, "generated")
+			hooksFile = filepath.Join(workDir, "hooks.yaml")
+			os.Setenv("ACC_HOOKS_FILE", hooksFile)
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("/api/about", func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(200)
+				w.Write([]byte("{}"))
+			})
+			mux.HandleFunc("/api/accelerators/zip", func(w http.ResponseWriter, r *http.Request) {
+				zipWriter := zip.NewWriter(w)
+				entry, _ := zipWriter.Create("test/mvnw")
+				entry.Write([]byte("#!/bin/sh\n"))
+				zipWriter.Close()
+			})
+			mux.HandleFunc("/api/accelerators/invoked", func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(200)
+			})
+			ts = httptest.NewServer(mux)
+		})
+
+		AfterEach(func() {
+			ts.Close()
+			os.Unsetenv("ACC_HOOKS_FILE")
+			os.RemoveAll(workDir)
+		})
+
+		writeHooks := func(content string) {
+			Expect(os.WriteFile(hooksFile, []byte(content), 0644)).To(Succeed())
+		}
+		generate := func(args ...string) (string, error) {
+			generateCmd := LocalGenerateCmd()
+			b := new(bytes.Buffer)
+			generateCmd.SetOut(b)
+			generateCmd.SetErr(b)
+			generateCmd.SetArgs(append([]string{"--server-url", ts.URL, "--accelerator-name", "java-rest", "--output-dir", outputDir}, args...))
+			err := generateCmd.Execute()
+			return b.String(), err
+		}
+
+		It("Should run the hooks of the hooks file and of the flags in the generated project", func() {
+			writeHooks(`
+postGenerate:
+- name: make the wrapper executable
+  command: chmod +x mvnw
+  accelerators: [java-rest]
+- command: echo not run
+  accelerators: [go-service]
+`)
+			out, err := generate("--options", `{"projectName":"orders","java-version":17,"includeKubernetes":true}`,
+				"--post-hook", `echo "$ACC_ACCELERATOR $ACC_OPTION_PROJECT_NAME $ACC_OPTION_JAVA_VERSION $ACC_OPTION_INCLUDE_KUBERNETES $(basename "$PWD")"`)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(out).To(Equal("generated project orders\n" +
+				"running post-generation hook \"make the wrapper executable\"\n" +
+				"running post-generation hook \"echo \\\"$ACC_ACCELERATOR $ACC_OPTION_PROJECT_NAME $ACC_OPTION_JAVA_VERSION $ACC_OPTION_INCLUDE_KUBERNETES $(basename \\\"$PWD\\\")\\\"\"\n" +
+				"java-rest orders 17 true generated\n"))
+			fi, err := os.Stat(filepath.Join(outputDir, "mvnw"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fi.Mode().Perm() & 0100).NotTo(BeZero())
+		})
+
+		It("Should run the hooks of generate when the project is extracted", func() {
+			generateCmd := GenerateCmd()
+			b := new(bytes.Buffer)
+			generateCmd.SetOut(b)
+			generateCmd.SetErr(b)
+			generateCmd.SetArgs([]string{"test-acc", "--server-url", ts.URL, "--output-dir", workDir, "--extract", "--post-hook", "echo $ACC_OPTIONS"})
+			Expect(generateCmd.Execute()).To(Succeed())
+			Expect(b.String()).To(Equal("project " + filepath.Join(workDir, "test-acc") + " created\n" +
+				"running post-generation hook \"echo $ACC_OPTIONS\"\n" +
+				"{\"projectName\":\"test-acc\"}\n"))
+		})
+
+		It("Should require the project of generate to be extracted to run hooks", func() {
+			generateCmd := GenerateCmd()
+			b := new(bytes.Buffer)
+			generateCmd.SetOut(b)
+			generateCmd.SetErr(b)
+			generateCmd.SetArgs([]string{"test-acc", "--server-url", ts.URL, "--output-dir", workDir, "--post-hook", "echo not run"})
+			err := generateCmd.Execute()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("--post-hook requires --extract, --git-init or --git-push, the hooks run in the extracted project"))
+			Expect(filepath.Join(workDir, "test-acc.zip")).NotTo(BeAnExistingFile())
+		})
+
+		It("Should stop at the first hook that fails", func() {
+			out, err := generate("--post-hook", "echo failing >&2; exit 3", "--post-hook", "echo not run")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(MatchRegexp(`^post-generation hook "echo failing >&2; exit 3" failed after \d+\.\d\ds: exit status 3, the project was generated in ` + outputDir + `$`))
+			Expect(out).To(HavePrefix("generated project java-rest\nrunning post-generation hook \"echo failing >&2; exit 3\"\nfailing\n"))
+			Expect(out).NotTo(ContainSubstring("not run"))
+		})
+
+		It("Should stop the hooks that time out", func() {
+			writeHooks(`
+postGenerate:
+- name: slow
+  command: sleep 5
+  timeout: 100ms
+`)
+			_, err := generate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(MatchRegexp(`^post-generation hook "slow" failed after 0\.\d\ds: timed out after 100ms, the project was generated in `))
+
+			_, err = generate("--force", "--no-hooks")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("Should reject invalid hooks files", func() {
+			writeHooks("postGenerate:\n- name: missing command\n")
+			_, err := generate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("invalid hooks file " + hooksFile + ": post-generation hook 1 has no command"))
+		})
+
+		It("Should name the environment variables of the options in upper snake case", func() {
+			for name, expected := range map[string]string{
+				"projectName":    "PROJECT_NAME",
+				"java-version":   "JAVA_VERSION",
+				"includeK8s":     "INCLUDE_K8S",
+				"db.url":         "DB_URL",
+				"java17Features": "JAVA17_FEATURES",
+			} {
+				Expect(optionEnvName(name)).To(Equal(expected))
+			}
+		})
+	})
+})
@@ -0,0 +1,329 @@
+/*
+Copyright 2021-2023 VMware, Inc. All Rights Reserved.
+*/
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	acceleratorv1alpha1 "github.com/pivotal/acc-controller/api/v1alpha1"
+	fluxcdv1beta1 "github.com/pivotal/acc-controller/fluxcd/api/v1beta2"
+	"github.com/spf13/cobra"
+	cli "github.com/vmware-tanzu/apps-cli-plugin/pkg/cli-runtime"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// scaffoldManifestFile is the name of the file containing the Accelerator or Fragment manifest in a scaffolded
+// directory, it is excluded from the generated projects by the scaffolded engine
+const scaffoldManifestFile = "k8s-resource.yaml"
+
+const scaffoldGitignore = `# Files that should not be part of the accelerator source
+.DS_Store
+.idea/
+.vscode/
+*.zip
+`
+
+const scaffoldAcceleratorTemplate = `# The accelerator section describes the accelerator as it is shown to the users generating projects with it
+accelerator:
+  displayName: {{ quote .DisplayName }}
+  description: {{ quote .Description }}
+  tags:
+{{- range .Tags }}
+    - {{ quote . }}
+{{- end }}
+
+  # The options the users provide when generating a project, there is a sample option for each data type.
+  # The values are available in the engine as #<option name>, for example #artifactId.
+  options:
+    # a text option, the default data type is string
+    - name: artifactId
+      label: Artifact ID
+      description: The name of the generated project
+      defaultValue: {{ .Name }}
+      required: true
+    # a number option
+    - name: port
+      label: Port
+      dataType: number
+      defaultValue: 8080
+    # a boolean option, shown as a checkbox
+    - name: includeReadme
+      label: Include README
+      dataType: boolean
+      defaultValue: true
+    # a choice of a single value
+    - name: deploymentType
+      label: Deployment Type
+      inputType: select
+      choices:
+        - value: workload
+          text: Workload
+        - value: none
+          text: None
+      defaultValue: workload
+    # a list of values, where multiple choices can be selected
+    - name: features
+      label: Features
+      dataType: [string]
+      inputType: checkbox
+      choices:
+        - value: web
+          text: Web
+        - value: data
+          text: Data
+      defaultValue: [web]
+{{- if .Imports }}
+
+  # The imported fragments, their options are added to the options above
+  imports:
+{{- range .Imports }}
+    - name: {{ . }}
+{{- end }}
+{{- end }}
+
+# The engine transforms the files of the accelerator into the generated project
+engine:
+  merge:
+    - include: ["**"]
+      exclude: ["accelerator.yaml", "{{ .ManifestFile }}", "README.md"]
+    - include: ["README.md"]
+      condition: "#includeReadme"
+      chain:
+        - type: ReplaceText
+          substitutions:
+            - text: "hello-world"
+              with: "#artifactId"
+{{- range .Imports }}
+    - type: InvokeFragment
+      reference: {{ . }}
+{{- end }}
+`
+
+const scaffoldFragmentTemplate = `# The accelerator section of a fragment only declares options, they are added to the options of the accelerators
+# importing the fragment
+accelerator:
+  options:
+    # a text option, the default data type is string
+    - name: {{ .OptionPrefix }}Name
+      label: Name
+      defaultValue: {{ .Name }}
+    # a number option
+    - name: {{ .OptionPrefix }}Replicas
+      label: Replicas
+      dataType: number
+      defaultValue: 1
+    # a boolean option, shown as a checkbox
+    - name: {{ .OptionPrefix }}Enabled
+      label: Enabled
+      dataType: boolean
+      defaultValue: true
+    # a choice of a single value
+    - name: {{ .OptionPrefix }}Level
+      label: Level
+      inputType: select
+      choices:
+        - value: basic
+        - value: advanced
+      defaultValue: basic
+    # a list of values, where multiple choices can be selected
+    - name: {{ .OptionPrefix }}Profiles
+      label: Profiles
+      dataType: [string]
+      inputType: checkbox
+      choices:
+        - value: dev
+        - value: prod
+      defaultValue: [dev]
+
+# The engine transforms the files of the fragment, the result is merged into the project of the accelerator that
+# invokes the fragment
+engine:
+  include: ["**"]
+  exclude: ["accelerator.yaml", "{{ .ManifestFile }}"]
+  condition: "#{{ .OptionPrefix }}Enabled"
+`
+
+const scaffoldReadme = `# hello-world
+
+This project was generated by an accelerator.
+`
+
+// scaffoldValues are the values used to render the scaffolded accelerator.yaml
+type scaffoldValues struct {
+	Name         string
+	DisplayName  string
+	Description  string
+	Tags         []string
+	Imports      []string
+	OptionPrefix string
+	ManifestFile string
+}
+
+func InitCmd(ctx context.Context, c *cli.Config) *cobra.Command {
+	opts := InitOptions{}
+	var initCmd = &cobra.Command{
+		Use:   "init",
+		Short: "Create the directory of a new accelerator",
+		Long: `Create a directory with the files needed to start authoring a new accelerator.
+
+The directory contains an annotated accelerator.yaml with a sample option for each data type, a README.md that is
+changed by the engine, a .gitignore and a k8s-resource.yaml with the Accelerator manifest. Fragments provided with
+--import are imported and invoked by the engine.
+
+The directory can be used with generate-from-local --accelerator-path right away. Once the files are pushed to the
+Git repository, the Accelerator can be created with apply --filename <directory>/k8s-resource.yaml.
+`,
+		Example: `tanzu accelerator init my-accelerator
+tanzu accelerator init my-accelerator --directory ./my-accelerator --git-repository https://github.com/example/my-accelerator --import java-version`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 1 {
+				return errors.New("you must pass the name of the accelerator")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+			if msgs := validation.IsDNS1123Subdomain(name); len(msgs) > 0 {
+				return fmt.Errorf("invalid accelerator name %q: %s", name, strings.Join(msgs, ", "))
+			}
+			directory := opts.Directory
+			if directory == "" {
+				directory = name
+			}
+			displayName := opts.DisplayName
+			if displayName == "" {
+				displayName = name
+			}
+			description := opts.Description
+			if description == "" {
+				description = fmt.Sprintf("Accelerator %s", name)
+			}
+			tags := opts.Tags
+			if len(tags) == 0 {
+				tags = []string{"sample"}
+			}
+
+			metadata, err := renderScaffold(scaffoldAcceleratorTemplate, scaffoldValues{
+				Name:         name,
+				DisplayName:  displayName,
+				Description:  description,
+				Tags:         tags,
+				Imports:      opts.Imports,
+				ManifestFile: scaffoldManifestFile,
+			})
+			if err != nil {
+				return err
+			}
+
+			accelerator := &acceleratorv1alpha1.Accelerator{
+				ObjectMeta: v1.ObjectMeta{Name: name},
+				Spec: acceleratorv1alpha1.AcceleratorSpec{
+					DisplayName: displayName,
+					Description: description,
+					Tags:        tags,
+					Git:         scaffoldGit(name, opts.GitRepoUrl, opts.GitBranch, opts.GitSubPath),
+				},
+			}
+			manifest, err := exportManifest(accelerator, "Accelerator")
+			if err != nil {
+				return err
+			}
+
+			err = writeScaffold(directory, opts.Force, map[string]string{
+				acceleratorMetadataFile: metadata,
+				scaffoldManifestFile:    string(manifest),
+				".gitignore":            scaffoldGitignore,
+				"README.md":             scaffoldReadme,
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "created accelerator %s in %s\n", name, directory)
+			printScaffoldNextSteps(cmd, name, directory, opts.GitRepoUrl == "")
+			return nil
+		},
+	}
+	opts.DefineFlags(ctx, initCmd, c)
+	return initCmd
+}
+
+func renderScaffold(text string, values scaffoldValues) (string, error) {
+	funcs := template.FuncMap{
+		// JSON strings are valid YAML, quoting keeps values containing YAML syntax intact
+		"quote": func(value string) (string, error) {
+			quoted, err := json.Marshal(value)
+			return string(quoted), err
+		},
+	}
+	tmpl, err := template.New("scaffold").Funcs(funcs).Parse(text)
+	if err != nil {
+		return "", err
+	}
+	out := &bytes.Buffer{}
+	if err := tmpl.Execute(out, values); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// scaffoldGit returns the Git source of a scaffolded manifest, using a placeholder URL when no repository is provided
+func scaffoldGit(name string, url string, branch string, subPath string) *acceleratorv1alpha1.Git {
+	if url == "" {
+		url = fmt.Sprintf("https://github.com/<your-org>/%s", name)
+	}
+	git := &acceleratorv1alpha1.Git{
+		URL: url,
+		Reference: &fluxcdv1beta1.GitRepositoryRef{
+			Branch: branch,
+		},
+	}
+	if subPath != "" {
+		git.SubPath = &subPath
+	}
+	return git
+}
+
+// writeScaffold writes the files to the directory, refusing to overwrite existing files unless force is set
+func writeScaffold(directory string, force bool, files map[string]string) error {
+	names := []string{}
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if !force {
+		for _, name := range names {
+			if _, err := os.Stat(filepath.Join(directory, name)); err == nil {
+				return fmt.Errorf("%s already exists, use --force to overwrite it", filepath.Join(directory, name))
+			}
+		}
+	}
+	if err := os.MkdirAll(directory, 0755); err != nil {
+		return err
+	}
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(directory, name), []byte(files[name]), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func printScaffoldNextSteps(cmd *cobra.Command, name string, directory string, placeholderUrl bool) {
+	fmt.Fprintf(cmd.OutOrStdout(), "\nNext steps:\n")
+	fmt.Fprintf(cmd.OutOrStdout(), "  edit %s to define the options and the engine\n", filepath.Join(directory, acceleratorMetadataFile))
+	if placeholderUrl {
+		fmt.Fprintf(cmd.OutOrStdout(), "  replace the placeholder Git repository URL in %s\n", filepath.Join(directory, scaffoldManifestFile))
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "  push the files to the Git repository and run: tanzu accelerator apply --filename %s\n", filepath.Join(directory, scaffoldManifestFile))
+}
@@ -0,0 +1,143 @@
+package commands
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	acceleratorv1alpha1 "github.com/pivotal/acc-controller/api/v1alpha1"
+	clitesting "github.com/vmware-tanzu/apps-cli-plugin/pkg/cli-runtime/testing"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestInitCommand(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = acceleratorv1alpha1.AddToScheme(scheme)
+
+	directory := filepath.Join(t.TempDir(), "my-accelerator")
+	existing := t.TempDir()
+	if err := os.WriteFile(filepath.Join(existing, "accelerator.yaml"), []byte("accelerator: {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	table := clitesting.CommandTestSuite{
+		{
+			Name:        "Missing args",
+			Args:        []string{},
+			ShouldError: true,
+		},
+		{
+			Name:        "Error invalid name",
+			Args:        []string{"My_Accelerator", "--directory", directory},
+			ShouldError: true,
+		},
+		{
+			Name:        "Error existing files",
+			Args:        []string{"my-accelerator", "--directory", existing},
+			ShouldError: true,
+			Verify: func(t *testing.T, output string, err error) {
+				if err == nil || !strings.Contains(err.Error(), "already exists, use --force to overwrite it") {
+					t.Errorf("expected error for existing file, got %v", err)
+				}
+				content, _ := os.ReadFile(filepath.Join(existing, "accelerator.yaml"))
+				if string(content) != "accelerator: {}\n" {
+					t.Errorf("expected existing accelerator.yaml to be kept, got %q", content)
+				}
+			},
+		},
+		{
+			Name: "Init accelerator",
+			Args: []string{"my-accelerator", "--directory", directory, "--git-repository", "https://github.com/example/my-accelerator",
+				"--display-name", "My Accelerator: Sample", "--tags", "java,web", "--import", "java-version"},
+			ExpectOutput: `
+created accelerator my-accelerator in ` + directory + `
+
+Next steps:
+  edit ` + filepath.Join(directory, "accelerator.yaml") + ` to define the options and the engine
+  push the files to the Git repository and run: tanzu accelerator apply --filename ` + filepath.Join(directory, "k8s-resource.yaml") + `
+`,
+			Verify: func(t *testing.T, output string, err error) {
+				for _, file := range []string{"accelerator.yaml", "k8s-resource.yaml", ".gitignore", "README.md"} {
+					if _, err := os.Stat(filepath.Join(directory, file)); err != nil {
+						t.Errorf("expected %s to be created: %v", file, err)
+					}
+				}
+				if problems := validateMetadataFile(directory, map[string]bool{"java-version": true}, true); len(problems) > 0 {
+					t.Errorf("expected a valid accelerator.yaml, got %v", problems)
+				}
+				verifyScaffoldManifest(t, directory, "Accelerator")
+				verifyScaffoldTarball(t, directory, "README.md")
+			},
+		},
+		{
+			Name: "Init accelerator with placeholder repository",
+			Args: []string{"my-accelerator", "--directory", directory, "--force"},
+			ExpectOutput: `
+created accelerator my-accelerator in ` + directory + `
+
+Next steps:
+  edit ` + filepath.Join(directory, "accelerator.yaml") + ` to define the options and the engine
+  replace the placeholder Git repository URL in ` + filepath.Join(directory, "k8s-resource.yaml") + `
+  push the files to the Git repository and run: tanzu accelerator apply --filename ` + filepath.Join(directory, "k8s-resource.yaml") + `
+`,
+			Verify: func(t *testing.T, output string, err error) {
+				if problems := validateMetadataFile(directory, map[string]bool{}, true); len(problems) > 0 {
+					t.Errorf("expected a valid accelerator.yaml, got %v", problems)
+				}
+				content, _ := os.ReadFile(filepath.Join(directory, "k8s-resource.yaml"))
+				if !strings.Contains(string(content), "url: https://github.com/<your-org>/my-accelerator") {
+					t.Errorf("expected placeholder repository in manifest, got %q", content)
+				}
+			},
+		},
+	}
+	table.Run(t, scheme, InitCmd)
+}
+
+// verifyScaffoldManifest checks that the scaffolded manifest is valid for the validate and apply commands
+func verifyScaffoldManifest(t *testing.T, directory string, kind string) {
+	manifest := filepath.Join(directory, "k8s-resource.yaml")
+	documents, err := loadResourcesFromFile(manifest)
+	if err != nil {
+		t.Fatalf("expected a valid manifest: %v", err)
+	}
+	if len(documents) != 1 {
+		t.Fatalf("expected a single resource in the manifest, got %d", len(documents))
+	}
+	for _, problem := range validateManifest(manifest, 1, documents[0].Raw, "accelerator-system") {
+		t.Errorf("expected no problems in the manifest, got %v", problem)
+	}
+	if !strings.Contains(string(documents[0].Raw), `"kind":"`+kind+`"`) {
+		t.Errorf("expected a %s manifest, got %s", kind, documents[0].Raw)
+	}
+}
+
+// verifyScaffoldTarball checks that the scaffolded directory can be packed by generate-from-local
+func verifyScaffoldTarball(t *testing.T, directory string, expected ...string) {
+	buf := &bytes.Buffer{}
+	if _, err := tarToWriter(directory, buf, packageOptions{}); err != nil {
+		t.Fatalf("expected directory to be packed: %v", err)
+	}
+	gzr, err := gzip.NewReader(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gzr)
+	names := []string{}
+	for header, err := tr.Next(); err == nil; header, err = tr.Next() {
+		names = append(names, header.Name)
+	}
+	for _, name := range append(expected, "accelerator.yaml") {
+		found := false
+		for _, n := range names {
+			found = found || n == name
+		}
+		if !found {
+			t.Errorf("expected %s in the packed directory, got %v", name, names)
+		}
+	}
+}
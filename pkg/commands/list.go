@@ -5,10 +5,14 @@ package commands
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
 	"sort"
 	"strings"
 	"text/tabwriter"
+	"time"
+	"unicode"
 
 	acceleratorv1alpha1 "github.com/pivotal/acc-controller/api/v1alpha1"
 	"github.com/spf13/cobra"
@@ -16,6 +20,9 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// listSortFields are the values of the --sort-by flag of list
+var listSortFields = []string{"name", "ready", "tags", "created"}
+
 func ListCmd(ctx context.Context, c *cli.Config) *cobra.Command {
 	var accServerUrl string
 	opts := ListOptions{}
@@ -28,8 +35,37 @@ You can choose to list the accelerators from the Application Accelerator server
 or from a Kubernetes context using --from-context flag. The default is to list accelerators from the
 Kubernetes context. To override this, you can set the ACC_SERVER_URL environment variable with the URL for
 the Application Accelerator server you want to access.
+
+When listing accelerators from a Kubernetes context you can use the --selector flag to only list the
+accelerators with labels matching the provided label selector.
+
+The --tags flag lists the accelerators matching all the provided tag expressions. An expression is a tag, several
+tags separated by "|" to match any of them, or an expression prefixed with "!" to exclude the accelerators matching
+it. For example --tags 'java,!legacy' lists the accelerators tagged java but not legacy, and --tags 'spring|go' the
+accelerators tagged spring or go.
+
+The --search flag lists the accelerators whose name, display name, description or tags match every word of the
+provided text, ignoring case. Words match parts of the fields. Words of 4 letters or more also match the letters of a
+name, display name or tag in order, like "jrest" for "java-rest", or words with one letter added, missing or changed,
+like "sprng" for "spring".
+
+Use --ready-only to only list the accelerators that are ready. These flags work the same when listing accelerators
+from the Application Accelerator server and from a Kubernetes context.
+
+The accelerators are sorted by name unless --sort-by is set: "ready" lists the ready accelerators first, "tags" the
+accelerators with the most tags first and "created" the oldest accelerators first. Accelerators that are equal are
+sorted by name. Sorting by creation time is only supported when listing accelerators from a Kubernetes context.
+
+Large catalogs can be listed in pages with --limit. The accelerators are split in pages by name, and when more
+accelerators are available a token is shown to list the next page with --continue, using the same flags. The other
+flags apply to the accelerators of each page, so a page can list fewer accelerators than the limit and --sort-by
+sorts the accelerators of the page. Without --limit the accelerators are requested from the Kubernetes API server in
+chunks of 500.
 `,
-		Example: "tanzu accelerator list",
+		Example: `tanzu accelerator list
+tanzu accelerator list --tags 'java,!legacy'
+tanzu accelerator list --search "spring boot" --ready-only
+tanzu accelerator list --sort-by created --limit 50`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			var context, kubeconfig bool
 			if cmd.Parent() != nil {
@@ -40,9 +76,24 @@ the Application Accelerator server you want to access.
 			if opts.ServerUrl != "" {
 				serverUrl = opts.ServerUrl
 			}
+			if !containsString(listSortFields, opts.SortBy) {
+				return fmt.Errorf("invalid value %q for --sort-by, must be one of %s", opts.SortBy, strings.Join(listSortFields, ", "))
+			}
+			if opts.Limit < 0 {
+				return fmt.Errorf("invalid value %d for --limit, must not be negative", opts.Limit)
+			}
+			if opts.Continue != "" && opts.Limit == 0 {
+				return errors.New("the --continue flag requires --limit")
+			}
 			w := new(tabwriter.Writer)
 			w.Init(cmd.OutOrStdout(), 0, 8, 3, ' ', 0)
 			if serverUrl != "" && !opts.FromContext && !context && !kubeconfig {
+				if opts.Selector != "" {
+					return errors.New("the --selector flag is only supported when listing accelerators using --from-context")
+				}
+				if opts.SortBy == "created" {
+					return errors.New("--sort-by created is only supported when listing accelerators using --from-context")
+				}
 				return printListFromUiServer(c, serverUrl, opts, cmd, w)
 			} else {
 				return printListFromClient(ctx, c, opts, cmd, w)
@@ -62,11 +113,16 @@ func printListFromUiServer(c *cli.Config, url string, opts ListOptions, cmd *cob
 	sort.Slice(accelerators, func(i, j int) bool {
 		return strings.Compare(accelerators[i].Name, accelerators[j].Name) < 0
 	})
+	// the server returns all the accelerators, the pages are cut by name like the Kubernetes API server does
+	accelerators, next, err := pageOfAccelerators(accelerators, opts.Limit, opts.Continue)
+	if err != nil {
+		return err
+	}
 
-	accList := [][]string{}
+	accList := []listedAccelerator{}
 
 	for _, accelerator := range accelerators {
-		if len(opts.Tags) == 0 || contains(accelerator.Tags, opts.Tags) {
+		if opts.matches(accelerator.Name, accelerator.DisplayName, accelerator.Description, accelerator.Tags, accelerator.Ready) {
 			repo := ""
 			tags := fmt.Sprintf("%v", accelerator.Tags)
 			if accelerator.SpecGitRepositoryUrl != "" {
@@ -86,42 +142,59 @@ func printListFromUiServer(c *cli.Config, url string, opts ListOptions, cmd *cob
 				status = "false"
 			}
 
-			accList = append(accList, []string{accelerator.Name, tags, repo, status})
+			accList = append(accList, listedAccelerator{
+				values: []string{accelerator.Name, tags, repo, status},
+				ready:  accelerator.Ready,
+				tags:   len(accelerator.Tags),
+			})
 		}
 	}
 	w.Flush()
 
-	printAcceleratorList(c, opts, cmd, w, accList)
+	printAcceleratorList(c, opts, cmd, w, sortAccelerators(accList, opts.SortBy))
+	printContinueHint(c, next)
 	return nil
 }
 
 func printListFromClient(ctx context.Context, c *cli.Config, opts ListOptions, cmd *cobra.Command, w *tabwriter.Writer) error {
-	accelerators := &acceleratorv1alpha1.AcceleratorList{}
-	err := c.List(ctx, accelerators, client.InNamespace(opts.Namespace))
+	listOpts, err := selectorListOptions(opts.Namespace, opts.Selector)
+	if err != nil {
+		return err
+	}
+	accelerators, next, err := listAccelerators(ctx, c, listOpts, opts.Limit, opts.Continue)
 	if err != nil {
 		fmt.Fprintf(cmd.OutOrStderr(), "There was an error listing accelerators\n")
 		return err
 	}
 
-	accList := [][]string{}
+	accList := []listedAccelerator{}
 
-	for _, accelerator := range accelerators.Items {
-
-		if len(opts.Tags) == 0 || contains(accelerator.Status.Tags, opts.Tags) {
-
-			values := []string{accelerator.Name}
+	for _, accelerator := range accelerators {
 
-			status := "unknown"
-			for _, cond := range accelerator.Status.Conditions {
-				if cond.Type == "Ready" {
-					if cond.Status == "True" {
-						status = "true"
-					} else {
-						status = "false"
-					}
-					break
+		status := "unknown"
+		for _, cond := range accelerator.Status.Conditions {
+			if cond.Type == "Ready" {
+				if cond.Status == "True" {
+					status = "true"
+				} else {
+					status = "false"
 				}
+				break
 			}
+		}
+		// the status has the values of the accelerator once it is reconciled
+		displayName := accelerator.Status.DisplayName
+		if displayName == "" {
+			displayName = accelerator.Spec.DisplayName
+		}
+		description := accelerator.Status.Description
+		if description == "" {
+			description = accelerator.Spec.Description
+		}
+
+		if opts.matches(accelerator.Name, displayName, description, accelerator.Status.Tags, status == "true") {
+
+			values := []string{accelerator.Name}
 
 			repo := ""
 			tags := fmt.Sprintf("%v", accelerator.Status.Tags)
@@ -142,14 +215,108 @@ func printListFromClient(ctx context.Context, c *cli.Config, opts ListOptions, c
 			} else {
 				values = append(values, "", "", "")
 			}
-			accList = append(accList, values)
+			accList = append(accList, listedAccelerator{
+				values:  values,
+				ready:   status == "true",
+				tags:    len(accelerator.Status.Tags),
+				created: accelerator.CreationTimestamp.Time,
+			})
 		}
 	}
 
-	printAcceleratorList(c, opts, cmd, w, accList)
+	printAcceleratorList(c, opts, cmd, w, sortAccelerators(accList, opts.SortBy))
+	printContinueHint(c, next)
 	return nil
 }
 
+// listChunkSize is the number of accelerators requested at a time from the Kubernetes API server when listing all of
+// them
+const listChunkSize = 500
+
+// listAccelerators lists the accelerators from the Kubernetes API server in chunks. With a limit only one chunk starting
+// at the continue token is listed, and the token of the next chunk is returned when there are more accelerators.
+func listAccelerators(ctx context.Context, reader client.Reader, listOpts []client.ListOption, limit int64, continueToken string) ([]acceleratorv1alpha1.Accelerator, string, error) {
+	if limit > 0 {
+		accelerators := &acceleratorv1alpha1.AcceleratorList{}
+		err := reader.List(ctx, accelerators, append(listOpts, client.Limit(limit), client.Continue(continueToken))...)
+		return accelerators.Items, accelerators.Continue, err
+	}
+	items := []acceleratorv1alpha1.Accelerator{}
+	for {
+		accelerators := &acceleratorv1alpha1.AcceleratorList{}
+		if err := reader.List(ctx, accelerators, append(listOpts, client.Limit(listChunkSize), client.Continue(continueToken))...); err != nil {
+			return nil, "", err
+		}
+		items = append(items, accelerators.Items...)
+		if accelerators.Continue == "" {
+			return items, "", nil
+		}
+		continueToken = accelerators.Continue
+	}
+}
+
+// pageOfAccelerators returns the accelerators, sorted by name, after the one encoded in the continue token, at most
+// limit of them, and the token of the next page when there are more accelerators
+func pageOfAccelerators(accelerators []Accelerator, limit int64, continueToken string) ([]Accelerator, string, error) {
+	if limit <= 0 {
+		return accelerators, "", nil
+	}
+	start := 0
+	if continueToken != "" {
+		last, err := base64.RawURLEncoding.DecodeString(continueToken)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid continue token %q", continueToken)
+		}
+		start = sort.Search(len(accelerators), func(i int) bool {
+			return accelerators[i].Name > string(last)
+		})
+	}
+	end := start + int(limit)
+	if end >= len(accelerators) {
+		return accelerators[start:], "", nil
+	}
+	return accelerators[start:end], base64.RawURLEncoding.EncodeToString([]byte(accelerators[end-1].Name)), nil
+}
+
+// listedAccelerator is a row of the list of accelerators with the values it can be sorted by
+type listedAccelerator struct {
+	// values are the name, tags, repository and ready status
+	values  []string
+	ready   bool
+	tags    int
+	created time.Time
+}
+
+// sortAccelerators sorts the accelerators by name, by readiness with the ready accelerators first, by number of tags
+// with the most tagged accelerators first or by creation time with the oldest accelerators first. Accelerators that
+// are equal are sorted by name, so that the order is the same for every source.
+func sortAccelerators(accelerators []listedAccelerator, sortBy string) [][]string {
+	sort.SliceStable(accelerators, func(i, j int) bool {
+		a, b := accelerators[i], accelerators[j]
+		switch {
+		case sortBy == "ready" && a.ready != b.ready:
+			return a.ready
+		case sortBy == "tags" && a.tags != b.tags:
+			return a.tags > b.tags
+		case sortBy == "created" && !a.created.Equal(b.created):
+			return a.created.Before(b.created)
+		}
+		return a.values[0] < b.values[0]
+	})
+	rows := [][]string{}
+	for _, accelerator := range accelerators {
+		rows = append(rows, accelerator.values)
+	}
+	return rows
+}
+
+// printContinueHint shows how to list the next page of accelerators when there is one
+func printContinueHint(c *cli.Config, next string) {
+	if next != "" {
+		c.Infof("\nMore accelerators are available, use --continue %s to list them.\n", next)
+	}
+}
+
 func contains(accTags []string, input []string) bool {
 
 	for _, inputTag := range input {
@@ -167,6 +334,112 @@ func contains(accTags []string, input []string) bool {
 	return true
 }
 
+// matches returns true if the accelerator matches the --tags, --search and --ready-only flags
+func (opts ListOptions) matches(name string, displayName string, description string, tags []string, ready bool) bool {
+	if opts.ReadyOnly && !ready {
+		return false
+	}
+	if !matchesTagExpressions(tags, opts.Tags) {
+		return false
+	}
+	for _, word := range strings.Fields(strings.ToLower(opts.Search)) {
+		if !fuzzyMatches(word, []string{name, displayName, description}, tags) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesTagExpressions returns true if the tags match all the expressions. An expression is a tag, tags separated by
+// "|" matching any of them, or an expression prefixed with "!" matching the tags that don't match the expression.
+func matchesTagExpressions(accTags []string, expressions []string) bool {
+	for _, expression := range expressions {
+		expression = strings.TrimSpace(expression)
+		if expression == "" {
+			continue
+		}
+		negated := strings.HasPrefix(expression, "!")
+		if negated {
+			expression = strings.TrimSpace(strings.TrimPrefix(expression, "!"))
+		}
+		matched := false
+		for _, inputTag := range strings.Split(expression, "|") {
+			if contains(accTags, []string{strings.TrimSpace(inputTag)}) {
+				matched = true
+				break
+			}
+		}
+		if matched == negated {
+			return false
+		}
+	}
+	return true
+}
+
+// fuzzyMatches returns true if the lower case word is part of one of the fields or tags. Words of 4 letters or more also
+// match if their letters appear in order in the name, display name or a tag, or if they are one edit away from a word
+// of the fields or tags.
+func fuzzyMatches(word string, fields []string, tags []string) bool {
+	short := append([]string{fields[0], fields[1]}, tags...)
+	candidates := []string{}
+	for _, field := range append(fields, tags...) {
+		field = strings.ToLower(field)
+		if strings.Contains(field, word) {
+			return true
+		}
+		candidates = append(candidates, strings.FieldsFunc(field, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})...)
+	}
+	// short words would match too many fields by their letters or with a typo
+	if len(word) < 4 {
+		return false
+	}
+	for _, field := range short {
+		if isSubsequence(word, strings.ToLower(field)) {
+			return true
+		}
+	}
+	for _, candidate := range candidates {
+		if withinOneEdit(word, candidate) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSubsequence returns true if the letters of the word appear in the same order in the text
+func isSubsequence(word string, text string) bool {
+	letters := []rune(word)
+	i := 0
+	for _, r := range text {
+		if i < len(letters) && r == letters[i] {
+			i++
+		}
+	}
+	return len(letters) > 0 && i == len(letters)
+}
+
+// withinOneEdit returns true if a is b with at most one letter added, removed or changed
+func withinOneEdit(a string, b string) bool {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) > len(rb) {
+		ra, rb = rb, ra
+	}
+	if len(rb)-len(ra) > 1 {
+		return false
+	}
+	// skip the common prefix, the rest must then be equal once one letter is removed from b, or changed in both
+	i := 0
+	for i < len(ra) && ra[i] == rb[i] {
+		i++
+	}
+	if len(ra) == len(rb) {
+		return i >= len(ra)-1 || string(ra[i+1:]) == string(rb[i+1:])
+	}
+	return string(ra[i:]) == string(rb[i+1:])
+}
+
 func printAcceleratorList(c *cli.Config, opts ListOptions, cmd *cobra.Command, w *tabwriter.Writer, accelerators [][]string) {
 	if len(accelerators) == 0 {
 		c.Infof("No accelerators found.\n")
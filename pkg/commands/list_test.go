@@ -3,10 +3,13 @@ package commands
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strconv"
 	"testing"
+	"time"
 
 	cli "github.com/vmware-tanzu/apps-cli-plugin/pkg/cli-runtime"
 	clitesting "github.com/vmware-tanzu/apps-cli-plugin/pkg/cli-runtime/testing"
@@ -48,6 +51,49 @@ func TestAcceleratorListCommand(t *testing.T) {
 		w.Write(mockResponse)
 	}))
 
+	searchServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mockAccelerators := UiAcceleratorsApiResponse{
+			Emdedded: Embedded{
+				Accelerators: []Accelerator{
+					{Name: "java-rest", DisplayName: "Java REST", Description: "A Spring Boot REST service", Tags: []string{"java", "spring"}, Ready: true},
+					{Name: "go-service", DisplayName: "Go Service", Description: "A Go web service", Tags: []string{"go"}},
+					{Name: "legacy-java", DisplayName: "Legacy Java", Description: "An old servlet application", Tags: []string{"java", "legacy"}, Ready: true},
+				},
+			},
+		}
+		mockResponse, _ := json.Marshal(mockAccelerators)
+		w.Write(mockResponse)
+	}))
+	searchAccelerator := func(name string, displayName string, description string, tags []string, ready metav1.ConditionStatus, created string) *acceleratorv1alpha1.Accelerator {
+		creationTimestamp, _ := time.Parse(time.RFC3339, created)
+		return &acceleratorv1alpha1.Accelerator{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         namespace,
+				CreationTimestamp: metav1.NewTime(creationTimestamp),
+			},
+			Spec: acceleratorv1alpha1.AcceleratorSpec{
+				Git: &acceleratorv1alpha1.Git{
+					URL: "https://www.test.com",
+					Reference: &v1beta2.GitRepositoryRef{
+						Branch: "main",
+					},
+				},
+			},
+			Status: acceleratorv1alpha1.AcceleratorStatus{
+				DisplayName: displayName,
+				Description: description,
+				Tags:        tags,
+				Conditions:  []metav1.Condition{{Type: "Ready", Status: ready}},
+			},
+		}
+	}
+	searchObjects := []client.Object{
+		searchAccelerator("java-rest", "Java REST", "A Spring Boot REST service", []string{"java", "spring"}, metav1.ConditionTrue, "2023-03-01T10:00:00Z"),
+		searchAccelerator("go-service", "Go Service", "A Go web service", []string{"go"}, metav1.ConditionFalse, "2023-02-01T10:00:00Z"),
+		searchAccelerator("legacy-java", "Legacy Java", "An old servlet application", []string{"java", "legacy"}, metav1.ConditionTrue, "2022-01-01T10:00:00Z"),
+	}
+
 	os.Setenv("ACC_SERVER_URL", ts.URL)
 	scheme := runtime.NewScheme()
 	_ = acceleratorv1alpha1.AddToScheme(scheme)
@@ -244,6 +290,318 @@ NAME               TAGS   READY
 test-accelerator   []     unknown
 `,
 		},
+		{
+			Name: "List accelerators from context matching selector",
+			Args: []string{"--from-context", "--selector", "team=alpha"},
+			GivenObjects: []client.Object{
+				&acceleratorv1alpha1.Accelerator{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      acceleratorName,
+						Namespace: namespace,
+						Labels:    map[string]string{"team": "alpha"},
+					},
+					Spec: acceleratorv1alpha1.AcceleratorSpec{
+						Git: &acceleratorv1alpha1.Git{
+							URL: "https://www.test.com",
+							Reference: &v1beta2.GitRepositoryRef{
+								Branch: "main",
+							},
+						},
+					},
+				},
+				&acceleratorv1alpha1.Accelerator{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "another-accelerator",
+						Namespace: namespace,
+						Labels:    map[string]string{"team": "beta"},
+					},
+					Spec: acceleratorv1alpha1.AcceleratorSpec{
+						Git: &acceleratorv1alpha1.Git{
+							URL: "https://www.test.com",
+							Reference: &v1beta2.GitRepositoryRef{
+								Branch: "main",
+							},
+						},
+					},
+				},
+			},
+			ExpectOutput: `
+NAME               TAGS   READY
+test-accelerator   []     unknown
+`,
+		},
+		{
+			Name: "List accelerators server-url with negated tag expression",
+			Args: []string{"--server-url", searchServer.URL, "--tags", "java,!legacy"},
+			ExpectOutput: `
+NAME        TAGS            READY
+java-rest   [java spring]   true
+`,
+		},
+		{
+			Name:         "List accelerators from context with negated tag expression",
+			Args:         []string{"--from-context", "--tags", "java,!legacy"},
+			GivenObjects: searchObjects,
+			ExpectOutput: `
+NAME        TAGS            READY
+java-rest   [java spring]   true
+`,
+		},
+		{
+			Name: "List accelerators server-url with alternative tag expression",
+			Args: []string{"--server-url", searchServer.URL, "--tags", "spring|go"},
+			ExpectOutput: `
+NAME         TAGS            READY
+go-service   [go]            false
+java-rest    [java spring]   true
+`,
+		},
+		{
+			Name:         "List accelerators from context with alternative tag expression",
+			Args:         []string{"--from-context", "--tags", "spring|go"},
+			GivenObjects: searchObjects,
+			ExpectOutput: `
+NAME         TAGS            READY
+go-service   [go]            false
+java-rest    [java spring]   true
+`,
+		},
+		{
+			Name: "List accelerators server-url searching with a typo",
+			Args: []string{"--server-url", searchServer.URL, "--search", "sprng"},
+			ExpectOutput: `
+NAME        TAGS            READY
+java-rest   [java spring]   true
+`,
+		},
+		{
+			Name:         "List accelerators from context searching with a typo",
+			Args:         []string{"--from-context", "--search", "sprng"},
+			GivenObjects: searchObjects,
+			ExpectOutput: `
+NAME        TAGS            READY
+java-rest   [java spring]   true
+`,
+		},
+		{
+			Name: "List accelerators server-url searching the letters of the name",
+			Args: []string{"--server-url", searchServer.URL, "--search", "jrest"},
+			ExpectOutput: `
+NAME        TAGS            READY
+java-rest   [java spring]   true
+`,
+		},
+		{
+			Name:         "List accelerators from context searching the letters of the name",
+			Args:         []string{"--from-context", "--search", "jrest"},
+			GivenObjects: searchObjects,
+			ExpectOutput: `
+NAME        TAGS            READY
+java-rest   [java spring]   true
+`,
+		},
+		{
+			Name: "List accelerators server-url searching words of the description",
+			Args: []string{"--server-url", searchServer.URL, "--search", "Web SERVICE"},
+			ExpectOutput: `
+NAME         TAGS   READY
+go-service   [go]   false
+`,
+		},
+		{
+			Name:         "List accelerators from context searching words of the description",
+			Args:         []string{"--from-context", "--search", "Web SERVICE"},
+			GivenObjects: searchObjects,
+			ExpectOutput: `
+NAME         TAGS   READY
+go-service   [go]   false
+`,
+		},
+		{
+			Name: "List accelerators server-url only ready",
+			Args: []string{"--server-url", searchServer.URL, "--ready-only"},
+			ExpectOutput: `
+NAME          TAGS            READY
+java-rest     [java spring]   true
+legacy-java   [java legacy]   true
+`,
+		},
+		{
+			Name:         "List accelerators from context only ready",
+			Args:         []string{"--from-context", "--ready-only"},
+			GivenObjects: searchObjects,
+			ExpectOutput: `
+NAME          TAGS            READY
+java-rest     [java spring]   true
+legacy-java   [java legacy]   true
+`,
+		},
+		{
+			Name: "List accelerators from context searching a short word",
+			Args: []string{"--from-context", "--search", "go"},
+			GivenObjects: []client.Object{
+				searchAccelerator("spring-boot", "Spring Boot", "A Spring Boot application", []string{"spring"}, metav1.ConditionTrue, "2023-01-01T10:00:00Z"),
+				searchAccelerator("go-service", "Go Service", "A Go web service", []string{"go"}, metav1.ConditionFalse, "2023-02-01T10:00:00Z"),
+			},
+			ExpectOutput: `
+NAME         TAGS   READY
+go-service   [go]   false
+`,
+		},
+		{
+			Name: "List accelerators server-url searching without matches",
+			Args: []string{"--server-url", searchServer.URL, "--search", "kotlin"},
+			ExpectOutput: `
+No accelerators found.
+`,
+		},
+		{
+			Name:         "List accelerators from context searching without matches",
+			Args:         []string{"--from-context", "--search", "kotlin"},
+			GivenObjects: searchObjects,
+			ExpectOutput: `
+No accelerators found.
+`,
+		},
+		{
+			Name: "List accelerators server-url sorted by ready",
+			Args: []string{"--server-url", searchServer.URL, "--sort-by", "ready"},
+			ExpectOutput: `
+NAME          TAGS            READY
+java-rest     [java spring]   true
+legacy-java   [java legacy]   true
+go-service    [go]            false
+`,
+		},
+		{
+			Name:         "List accelerators from context sorted by ready",
+			Args:         []string{"--from-context", "--sort-by", "ready"},
+			GivenObjects: searchObjects,
+			ExpectOutput: `
+NAME          TAGS            READY
+java-rest     [java spring]   true
+legacy-java   [java legacy]   true
+go-service    [go]            false
+`,
+		},
+		{
+			Name: "List accelerators server-url sorted by tags",
+			Args: []string{"--server-url", searchServer.URL, "--sort-by", "tags"},
+			ExpectOutput: `
+NAME          TAGS            READY
+java-rest     [java spring]   true
+legacy-java   [java legacy]   true
+go-service    [go]            false
+`,
+		},
+		{
+			Name:         "List accelerators from context sorted by creation time",
+			Args:         []string{"--from-context", "--sort-by", "created"},
+			GivenObjects: searchObjects,
+			ExpectOutput: `
+NAME          TAGS            READY
+legacy-java   [java legacy]   true
+go-service    [go]            false
+java-rest     [java spring]   true
+`,
+		},
+		{
+			Name: "List accelerators server-url with a limit",
+			Args: []string{"--server-url", searchServer.URL, "--limit", "2"},
+			ExpectOutput: `
+NAME         TAGS            READY
+go-service   [go]            false
+java-rest    [java spring]   true
+
+More accelerators are available, use --continue amF2YS1yZXN0 to list them.
+`,
+		},
+		{
+			Name: "List accelerators server-url continuing after a limit",
+			Args: []string{"--server-url", searchServer.URL, "--limit", "2", "--continue", "amF2YS1yZXN0"},
+			ExpectOutput: `
+NAME          TAGS            READY
+legacy-java   [java legacy]   true
+`,
+		},
+		{
+			Name:        "Error listing accelerators server-url sorted by creation time",
+			Args:        []string{"--server-url", searchServer.URL, "--sort-by", "created"},
+			ShouldError: true,
+		},
+		{
+			Name:         "Error listing accelerators with an invalid sort",
+			Args:         []string{"--from-context", "--sort-by", "size"},
+			GivenObjects: searchObjects,
+			ShouldError:  true,
+		},
+		{
+			Name:         "Error listing accelerators continuing without a limit",
+			Args:         []string{"--from-context", "--continue", "abc"},
+			GivenObjects: searchObjects,
+			ShouldError:  true,
+		},
+		{
+			Name:        "Error listing accelerators server-url with selector",
+			Args:        []string{"--server-url", ts.URL, "--selector", "team=alpha"},
+			ShouldError: true,
+		},
 	}
 	table.Run(t, scheme, ListCmd)
 }
+
+// chunkedReader lists the accelerators in chunks like the Kubernetes API server, the continue token is the offset of
+// the next chunk
+type chunkedReader struct {
+	client.Reader
+	accelerators []acceleratorv1alpha1.Accelerator
+	calls        int
+}
+
+func (r *chunkedReader) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	r.calls++
+	listOpts := &client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+	start, _ := strconv.Atoi(listOpts.Continue)
+	end := len(r.accelerators)
+	if listOpts.Limit > 0 && start+int(listOpts.Limit) < end {
+		end = start + int(listOpts.Limit)
+	}
+	accelerators := list.(*acceleratorv1alpha1.AcceleratorList)
+	accelerators.Items = r.accelerators[start:end]
+	if end < len(r.accelerators) {
+		accelerators.Continue = strconv.Itoa(end)
+	}
+	return nil
+}
+
+func TestListAcceleratorsInChunks(t *testing.T) {
+	reader := &chunkedReader{}
+	for i := 0; i < 2*listChunkSize+1; i++ {
+		reader.accelerators = append(reader.accelerators, acceleratorv1alpha1.Accelerator{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("accelerator-%04d", i)}})
+	}
+
+	accelerators, next, err := listAccelerators(context.Background(), reader, nil, 0, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(accelerators) != 2*listChunkSize+1 || next != "" || reader.calls != 3 {
+		t.Errorf("expected %d accelerators in 3 chunks, got %d accelerators in %d chunks with continue %q", 2*listChunkSize+1, len(accelerators), reader.calls, next)
+	}
+
+	accelerators, next, err = listAccelerators(context.Background(), reader, nil, 10, "995")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(accelerators) != 6 || accelerators[0].Name != "accelerator-0995" || next != "" {
+		t.Errorf("expected the 6 last accelerators, got %d accelerators starting at %s with continue %q", len(accelerators), accelerators[0].Name, next)
+	}
+
+	accelerators, next, err = listAccelerators(context.Background(), reader, nil, 10, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(accelerators) != 10 || next != "10" {
+		t.Errorf("expected 10 accelerators with continue \"10\", got %d accelerators with continue %q", len(accelerators), next)
+	}
+}
@@ -8,18 +8,25 @@ import (
 	"archive/zip"
 	"bytes"
 	"compress/gzip"
+	"context"
+	"crypto/sha256"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"io/ioutil"
 	"mime/multipart"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/denormal/go-gitignore"
+	"github.com/fsnotify/fsnotify"
 	"github.com/spf13/cobra"
 )
 
@@ -76,6 +83,16 @@ func LocalGenerateCmd() *cobra.Command {
 	var fragmentNames []string
 	var localFragments map[string]string
 	var forceOverwrite bool
+	var watch bool
+	var watchDebounce time.Duration
+	var excludes []string
+	var includes []string
+	var dryRun bool
+	var showProgress bool
+	var cacheFlags generateCacheFlags
+	var gitFlags gitInitFlags
+	var hookFlags postGenerateHookFlags
+	var previewOpts previewFlags
 	var localGenerateCommand = &cobra.Command{
 		Use:   "generate-from-local",
 		Short: "Generate project from a combination of registered and local artifacts",
@@ -95,198 +112,846 @@ You can also provide a file that specifies the JSON string using the --options-f
 The generate-from-local command needs access to the Application Accelerator server. You can specify the --server-url flag or set
 an ACC_SERVER_URL environment variable. If you specify the --server-url flag it will override the ACC_SERVER_URL
 environment variable if it is set.
+
+With --watch the command keeps running after generating the project and watches the --accelerator-path and
+--fragment-paths directories. When files change, the project is generated again into the same directory and the
+files that were added, changed or removed compared to the previous run are listed. When only fragments are generated,
+the files of the existing project that conflict with the generated files are only overwritten with --force. Press
+Ctrl+C to stop watching.
+
+The files in the local directories are packaged and uploaded to the server, except for the .git directory and the files
+matching the patterns in .gitignore and .acceleratorignore files. The .acceleratorignore file uses the .gitignore
+syntax and is meant for files that are part of the repository but not of the accelerator, like tests or build
+output. More patterns can be excluded with --exclude, and --include adds back files that would otherwise be left out.
+Use --dry-run to list the files that would be packaged, with their sizes, without contacting the server.
+
+Executable files, empty directories and symbolic links are kept when the files are packaged and when the generated
+project is extracted. Symbolic links must use a relative path to a file or directory inside the accelerator or
+fragment directory. The packages are reproducible: their modification times, owners and permissions are normalized,
+so the same files always produce the same package. The digest of each package is shown by --dry-run.
+
+With --cache, or when the ACC_GENERATE_CACHE environment variable is set to true, the generated projects are kept in a
+local cache and generating the same project again doesn't contact the server to generate it. The projects are cached
+by the digests of the local accelerator and fragments, the revision of the registered accelerator, the names of the
+registered fragments and the options. Changes to registered fragments are not detected, use --no-cache or
+"tanzu accelerator cache prune --all" after updating them. The cache is limited to --cache-max-size, the least
+recently used projects are removed first.
+
+The local directories are packaged while they are uploaded and the generated project is downloaded to a temporary file
+before it is extracted. When the standard error is a terminal, or with --progress, the number of bytes uploaded and
+downloaded is shown while the project is generated.
+
+With --git-init a Git repository is initialized in the output directory with an initial commit of the generated
+files. The message of the commit includes the name of the accelerator and the options. With --git-push the initial
+commit is also pushed to the given remote repository, using any URL supported by git such as an SSH, HTTPS or file
+URL. The remote repository should be empty, and the credentials are the ones git uses for the URL.
+
+Post-generation hooks are shell commands run in the output directory after the project is generated, before the Git
+repository is initialized and after every generation in --watch mode. They are declared in the hooks file, set with
+the ACC_HOOKS_FILE environment variable or tanzu-accelerator/hooks.yaml in the configuration directory of the user,
+and with --post-hook. For example:
+
+    postGenerate:
+    - name: make the Maven wrapper executable
+      command: chmod +x mvnw
+      accelerators: [java-rest]
+    - command: go mod tidy
+      timeout: 2m
+      accelerators: [go-service]
+
+The hooks run in order, with the name of the accelerator in ACC_ACCELERATOR, the project directory in ACC_PROJECT_DIR,
+the options as a JSON object in ACC_OPTIONS and every option in an ACC_OPTION_ variable named after the option in upper
+snake case, for example ACC_OPTION_PROJECT_NAME. Each hook may run for --hook-timeout unless it sets its own timeout.
+The command fails at the first hook that fails. Use --no-hooks to skip the hooks.
+
+Fragments can be added to an existing project: without --accelerator-name or --accelerator-path, the project is
+generated from the --fragment-names and --fragment-paths only and the generated files are applied to the existing
+project in --output-dir, which is required. The "projectName" option defaults to the name of the output directory. The
+existing files that are not generated are kept, and the generated files are added. When a generated file already
+exists with a different content the command fails and lists the conflicting files without changing any file, use
+--force to overwrite them. In this mode --force never removes the existing files.
+
+With --preview nothing is written: the files of the generated project are printed as a tree with their sizes, and
+the content of the files matching the --show patterns is printed after the tree. The patterns match the paths of the
+files in the project, "*" matches any part of a file or directory name and "**" any number of directories, for example
+--show README.md or --show 'src/**/*.java'. With --diff-against the generated project is compared with an existing
+directory instead: the files the generated project would add (+), change (~) or remove (-) are printed, with the
+differences of the changed text files. The .git directory is ignored. When only fragments are generated, the files of
+the directory are never removed.
 `,
-		Example: `tanzu accelerator generate-from-local --accelerator-path java-rest=workspace/java-rest --fragment-paths java-version=workspace/version --fragment-names tap-workload --options '{"projectName":"test"}'`,
+		Example: `tanzu accelerator generate-from-local --accelerator-path java-rest=workspace/java-rest --fragment-paths java-version=workspace/version --fragment-names tap-workload --options '{"projectName":"test"}'
+tanzu accelerator generate-from-local --accelerator-path java-rest=workspace/java-rest --output-dir workspace/generated --force --watch
+tanzu accelerator generate-from-local --accelerator-path java-rest=workspace/java-rest --exclude 'target/' --exclude '*.jar' --dry-run
+tanzu accelerator generate-from-local --fragment-names tap-workload --options '{"projectName":"orders"}' --output-dir workspace/orders
+tanzu accelerator generate-from-local --accelerator-path java-rest=workspace/java-rest --git-push git@github.com:example/test.git`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			// build a form body
-			requestBody := &bytes.Buffer{}
-			bodyWriter := multipart.NewWriter(requestBody)
+			if err := previewOpts.validate(); err != nil {
+				return err
+			}
+			if watch && localAccelerator.isEmpty() && len(localFragments) == 0 {
+				return errors.New("--watch requires --accelerator-path or --fragment-paths")
+			}
+			packaging := packageOptions{excludes: excludes, includes: includes}
+			if dryRun {
+				if localAccelerator.isEmpty() && len(localFragments) == 0 {
+					return errors.New("--dry-run requires --accelerator-path or --fragment-paths")
+				}
+				return printPackagedFiles(cmd, localAccelerator, localFragments, packaging)
+			}
 
-			var defaultProjectName string
-			if !localAccelerator.isEmpty() {
-				defaultProjectName = localAccelerator.key
-				accFolderName := localAccelerator.value
-				fileWriter, err := bodyWriter.CreateFormFile("accelerator", defaultProjectName+".tar.gz")
+			// without an accelerator the files generated by the fragments are applied to an existing project
+			fragmentsOnly := localAccelerator.isEmpty() && acceleratorName == "" && (len(fragmentNames) > 0 || len(localFragments) > 0)
+			if fragmentsOnly && gitFlags.enabled() {
+				return errors.New("--git-init and --git-push can't be used when only fragments are generated, the fragments are applied to an existing project")
+			}
 
-				err = tarToWriter(accFolderName, fileWriter)
-				if err != nil {
-					return err
-				}
-			} else if acceleratorName != "" {
-				defaultProjectName = acceleratorName
-				field, err := bodyWriter.CreateFormField("accelerator_name")
-				if err != nil {
-					return err
-				}
-				_, err = field.Write([]byte(acceleratorName))
-				if err != nil {
-					return err
+			// generate builds the request from the local files, generates the project and returns the directory
+			// it was extracted to, the options of the generated project are kept in options
+			var options map[string]interface{}
+			var server *acceleratorServer
+			generate := func(force bool) (string, error) {
+				var defaultProjectName string
+				if !localAccelerator.isEmpty() {
+					defaultProjectName = localAccelerator.key
+				} else if acceleratorName != "" {
+					defaultProjectName = acceleratorName
+				} else if fragmentsOnly {
+					if outputDirectory == "" {
+						return "", errors.New("no output directory, you must provide --output-dir with the existing project the fragments are applied to")
+					}
+					abs, err := filepath.Abs(outputDirectory)
+					if err != nil {
+						return "", err
+					}
+					defaultProjectName = filepath.Base(abs)
+				} else {
+					return "", errors.New("no accelerator, you must provide --accelerator-name or --accelerator-path")
 				}
-				if err != nil {
-					return err
+				// the local directories are packaged while the request is sent, check them before sending it
+				if err := checkLocalDirectories(localAccelerator, localFragments); err != nil {
+					return "", err
 				}
-			} else {
-				return errors.New("no accelerator, you must provide --accelerator-name or --accelerator-path")
-			}
 
-			for _, fragmentName := range fragmentNames {
-				field, err := bodyWriter.CreateFormField("fragment_names")
-				if err != nil {
-					return err
+				options = nil
+				if optionsFilename != "" {
+					fileBytes, err := ioutil.ReadFile(optionsFilename)
+					if err != nil {
+						return "", err
+					}
+					optionsString = string(fileBytes)
 				}
-				_, err = field.Write([]byte(fragmentName))
+				err := json.Unmarshal([]byte(optionsString), &options)
 				if err != nil {
-					return err
+					return "", errors.New("invalid options provided, must be valid JSON")
+				}
+				if _, found := options["projectName"]; !found {
+					options["projectName"] = defaultProjectName
 				}
-			}
 
-			for fragmentName, fragmentFolderName := range localFragments {
-				fileWriter, err := bodyWriter.CreateFormFile("fragment_"+fragmentName, fragmentName+".tar.gz")
-				err = tarToWriter(fragmentFolderName, fileWriter)
-				if err != nil {
-					return err
+				// the server is only resolved once, not every time the project is generated in --watch mode
+				if server == nil {
+					serverUrl := accServerUrl
+					if uiServer != "" {
+						serverUrl = uiServer
+					}
+					resolved, err := newAcceleratorServer(serverUrl)
+					if err != nil {
+						return "", err
+					}
+					server = &resolved
 				}
-			}
 
-			if optionsFilename != "" {
-				fileBytes, err := ioutil.ReadFile(optionsFilename)
+				targetDirectory := outputDirectory
+				if outputDirectory == "" {
+					targetDirectory = options["projectName"].(string)
+				}
+				cache, err := cacheFlags.open()
 				if err != nil {
-					return err
+					return "", err
+				}
+				project := localProject{
+					localAccelerator: localAccelerator,
+					acceleratorName:  acceleratorName,
+					fragmentNames:    fragmentNames,
+					localFragments:   localFragments,
+					options:          options,
+					packaging:        packaging,
+					targetDirectory:  targetDirectory,
+					fragmentsOnly:    fragmentsOnly,
 				}
-				optionsString = string(fileBytes)
+				progress := showProgress || isTerminal(cmd.ErrOrStderr())
+				return targetDirectory, generateLocalProject(cmd, *server, project, cache, &previewOpts, progress, force)
 			}
 
-			var options map[string]interface{}
-			if optionsFilename != "" {
-				fileBytes, err := ioutil.ReadFile(optionsFilename)
-				if err != nil {
-					return err
-				}
-				optionsString = string(fileBytes)
+			accelerator := acceleratorName
+			if !localAccelerator.isEmpty() {
+				accelerator = localAccelerator.key
 			}
-			err := json.Unmarshal([]byte(optionsString), &options)
-			if err != nil {
-				return errors.New("invalid options provided, must be valid JSON")
+			targetDirectory, err := generate(forceOverwrite)
+			if err != nil || previewOpts.enabled() {
+				return err
 			}
-			if _, found := options["projectName"]; !found {
-				options["projectName"] = defaultProjectName
+			if err := hookFlags.run(cmd.Context(), cmd, targetDirectory, accelerator, options); err != nil {
+				return err
+			}
+			if gitFlags.enabled() {
+				return gitFlags.run(cmd.Context(), cmd, targetDirectory, accelerator, options)
+			}
+			if !watch {
+				return nil
 			}
-			projectName := options["projectName"].(string)
-
-			client := &http.Client{}
 
-			optionsField, err := bodyWriter.CreateFormField("options")
-			if err != nil {
-				return err
+			directories := []string{}
+			if !localAccelerator.isEmpty() {
+				directories = append(directories, localAccelerator.value)
 			}
-			err = json.NewEncoder(optionsField).Encode(options)
-			if err != nil {
-				return err
+			for _, fragmentFolderName := range localFragments {
+				directories = append(directories, fragmentFolderName)
 			}
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+			defer stop()
+			return watchAndRegenerate(ctx, cmd, directories, targetDirectory, watchDebounce, func() error {
+				// the output directory was generated by a previous run, so it is overwritten, except for an existing
+				// project the fragments are applied to, where the user may have edited the conflicting files
+				if _, err := generate(forceOverwrite || !fragmentsOnly); err != nil {
+					return err
+				}
+				return hookFlags.run(ctx, cmd, targetDirectory, accelerator, options)
+			})
+		},
+	}
+	localGenerateCommand.Flags().StringVar(&optionsString, "options", "{}", "options JSON string")
+	localGenerateCommand.Flags().StringVar(&optionsFilename, "options-file", "", "path to file containing options JSON string")
+	localGenerateCommand.Flags().StringVar(&uiServer, "server-url", "", "the URL for the Application Accelerator server")
+	localGenerateCommand.Flags().StringVarP(&outputDirectory, "output-dir", "o", "", "the directory that the project will be created in (defaults to the project name)")
+	localGenerateCommand.Flags().StringVar(&acceleratorName, "accelerator-name", "", "name of the registered accelerator to use")
+	localGenerateCommand.Flags().StringSliceVar(&fragmentNames, "fragment-names", []string{}, "names of the registered fragments to use")
+	localGenerateCommand.Flags().Var(newPairValue(kvPair{}, &localAccelerator), "accelerator-path", "key value pair of the name and path to the directory containing the accelerator")
+	localGenerateCommand.Flags().StringToStringVar(&localFragments, "fragment-paths", map[string]string{}, "key value pairs of the name and path to the directory containing each fragment")
+	localGenerateCommand.Flags().BoolVarP(&forceOverwrite, "force", "f", false, "force clean and rewrite of output-dir, or overwrite the conflicting files when only fragments are generated")
+	localGenerateCommand.Flags().BoolVar(&watch, "watch", false, "watch the local accelerator and fragment directories and regenerate the project when they change")
+	localGenerateCommand.Flags().DurationVar(&watchDebounce, "watch-debounce", 500*time.Millisecond, "time to wait for further changes before regenerating the project in --watch mode")
+	localGenerateCommand.Flags().StringArrayVar(&excludes, "exclude", []string{}, "pattern of files in the local directories that are not packaged, using the .gitignore syntax (can be used multiple times)")
+	localGenerateCommand.Flags().StringArrayVar(&includes, "include", []string{}, "pattern of files in the local directories that are packaged even when they are ignored or excluded, using the .gitignore syntax (can be used multiple times)")
+	localGenerateCommand.Flags().BoolVar(&dryRun, "dry-run", false, "list the files that would be packaged from the local directories without generating the project")
+	localGenerateCommand.Flags().BoolVar(&showProgress, "progress", false, "show the progress of the upload and download even when the standard error is not a terminal")
+	cacheFlags.define(localGenerateCommand)
+	gitFlags.define(localGenerateCommand)
+	hookFlags.define(localGenerateCommand)
+	previewOpts.define(localGenerateCommand)
+	localGenerateCommand.MarkFlagsMutuallyExclusive("options", "options-file")
+	localGenerateCommand.MarkFlagsMutuallyExclusive("accelerator-path", "accelerator-name")
+	localGenerateCommand.MarkFlagsMutuallyExclusive("dry-run", "watch")
+	localGenerateCommand.MarkFlagsMutuallyExclusive("git-init", "watch")
+	localGenerateCommand.MarkFlagsMutuallyExclusive("git-push", "watch")
+	for _, flag := range []string{"watch", "dry-run", "git-init", "git-push"} {
+		localGenerateCommand.MarkFlagsMutuallyExclusive("preview", flag)
+		localGenerateCommand.MarkFlagsMutuallyExclusive("diff-against", flag)
+	}
+	accServerUrl = EnvVar("ACC_SERVER_URL", "")
+	return localGenerateCommand
+}
 
-			// Close the body writer
-			bodyWriter.Close()
+// acceleratorServer is the Application Accelerator server the projects are generated with
+type acceleratorServer struct {
+	url       string
+	apiPrefix string
+	client    *http.Client
+}
+
+// newAcceleratorServer checks the URL of the server and determines the prefix of its API, once for all the projects
+// generated with it
+func newAcceleratorServer(serverUrl string) (acceleratorServer, error) {
+	if serverUrl == "" {
+		return acceleratorServer{}, errors.New("no server URL provided, you must provide --server-url option or set ACC_SERVER_URL environment variable")
+	}
+	if !strings.HasPrefix(serverUrl, "http://") && !strings.HasPrefix(serverUrl, "https://") {
+		return acceleratorServer{}, errors.New(fmt.Sprintf("error creating request for %s, the URL needs to include the protocol (\"http://\" or \"https://\")", serverUrl))
+	}
+	return acceleratorServer{url: serverUrl, apiPrefix: DetermineApiServerPrefix(serverUrl), client: &http.Client{}}, nil
+}
+
+// localProject is a project generated from a combination of local and registered accelerators and fragments
+type localProject struct {
+	localAccelerator kvPair
+	acceleratorName  string
+	fragmentNames    []string
+	localFragments   map[string]string
+	options          map[string]interface{}
+	packaging        packageOptions
+	// targetDirectory is the directory the project is extracted to, or the existing project the generated files are
+	// applied to when only fragments are generated
+	targetDirectory string
+	fragmentsOnly   bool
+}
+
+// checkLocalDirectories checks that the local accelerator and fragment directories exist
+func checkLocalDirectories(localAccelerator kvPair, localFragments map[string]string) error {
+	if !localAccelerator.isEmpty() {
+		if _, err := os.Stat(localAccelerator.value); err != nil {
+			return fmt.Errorf("cannot find directory %v", localAccelerator.value)
+		}
+	}
+	for _, fragmentFolderName := range localFragments {
+		if _, err := os.Stat(fragmentFolderName); err != nil {
+			return fmt.Errorf("cannot find directory %v", fragmentFolderName)
+		}
+	}
+	return nil
+}
 
-			serverUrl := accServerUrl
-			if uiServer != "" {
-				serverUrl = uiServer
+// generateLocalProject generates the project with the server, or takes it from the cache when it is not nil, and
+// extracts it to the target directory, applies it to the existing project when only fragments are generated or
+// prints it with preview
+func generateLocalProject(cmd *cobra.Command, server acceleratorServer, project localProject, cache *generateCache, preview *previewFlags, progress bool, force bool) error {
+	projectName := project.options["projectName"].(string)
+	var cacheKey string
+	var err error
+	if cache != nil {
+		cacheKey, err = localGenerateCacheKey(server.url, server.apiPrefix, project.localAccelerator, project.acceleratorName, project.fragmentNames, project.localFragments, project.options, project.packaging)
+		if err != nil {
+			fmt.Fprintf(cmd.OutOrStderr(), "not using the cache of generated projects: %v\n", err)
+			cache = nil
+		} else if cached, found := cache.get(cacheKey); found {
+			zipReader, err := zip.OpenReader(cached)
+			if err != nil {
+				return err
 			}
-			if serverUrl == "" {
-				return errors.New("no server URL provided, you must provide --server-url option or set ACC_SERVER_URL environment variable")
+			defer zipReader.Close()
+			if preview.enabled() {
+				return preview.print(cmd, &zipReader.Reader, projectName, project.fragmentsOnly)
 			}
-			if !strings.HasPrefix(serverUrl, "http://") && !strings.HasPrefix(serverUrl, "https://") {
-				return errors.New(fmt.Sprintf("error creating request for %s, the URL needs to include the protocol (\"http://\" or \"https://\")", serverUrl))
+			if project.fragmentsOnly {
+				fmt.Fprintf(cmd.OutOrStdout(), "generated fragments from the cache\n")
+				return applyProject(cmd, &zipReader.Reader, project.targetDirectory, force)
 			}
-
-			apiPrefix := DetermineApiServerPrefix(serverUrl)
-			proxyRequest, _ := http.NewRequest("POST", fmt.Sprintf("%s/%s/accelerators/zip", serverUrl, apiPrefix), requestBody)
-			proxyRequest.Header.Add("Content-Type", bodyWriter.FormDataContentType())
-			resp, err := client.Do(proxyRequest)
-			if err != nil {
+			if err := extractProject(&zipReader.Reader, project.targetDirectory, force); err != nil {
 				return err
 			}
+			fmt.Fprintf(cmd.OutOrStdout(), "generated project %s from the cache\n", projectName)
+			return nil
+		}
+	}
 
-			if resp.StatusCode >= 300 {
-				var errorMsg string
-				if resp.StatusCode == http.StatusNotFound {
-					errorMsg = fmt.Sprintf("one of the accelerators or fragments was not found\n")
-				} else {
-					var errorResponse UiErrorResponse
-					body, _ := ioutil.ReadAll(resp.Body)
-					json.Unmarshal(body, &errorResponse)
-					if errorResponse.Detail > "" {
-						errorMsg = fmt.Sprintf("there was an error generating the accelerator, the server response was: \"%s\"\n", errorResponse.Detail)
-					} else {
-						errorMsg = fmt.Sprintf("there was an error generating the accelerator, the server response code was: \"%v\"\n", resp.StatusCode)
-					}
-				}
-				return fmt.Errorf(errorMsg)
-			}
+	var upload *progressWriter
+	if progress {
+		upload = newProgressWriter(cmd.ErrOrStderr(), "uploaded", -1)
+	}
 
+	// the form body is written while it is sent, so the packaged files are never held in memory
+	bodyReader, pipeWriter := io.Pipe()
+	var body io.Writer = pipeWriter
+	if upload != nil {
+		body = io.MultiWriter(pipeWriter, upload)
+	}
+	bodyWriter := multipart.NewWriter(body)
+	uploaded := make(chan error, 1)
+	go func() {
+		err := writeGenerateForm(bodyWriter, project.localAccelerator, project.acceleratorName, project.fragmentNames, project.localFragments, project.options, project.packaging)
+		if err == nil && upload != nil {
+			upload.done()
+		}
+		pipeWriter.CloseWithError(err)
+		uploaded <- err
+	}()
+
+	proxyRequest, _ := http.NewRequest("POST", fmt.Sprintf("%s/%s/accelerators/zip", server.url, server.apiPrefix), bodyReader)
+	proxyRequest.Header.Add("Content-Type", bodyWriter.FormDataContentType())
+	resp, err := server.client.Do(proxyRequest)
+	if err != nil {
+		// packaging errors are more helpful than the failed request they cause
+		bodyReader.CloseWithError(err)
+		if uploadErr := <-uploaded; uploadErr != nil {
+			return uploadErr
+		}
+		return err
+	}
+	defer resp.Body.Close()
+	// the server may answer before reading the whole body, the upload is stopped in that case, otherwise
+	// the server received an incomplete request if packaging failed and its response doesn't matter
+	bodyReader.CloseWithError(errBodyNotRead)
+	if uploadErr := <-uploaded; uploadErr != nil && !errors.Is(uploadErr, errBodyNotRead) {
+		return uploadErr
+	}
+
+	if resp.StatusCode >= 300 {
+		var errorMsg string
+		if resp.StatusCode == http.StatusNotFound {
+			errorMsg = fmt.Sprintf("one of the accelerators or fragments was not found\n")
+		} else {
+			var errorResponse UiErrorResponse
 			body, _ := ioutil.ReadAll(resp.Body)
-			zipReader, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
-			if err != nil {
-				return err
+			json.Unmarshal(body, &errorResponse)
+			if errorResponse.Detail > "" {
+				errorMsg = fmt.Sprintf("there was an error generating the accelerator, the server response was: \"%s\"\n", errorResponse.Detail)
+			} else {
+				errorMsg = fmt.Sprintf("there was an error generating the accelerator, the server response code was: \"%v\"\n", resp.StatusCode)
 			}
+		}
+		return fmt.Errorf(errorMsg)
+	}
+
+	// nothing is written when previewing, the zip is read in memory and not cached
+	if preview.enabled() {
+		var download *progressWriter
+		var in io.Reader = resp.Body
+		if progress {
+			download = newProgressWriter(cmd.ErrOrStderr(), "downloaded", resp.ContentLength)
+			in = io.TeeReader(resp.Body, download)
+		}
+		body, err := io.ReadAll(in)
+		if err != nil {
+			return err
+		}
+		if progress {
+			download.done()
+		}
+		zipReader, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+		if err != nil {
+			return err
+		}
+		return preview.print(cmd, zipReader, projectName, project.fragmentsOnly)
+	}
+
+	// the zip is spooled to a temporary file as it needs random access to be extracted
+	zipFile, err := os.CreateTemp("", "accelerator-*.zip")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(zipFile.Name())
+	defer zipFile.Close()
+	var download *progressWriter
+	var in io.Reader = resp.Body
+	if progress {
+		download = newProgressWriter(cmd.ErrOrStderr(), "downloaded", resp.ContentLength)
+		in = io.TeeReader(resp.Body, download)
+	}
+	size, err := io.Copy(zipFile, in)
+	if err != nil {
+		return err
+	}
+	if progress {
+		download.done()
+	}
+	zipReader, err := zip.NewReader(zipFile, size)
+	if err != nil {
+		return err
+	}
+	if cache != nil {
+		if err := cache.put(cacheKey, io.NewSectionReader(zipFile, 0, size)); err != nil {
+			fmt.Fprintf(cmd.OutOrStderr(), "could not store the generated project in the cache: %v\n", err)
+		}
+	}
+
+	if project.fragmentsOnly {
+		fmt.Fprintf(cmd.OutOrStdout(), "generated fragments\n")
+		return applyProject(cmd, zipReader, project.targetDirectory, force)
+	}
+	if err := extractProject(zipReader, project.targetDirectory, force); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "generated project %s\n", projectName)
+	return nil
+}
+
+// writeGenerateForm writes the fields and the packaged local directories of a generate request and closes the writer
+func writeGenerateForm(bodyWriter *multipart.Writer, localAccelerator kvPair, acceleratorName string, fragmentNames []string, localFragments map[string]string, options map[string]interface{}, packaging packageOptions) error {
+	if !localAccelerator.isEmpty() {
+		fileWriter, err := bodyWriter.CreateFormFile("accelerator", localAccelerator.key+".tar.gz")
+		if err != nil {
+			return err
+		}
+		if _, err := tarToWriter(localAccelerator.value, fileWriter, packaging); err != nil {
+			return err
+		}
+	} else if acceleratorName != "" {
+		if err := bodyWriter.WriteField("accelerator_name", acceleratorName); err != nil {
+			return err
+		}
+	}
 
-			targetDirectory := outputDirectory
-			if outputDirectory == "" {
-				targetDirectory = projectName
+	for _, fragmentName := range fragmentNames {
+		if err := bodyWriter.WriteField("fragment_names", fragmentName); err != nil {
+			return err
+		}
+	}
+
+	localFragmentNames := []string{}
+	for fragmentName := range localFragments {
+		localFragmentNames = append(localFragmentNames, fragmentName)
+	}
+	sort.Strings(localFragmentNames)
+	for _, fragmentName := range localFragmentNames {
+		fileWriter, err := bodyWriter.CreateFormFile("fragment_"+fragmentName, fragmentName+".tar.gz")
+		if err != nil {
+			return err
+		}
+		if _, err := tarToWriter(localFragments[fragmentName], fileWriter, packaging); err != nil {
+			return err
+		}
+	}
+
+	optionsField, err := bodyWriter.CreateFormField("options")
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(optionsField).Encode(options); err != nil {
+		return err
+	}
+	return bodyWriter.Close()
+}
+
+// errBodyNotRead stops the upload of a generate request when the server answered without reading all of it
+var errBodyNotRead = errors.New("the server did not read the whole request")
+
+// progressInterval is the minimum time between two progress reports
+const progressInterval = 500 * time.Millisecond
+
+// progressWriter counts the bytes written to it and reports them periodically, on a single line when the output is a
+// terminal
+type progressWriter struct {
+	out      io.Writer
+	label    string
+	total    int64
+	written  int64
+	reported time.Time
+	terminal bool
+}
+
+// newProgressWriter returns a progressWriter reporting to out, total is the expected number of bytes or -1 if unknown
+func newProgressWriter(out io.Writer, label string, total int64) *progressWriter {
+	return &progressWriter{out: out, label: label, total: total, reported: time.Now(), terminal: isTerminal(out)}
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	p.written += int64(len(b))
+	if time.Since(p.reported) >= progressInterval {
+		p.report(false)
+	}
+	return len(b), nil
+}
+
+// done reports the final number of bytes
+func (p *progressWriter) done() {
+	p.report(true)
+}
+
+func (p *progressWriter) report(final bool) {
+	p.reported = time.Now()
+	message := fmt.Sprintf("%s %s", p.label, formatSize(p.written))
+	if p.total > 0 {
+		message = fmt.Sprintf("%s of %s (%d%%)", message, formatSize(p.total), p.written*100/p.total)
+	}
+	switch {
+	case p.terminal && final:
+		fmt.Fprintf(p.out, "\r\033[K%s\n", message)
+	case p.terminal:
+		fmt.Fprintf(p.out, "\r\033[K%s", message)
+	default:
+		fmt.Fprintf(p.out, "%s\n", message)
+	}
+}
+
+// printPackagedFiles lists the files that are packaged from the local accelerator and fragment directories with their
+// sizes, warning about large files
+func printPackagedFiles(cmd *cobra.Command, localAccelerator kvPair, localFragments map[string]string, opts packageOptions) error {
+	type source struct {
+		kind      string
+		name      string
+		directory string
+	}
+	sources := []source{}
+	if !localAccelerator.isEmpty() {
+		sources = append(sources, source{"accelerator", localAccelerator.key, localAccelerator.value})
+	}
+	fragmentNames := []string{}
+	for fragmentName := range localFragments {
+		fragmentNames = append(fragmentNames, fragmentName)
+	}
+	sort.Strings(fragmentNames)
+	for _, fragmentName := range fragmentNames {
+		sources = append(sources, source{"fragment", fragmentName, localFragments[fragmentName]})
+	}
+
+	var totalFiles int
+	var totalSize int64
+	large := []string{}
+	for _, s := range sources {
+		if info, err := os.Stat(s.directory); err != nil || !info.IsDir() {
+			return fmt.Errorf("cannot find directory %v", s.directory)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "%s %s (%s):\n", s.kind, s.name, s.directory)
+		var files int
+		var size int64
+		err := walkSourceDir(s.directory, opts, func(file string, name string, fi os.FileInfo) error {
+			if fi.IsDir() {
+				return nil
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "  %10s  %s\n", formatSize(fi.Size()), name)
+			files++
+			size += fi.Size()
+			if fi.Size() > largeFileThreshold {
+				large = append(large, file)
 			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		digest, err := packageDigest(s.directory, opts)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "  %d file(s), %s, digest %s\n", files, formatSize(size), digest)
+		totalFiles += files
+		totalSize += size
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "%d file(s) would be packaged, %s in total\n", totalFiles, formatSize(totalSize))
+	for _, file := range large {
+		fmt.Fprintf(cmd.OutOrStderr(), "warning: %s is larger than %s, consider excluding it with .acceleratorignore or --exclude\n", file, formatSize(largeFileThreshold))
+	}
+	return nil
+}
 
-			if forceOverwrite {
-				err := os.RemoveAll(targetDirectory)
-				if err != nil {
-					return errors.New(fmt.Sprintf("could not remove %s", targetDirectory))
+// watchAndRegenerate watches the directories until the context is done, calling regenerate once the changes have
+// settled for the debounce duration and printing the changes to the files in the output directory
+func watchAndRegenerate(ctx context.Context, cmd *cobra.Command, directories []string, outputDirectory string, debounce time.Duration, regenerate func() error) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	// changes to the generated project must not trigger another generation, for example when the output directory
+	// is inside the accelerator directory
+	excluded, err := filepath.Abs(outputDirectory)
+	if err != nil {
+		return err
+	}
+	isExcluded := func(path string) bool {
+		abs, err := filepath.Abs(path)
+		return err == nil && (abs == excluded || strings.HasPrefix(abs, excluded+string(filepath.Separator)))
+	}
+	for _, directory := range directories {
+		if err := watchDirectory(watcher, directory, isExcluded); err != nil {
+			return err
+		}
+	}
+
+	previous, err := snapshotDirectory(outputDirectory)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "watching %s for changes\n", strings.Join(directories, ", "))
+
+	var timer *time.Timer
+	var settled <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			return err
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if isExcluded(event.Name) || filepath.Base(event.Name) == ".git" {
+				continue
+			}
+			if event.Op&fsnotify.Create != 0 {
+				if fi, err := os.Stat(event.Name); err == nil && fi.IsDir() {
+					// new directories are not watched by fsnotify automatically
+					if err := watchDirectory(watcher, event.Name, isExcluded); err != nil {
+						return err
+					}
 				}
+			}
+			if timer == nil {
+				timer = time.NewTimer(debounce)
 			} else {
-				if _, err := os.Stat(targetDirectory); !errors.Is(err, os.ErrNotExist) {
-					// directory exists
-					if empty, _ := isEmpty(targetDirectory); !empty {
-						return errors.New(fmt.Sprintf("path %s is not empty, use --force to overwrite", targetDirectory))
+				if !timer.Stop() {
+					// drain a pending expiry, otherwise it would be received right after the reset
+					select {
+					case <-timer.C:
+					default:
 					}
 				}
+				timer.Reset(debounce)
 			}
-			for _, f := range zipReader.File {
-				err = extractFile(f, targetDirectory)
-				if err != nil {
-					return err
-				}
+			settled = timer.C
+		case <-settled:
+			settled = nil
+			if err := regenerate(); err != nil {
+				// keep watching, the next change might fix the problem
+				fmt.Fprintf(cmd.OutOrStderr(), "error generating project: %v\n", err)
+				continue
+			}
+			current, err := snapshotDirectory(outputDirectory)
+			if err != nil {
+				return err
 			}
+			printSnapshotDiff(cmd, previous, current)
+			previous = current
+		}
+	}
+}
 
-			fmt.Fprintf(cmd.OutOrStdout(), "generated project %s\n", projectName)
+// watchDirectory adds the directory and its subdirectories to the watcher, skipping .git directories
+func watchDirectory(watcher *fsnotify.Watcher, directory string, isExcluded func(string) bool) error {
+	return filepath.WalkDir(directory, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
 			return nil
-		},
+		}
+		if d.Name() == ".git" || isExcluded(path) {
+			return filepath.SkipDir
+		}
+		return watcher.Add(path)
+	})
+}
+
+// snapshotDirectory returns the checksums of the files in the directory by their slash separated relative path
+func snapshotDirectory(directory string) (map[string]string, error) {
+	snapshot := map[string]string{}
+	err := filepath.WalkDir(directory, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) && path == directory {
+				return nil
+			}
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(directory, path)
+		if err != nil {
+			return err
+		}
+		snapshot[filepath.ToSlash(rel)] = fmt.Sprintf("%x", sha256.Sum256(content))
+		return nil
+	})
+	return snapshot, err
+}
+
+func printSnapshotDiff(cmd *cobra.Command, previous map[string]string, current map[string]string) {
+	lines := []string{}
+	for path, checksum := range current {
+		if previousChecksum, found := previous[path]; !found {
+			lines = append(lines, "+ "+path)
+		} else if previousChecksum != checksum {
+			lines = append(lines, "~ "+path)
+		}
+	}
+	for path := range previous {
+		if _, found := current[path]; !found {
+			lines = append(lines, "- "+path)
+		}
+	}
+	if len(lines) == 0 {
+		fmt.Fprintf(cmd.OutOrStdout(), "no changes to the generated files\n")
+		return
+	}
+	sort.Slice(lines, func(i, j int) bool {
+		return lines[i][2:] < lines[j][2:]
+	})
+	for _, line := range lines {
+		fmt.Fprintf(cmd.OutOrStdout(), "%s\n", line)
 	}
-	localGenerateCommand.Flags().StringVar(&optionsString, "options", "{}", "options JSON string")
-	localGenerateCommand.Flags().StringVar(&optionsFilename, "options-file", "", "path to file containing options JSON string")
-	localGenerateCommand.Flags().StringVar(&uiServer, "server-url", "", "the URL for the Application Accelerator server")
-	localGenerateCommand.Flags().StringVarP(&outputDirectory, "output-dir", "o", "", "the directory that the project will be created in (defaults to the project name)")
-	localGenerateCommand.Flags().StringVar(&acceleratorName, "accelerator-name", "", "name of the registered accelerator to use")
-	localGenerateCommand.Flags().StringSliceVar(&fragmentNames, "fragment-names", []string{}, "names of the registered fragments to use")
-	localGenerateCommand.Flags().Var(newPairValue(kvPair{}, &localAccelerator), "accelerator-path", "key value pair of the name and path to the directory containing the accelerator")
-	localGenerateCommand.Flags().StringToStringVar(&localFragments, "fragment-paths", map[string]string{}, "key value pairs of the name and path to the directory containing each fragment")
-	localGenerateCommand.Flags().BoolVarP(&forceOverwrite, "force", "f", false, "force clean and rewrite of output-dir")
-	localGenerateCommand.MarkFlagsMutuallyExclusive("options", "options-file")
-	localGenerateCommand.MarkFlagsMutuallyExclusive("accelerator-path", "accelerator-name")
-	accServerUrl = EnvVar("ACC_SERVER_URL", "")
-	return localGenerateCommand
 }
 
-func extractFile(f *zip.File, targetDirectory string) error {
+// extractProject extracts the generated project to the target directory, which must be empty unless force is set
+func extractProject(zipReader *zip.Reader, targetDirectory string, force bool) error {
+	if force {
+		err := os.RemoveAll(targetDirectory)
+		if err != nil {
+			return errors.New(fmt.Sprintf("could not remove %s", targetDirectory))
+		}
+	} else {
+		if _, err := os.Stat(targetDirectory); !errors.Is(err, os.ErrNotExist) {
+			// directory exists
+			if empty, _ := isEmpty(targetDirectory); !empty {
+				return errors.New(fmt.Sprintf("path %s is not empty, use --force to overwrite", targetDirectory))
+			}
+		}
+	}
+	return extractZip(zipReader, targetDirectory)
+}
+
+// extractZip extracts the files of the zip, without the top level directory, to the target directory. The permissions,
+// modification times, empty directories and symbolic links of the zip are kept.
+func extractZip(zipReader *zip.Reader, targetDirectory string) error {
+	for _, f := range zipReader.File {
+		if err := extractFile(f, targetDirectory); err != nil {
+			return err
+		}
+	}
+	// the modification times of directories change when their files are extracted, so they are set last, children first
+	for i := len(zipReader.File) - 1; i >= 0; i-- {
+		f := zipReader.File[i]
+		if f.FileInfo().IsDir() {
+			path := extractedPath(f, targetDirectory)
+			if err := os.Chtimes(path, f.Modified, f.Modified); err != nil {
+				return fmt.Errorf("could not set the modification time of %s: %v", path, err)
+			}
+		}
+	}
+	return nil
+}
+
+// extractedPath returns the path of a zip entry in the target directory, without the top level directory
+func extractedPath(f *zip.File, targetDirectory string) string {
 	filePaths := strings.Split(f.Name, "/")[1:]
-	path := filepath.Join(append([]string{targetDirectory}, filePaths...)...)
+	return filepath.Join(append([]string{targetDirectory}, filePaths...)...)
+}
+
+func extractFile(f *zip.File, targetDirectory string) error {
+	path := extractedPath(f, targetDirectory)
+	if !isInside(targetDirectory, path) {
+		return fmt.Errorf("invalid file %s in generated project, it is outside of the project directory", f.Name)
+	}
 
 	if f.FileInfo().IsDir() {
 		err := os.MkdirAll(path, 0755)
 		if err != nil {
 			return errors.New(fmt.Sprintf("could not create directory %s", path))
 		}
+	} else if f.Mode()&os.ModeSymlink != 0 {
+		// the content of a symbolic link entry is its target
+		os.MkdirAll(filepath.Dir(path), 0755)
+
+		fileInArchive, err := f.Open()
+		if err != nil {
+			return errors.New(fmt.Sprintf("could not open file %s", f.Name))
+		}
+		link, err := io.ReadAll(fileInArchive)
+		fileInArchive.Close()
+		if err != nil {
+			return errors.New(fmt.Sprintf("could not open file %s", f.Name))
+		}
+		target := filepath.FromSlash(string(link))
+		if filepath.IsAbs(target) || !isInside(targetDirectory, filepath.Join(filepath.Dir(path), target)) {
+			return fmt.Errorf("invalid symbolic link %s in generated project, it points to %s, which is outside of the project directory", f.Name, link)
+		}
+		os.Remove(path)
+		if err := os.Symlink(target, path); err != nil {
+			return fmt.Errorf("could not create symbolic link %s: %v", path, err)
+		}
 	} else {
 		// create directories to the file
 		os.MkdirAll(filepath.Dir(path), 0755)
 
-		dstFile, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, f.Mode())
+		dstFile, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, fileMode(f.Mode()))
 		if err != nil {
 			return errors.New("error creating subdirectories in generated project")
 		}
@@ -302,6 +967,14 @@ func extractFile(f *zip.File, targetDirectory string) error {
 
 		dstFile.Close()
 		fileInArchive.Close()
+
+		// the mode is only used when the file is created, executable bits must be kept
+		if err := os.Chmod(path, fileMode(f.Mode())); err != nil {
+			return fmt.Errorf("could not set the permissions of %s: %v", path, err)
+		}
+		if err := os.Chtimes(path, f.Modified, f.Modified); err != nil {
+			return fmt.Errorf("could not set the modification time of %s: %v", path, err)
+		}
 	}
 	return nil
 }
@@ -320,27 +993,185 @@ func isEmpty(name string) (bool, error) {
 	return false, err // Either not empty or error, suits both cases
 }
 
-// tarToWriter takes a source and a writer and walks sourceDir writing each file
-// found to the tar writer
-func tarToWriter(sourceDir string, writer io.Writer) error {
+// packageModTime is the modification time of every entry of a package, so that packaging the same files always produces
+// the same bytes. The zip format used for the generated projects can't represent earlier times.
+var packageModTime = time.Date(1980, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// tarToWriter takes a source and a writer and walks sourceDir writing each file found to the gzip compressed tar
+// writer. The tar is deterministic: the entries are in the lexical order of filepath.Walk, the modification times,
+// owners and permissions are normalized, keeping only whether files are executable, and the gzip header is fixed.
+// The digest of the uncompressed tar is returned, it identifies the content of the package.
+func tarToWriter(sourceDir string, writer io.Writer, opts packageOptions) (string, error) {
 
 	// ensure the sourceDir actually exists before trying to tar it
 	if _, err := os.Stat(sourceDir); err != nil {
-		return fmt.Errorf("cannot find directory %v", sourceDir)
+		return "", fmt.Errorf("cannot find directory %v", sourceDir)
 	}
 
 	gzw := gzip.NewWriter(writer)
-	defer gzw.Close()
+	// no name, modification time or operating system in the gzip header
+	gzw.Header = gzip.Header{OS: 255}
 
-	tw := tar.NewWriter(gzw)
-	defer tw.Close()
+	digest := sha256.New()
+	tw := tar.NewWriter(io.MultiWriter(gzw, digest))
 
-	cleanSourceDir := filepath.Clean(sourceDir)
+	err := walkSourceDir(sourceDir, opts, func(file string, name string, fi os.FileInfo) error {
+		// create a new dir/file/symlink header without the owner and the times of the file
+		header := &tar.Header{
+			Name:     name,
+			Mode:     0644,
+			ModTime:  packageModTime,
+			Typeflag: tar.TypeReg,
+		}
+		switch {
+		case fi.IsDir():
+			header.Name += "/"
+			header.Mode = 0755
+			header.Typeflag = tar.TypeDir
+		case fi.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(file)
+			if err != nil {
+				return err
+			}
+			header.Mode = 0777
+			header.Typeflag = tar.TypeSymlink
+			header.Linkname = filepath.ToSlash(link)
+		default:
+			header.Size = fi.Size()
+			if fi.Mode()&0111 != 0 {
+				header.Mode = 0755
+			}
+		}
+
+		// write the header
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg {
+			return nil
+		}
+
+		// open files for taring
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+
+		// copy file data into tar writer
+		if _, err := io.Copy(tw, f); err != nil {
+			return err
+		}
+
+		// manually close here after each file operation; deferring would cause each file close
+		// to wait until all operations have completed.
+		f.Close()
+
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if err := tw.Close(); err != nil {
+		return "", err
+	}
+	if err := gzw.Close(); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("sha256:%x", digest.Sum(nil)), nil
+}
 
-	ignore, err := gitignore.NewRepository(cleanSourceDir)
+// packageDigest returns the digest of the package of sourceDir, without keeping the package
+func packageDigest(sourceDir string, opts packageOptions) (string, error) {
+	return tarToWriter(sourceDir, io.Discard, opts)
+}
+
+// acceleratorIgnoreFile is the name of the files with patterns of files that are not packaged, in addition to the
+// patterns in .gitignore files
+const acceleratorIgnoreFile = ".acceleratorignore"
+
+// largeFileThreshold is the size above which --dry-run warns about packaged files
+const largeFileThreshold = 10 << 20
+
+// packageOptions selects the files of a local source directory that are packaged, using .gitignore patterns
+type packageOptions struct {
+	// excludes are patterns of files that are not packaged
+	excludes []string
+	// includes are patterns of files that are packaged even when they are ignored or excluded
+	includes []string
+}
+
+// copySourceFile copies a file, directory or symbolic link found when walking a source directory to the destination,
+// keeping its executable bit, other special files are skipped
+func copySourceFile(file string, destination string, fi os.FileInfo) error {
+	if fi.IsDir() {
+		return os.MkdirAll(destination, fi.Mode().Perm()|0700)
+	}
+	if err := os.MkdirAll(filepath.Dir(destination), 0755); err != nil {
+		return err
+	}
+	if fi.Mode()&os.ModeSymlink != 0 {
+		link, err := os.Readlink(file)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(link, destination)
+	}
+	if !fi.Mode().IsRegular() {
+		return nil
+	}
+	content, err := os.ReadFile(file)
 	if err != nil {
 		return err
 	}
+	if err := os.WriteFile(destination, content, fileMode(fi.Mode())); err != nil {
+		return err
+	}
+	// the umask applies to the mode of new files, executable bits must be kept
+	return os.Chmod(destination, fileMode(fi.Mode()))
+}
+
+// fileMode returns the permissions of a copied or extracted file: only the executable bit of the mode is kept, so that
+// modes like 0666 of zip files written on other platforms don't make files writable by everyone
+func fileMode(mode os.FileMode) os.FileMode {
+	if mode&0111 != 0 {
+		return 0755
+	}
+	return 0644
+}
+
+// isInside returns true if the path is the root directory or inside of it
+func isInside(root string, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// walkSourceDir walks the regular files, directories and symbolic links in sourceDir that are part of the accelerator
+// source, skipping the .git directory, the files in .gitignore and .acceleratorignore files and the files matching the
+// excludes of the options unless they match the includes. Symbolic links must point to a path inside sourceDir, they
+// are not followed. Other special files are skipped. The name passed to fn is the slash separated path relative to
+// sourceDir.
+func walkSourceDir(sourceDir string, opts packageOptions, fn func(file string, name string, fi os.FileInfo) error) error {
+	cleanSourceDir := filepath.Clean(sourceDir)
+
+	ignores := []gitignore.GitIgnore{}
+	for _, ignoreFile := range []string{gitignore.File, acceleratorIgnoreFile} {
+		ignore, err := gitignore.NewRepositoryWithFile(cleanSourceDir, ignoreFile)
+		if err != nil {
+			return err
+		}
+		ignores = append(ignores, ignore)
+	}
+	if len(opts.excludes) > 0 {
+		ignores = append(ignores, gitignore.New(strings.NewReader(strings.Join(opts.excludes, "\n")), cleanSourceDir, nil))
+	}
+	var includes gitignore.GitIgnore
+	if len(opts.includes) > 0 {
+		includes = gitignore.New(strings.NewReader(strings.Join(opts.includes, "\n")), cleanSourceDir, nil)
+	}
+
+	// excludedDirs tracks the directories that are walked although they are excluded, because files in them may be
+	// included
+	excludedDirs := map[string]bool{}
 
 	// walk path
 	return filepath.Walk(sourceDir, func(file string, fi os.FileInfo, err error) error {
@@ -355,11 +1186,25 @@ func tarToWriter(sourceDir string, writer io.Writer) error {
 			return filepath.SkipDir
 		}
 
-		// exclude directories and files in .gitignore
+		// exclude directories and files in .gitignore, .acceleratorignore and the excludes
 		// don't call Ignore for root path (see https://github.com/denormal/go-gitignore/pull/4)
 		if file != sourceDir {
-			if match := ignore.Match(file); match != nil && match.Ignore() {
-				if fi.IsDir() {
+			excluded := excludedDirs[filepath.Dir(file)]
+			for _, ignore := range ignores {
+				if match := ignore.Match(file); match != nil && match.Ignore() {
+					excluded = true
+				}
+			}
+			if excluded && includes != nil {
+				if match := includes.Match(file); match != nil && match.Ignore() {
+					excluded = false
+				}
+			}
+			if excluded {
+				if fi.IsDir() && includes != nil {
+					excludedDirs[file] = true
+					return nil
+				} else if fi.IsDir() {
 					return filepath.SkipDir
 				} else {
 					return nil
@@ -367,42 +1212,24 @@ func tarToWriter(sourceDir string, writer io.Writer) error {
 			}
 		}
 
-		// return on non-regular files
-		if !fi.Mode().IsRegular() {
+		// the root is not part of the source, and special files like sockets or devices can't be packaged
+		if file == sourceDir || !(fi.Mode().IsRegular() || fi.IsDir() || fi.Mode()&os.ModeSymlink != 0) {
 			return nil
 		}
-
-		// create a new dir/file header
-		header, err := tar.FileInfoHeader(fi, "")
-		if err != nil {
-			return err
+		if fi.Mode()&os.ModeSymlink != 0 {
+			link, err := os.Readlink(file)
+			if err != nil {
+				return err
+			}
+			// absolute links would point outside of the tree once it is extracted somewhere else
+			if filepath.IsAbs(link) || !isInside(cleanSourceDir, filepath.Join(filepath.Dir(file), link)) {
+				return fmt.Errorf("symbolic link %s points to %s, which is outside of %s", file, link, sourceDir)
+			}
 		}
 
-		// update the name to correctly reflect the desired destination when untaring, accounting for Windows paths
-		// which use "\" instead of "/"
+		// the name reflects the desired destination when untaring, accounting for Windows paths which use "\\"
+		// instead of "/"
 		fileDestination := strings.TrimPrefix(filepath.Clean(file), cleanSourceDir+string(filepath.Separator))
-		header.Name = filepath.ToSlash(fileDestination)
-
-		// write the header
-		if err := tw.WriteHeader(header); err != nil {
-			return err
-		}
-
-		// open files for taring
-		f, err := os.Open(file)
-		if err != nil {
-			return err
-		}
-
-		// copy file data into tar writer
-		if _, err := io.Copy(tw, f); err != nil {
-			return err
-		}
-
-		// manually close here after each file operation; deferring would cause each file close
-		// to wait until all operations have completed.
-		f.Close()
-
-		return nil
+		return fn(file, filepath.ToSlash(fileDestination), fi)
 	})
 }
@@ -5,12 +5,19 @@ import (
 	"archive/zip"
 	"bytes"
 	"compress/gzip"
+	"context"
+	"crypto/sha256"
 	"encoding/json"
+	"fmt"
 	"io"
 	"io/ioutil"
+	"math/rand"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"sync"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -392,5 +399,519 @@ partially-excluded-dir/*.txt
 				Expect(err.Error()).Should(Equal("one of the accelerators or fragments was not found\n"))
 			})
 		})
+
+		When("Executes generate-from-local command with --watch and no local paths", func() {
+			It("Should output error message", func() {
+				generateCmd := LocalGenerateCmd()
+				generateCmd.SetArgs([]string{"--accelerator-name", "acc", "--watch"})
+				err := generateCmd.Execute()
+				Expect(err).NotTo(BeNil())
+				Expect(err.Error()).Should(Equal("--watch requires --accelerator-path or --fragment-paths"))
+			})
+		})
+
+		When("Executes generate-from-local command with excluded files", func() {
+			var accDir string
+
+			BeforeEach(func() {
+				var err error
+				accDir, err = os.MkdirTemp("", "acc")
+				Expect(err).NotTo(HaveOccurred())
+				files := map[string]string{
+					"accelerator.yaml":             "accelerator: {}\n",
+					".acceleratorignore":           "node_modules/\n*.log\n",
+					"README.md":                    "readme",
+					"debug.log":                    "log",
+					"keep.log":                     "log",
+					"node_modules/dep/index.js":    "js",
+					"target/app.jar":               "jar",
+					"target/classes/App.class":     "class",
+					"src/main/java/App.java":       "java",
+					"src/main/resources/notes.txt": "notes",
+				}
+				for name, content := range files {
+					Expect(os.MkdirAll(filepath.Dir(filepath.Join(accDir, name)), 0755)).To(Succeed())
+					Expect(os.WriteFile(filepath.Join(accDir, name), []byte(content), 0644)).To(Succeed())
+				}
+			})
+
+			AfterEach(func() {
+				os.RemoveAll(accDir)
+			})
+
+			It("Should not send the files in .acceleratorignore and --exclude unless they match --include", func() {
+				mux := http.NewServeMux()
+				mux.HandleFunc("/api/about", func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(200)
+					w.Write([]byte("{}"))
+				})
+				mux.HandleFunc("/api/accelerators/zip", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					r.ParseMultipartForm(100 << 20)
+					fileAcc, _, err := r.FormFile("accelerator")
+					Expect(err).NotTo(HaveOccurred())
+					gzr, err := gzip.NewReader(fileAcc)
+					Expect(err).NotTo(HaveOccurred())
+					tr := tar.NewReader(gzr)
+					names := []string{}
+					for header, err := tr.Next(); err == nil; header, err = tr.Next() {
+						if header.Typeflag == tar.TypeReg {
+							names = append(names, header.Name)
+						}
+					}
+
+					Expect(names).Should(ConsistOf(".acceleratorignore", "accelerator.yaml", "README.md", "keep.log",
+						"src/main/java/App.java", "target/app.jar"))
+
+					zipWriter := zip.NewWriter(w)
+					zipWriter.Close()
+				}))
+				ts := httptest.NewServer(mux)
+				defer ts.Close()
+				outputDir, err := os.MkdirTemp("", "generated")
+				Expect(err).NotTo(HaveOccurred())
+				defer os.RemoveAll(outputDir)
+
+				generateCmd := LocalGenerateCmd()
+				b := new(bytes.Buffer)
+				generateCmd.SetOut(b)
+				generateCmd.SetErr(b)
+				generateCmd.SetArgs([]string{"--accelerator-path", "acc=" + accDir, "--server-url", ts.URL, "--output-dir", outputDir,
+					"--exclude", "target/", "--exclude", "*.txt", "--include", "target/*.jar", "--include", "keep.log"})
+				Expect(generateCmd.Execute()).To(Succeed())
+				Expect(b.String()).Should(Equal("generated project acc\n"))
+			})
+
+			It("Should list the packaged files with --dry-run", func() {
+				// a sparse file is enough to trigger the warning
+				large, err := os.Create(filepath.Join(accDir, "large.bin"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(large.Truncate(largeFileThreshold + 1)).To(Succeed())
+				Expect(large.Close()).To(Succeed())
+				fragmentDir, err := os.MkdirTemp("", "fragment")
+				Expect(err).NotTo(HaveOccurred())
+				defer os.RemoveAll(fragmentDir)
+				Expect(os.WriteFile(filepath.Join(fragmentDir, "accelerator.yaml"), []byte("accelerator: {}\n"), 0644)).To(Succeed())
+
+				generateCmd := LocalGenerateCmd()
+				out := new(bytes.Buffer)
+				generateCmd.SetOut(out)
+				generateCmd.SetErr(out)
+				// no server is needed for a dry run
+				generateCmd.SetArgs([]string{"--accelerator-path", "acc=" + accDir, "--fragment-paths", "frag=" + fragmentDir,
+					"--exclude", "target/", "--dry-run"})
+				Expect(generateCmd.Execute()).To(Succeed())
+				accDigest, err := packageDigest(accDir, packageOptions{excludes: []string{"target/"}})
+				Expect(err).NotTo(HaveOccurred())
+				fragmentDigest, err := packageDigest(fragmentDir, packageOptions{excludes: []string{"target/"}})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(out.String()).Should(Equal("accelerator acc (" + accDir + "):\n" +
+					"        20 B  .acceleratorignore\n" +
+					"         6 B  README.md\n" +
+					"        16 B  accelerator.yaml\n" +
+					"    10.0 MiB  large.bin\n" +
+					"         4 B  src/main/java/App.java\n" +
+					"         5 B  src/main/resources/notes.txt\n" +
+					"  6 file(s), 10.0 MiB, digest " + accDigest + "\n" +
+					"fragment frag (" + fragmentDir + "):\n" +
+					"        16 B  accelerator.yaml\n" +
+					"  1 file(s), 16 B, digest " + fragmentDigest + "\n" +
+					"7 file(s) would be packaged, 10.0 MiB in total\n" +
+					"warning: " + filepath.Join(accDir, "large.bin") + " is larger than 10.0 MiB, consider excluding it with .acceleratorignore or --exclude\n"))
+			})
+		})
+
+		When("Executes generate-from-local command with symbolic links, empty directories and executable files", func() {
+			var accDir string
+			var outputDir string
+			var ts *httptest.Server
+			modified := time.Date(2023, 4, 5, 6, 7, 8, 0, time.UTC)
+
+			BeforeEach(func() {
+				var err error
+				accDir, err = os.MkdirTemp("", "acc")
+				Expect(err).NotTo(HaveOccurred())
+				outputDir, err = os.MkdirTemp("", "generated")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(os.WriteFile(filepath.Join(accDir, "accelerator.yaml"), []byte("accelerator: {}\n"), 0644)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(accDir, "mvnw"), []byte("#!/bin/sh\n"), 0755)).To(Succeed())
+				Expect(os.MkdirAll(filepath.Join(accDir, "src", "empty"), 0755)).To(Succeed())
+				Expect(os.MkdirAll(filepath.Join(accDir, "docs"), 0755)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(accDir, "README.md"), []byte("readme"), 0644)).To(Succeed())
+				Expect(os.Symlink("../README.md", filepath.Join(accDir, "docs", "README.md"))).To(Succeed())
+				Expect(os.Symlink("src", filepath.Join(accDir, "sources"))).To(Succeed())
+				// the modification times are normalized when packaging
+				for _, path := range []string{"accelerator.yaml", "mvnw", "README.md", "src/empty", "src", "docs"} {
+					Expect(os.Chtimes(filepath.Join(accDir, path), modified, modified)).To(Succeed())
+				}
+
+				// the generated project has the files of the local accelerator, as they were received
+				mux := http.NewServeMux()
+				mux.HandleFunc("/api/about", func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(200)
+					w.Write([]byte("{}"))
+				})
+				mux.HandleFunc("/api/accelerators/zip", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					// the request is incomplete when packaging fails
+					if err := r.ParseMultipartForm(100 << 20); err != nil {
+						w.WriteHeader(http.StatusBadRequest)
+						return
+					}
+					fileAcc, _, err := r.FormFile("accelerator")
+					Expect(err).NotTo(HaveOccurred())
+					gzr, err := gzip.NewReader(fileAcc)
+					Expect(err).NotTo(HaveOccurred())
+					tr := tar.NewReader(gzr)
+					zipWriter := zip.NewWriter(w)
+					for header, err := tr.Next(); err == nil; header, err = tr.Next() {
+						zipHeader, err := zip.FileInfoHeader(header.FileInfo())
+						Expect(err).NotTo(HaveOccurred())
+						zipHeader.Name = "acc/" + header.Name
+						entry, err := zipWriter.CreateHeader(zipHeader)
+						Expect(err).NotTo(HaveOccurred())
+						if header.Typeflag == tar.TypeSymlink {
+							entry.Write([]byte(header.Linkname))
+						} else {
+							io.Copy(entry, tr)
+						}
+					}
+					zipWriter.Close()
+				}))
+				ts = httptest.NewServer(mux)
+			})
+
+			AfterEach(func() {
+				ts.Close()
+				os.RemoveAll(accDir)
+				os.RemoveAll(outputDir)
+			})
+
+			It("Should keep them in the generated project", func() {
+				generateCmd := LocalGenerateCmd()
+				b := new(bytes.Buffer)
+				generateCmd.SetOut(b)
+				generateCmd.SetErr(b)
+				generateCmd.SetArgs([]string{"--accelerator-path", "acc=" + accDir, "--server-url", ts.URL, "--output-dir", outputDir, "--force"})
+				Expect(generateCmd.Execute()).To(Succeed())
+				Expect(b.String()).Should(Equal("generated project acc\n"))
+
+				fi, err := os.Stat(filepath.Join(outputDir, "mvnw"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(fi.Mode().Perm()).To(Equal(os.FileMode(0755)))
+				Expect(fi.ModTime().Equal(packageModTime)).To(BeTrue(), "unexpected modification time %v", fi.ModTime())
+				fi, err = os.Stat(filepath.Join(outputDir, "README.md"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(fi.Mode().Perm()).To(Equal(os.FileMode(0644)))
+
+				fi, err = os.Stat(filepath.Join(outputDir, "src", "empty"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(fi.IsDir()).To(BeTrue())
+				Expect(fi.ModTime().Equal(packageModTime)).To(BeTrue(), "unexpected modification time %v", fi.ModTime())
+				fi, err = os.Stat(filepath.Join(outputDir, "src"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(fi.ModTime().Equal(packageModTime)).To(BeTrue(), "unexpected modification time %v", fi.ModTime())
+
+				link, err := os.Readlink(filepath.Join(outputDir, "docs", "README.md"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(link).To(Equal("../README.md"))
+				content, err := os.ReadFile(filepath.Join(outputDir, "docs", "README.md"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(content)).To(Equal("readme"))
+				link, err = os.Readlink(filepath.Join(outputDir, "sources"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(link).To(Equal("src"))
+			})
+
+			It("Should refuse symbolic links pointing outside of the accelerator", func() {
+				Expect(os.Symlink("../../etc/passwd", filepath.Join(accDir, "docs", "passwd"))).To(Succeed())
+				generateCmd := LocalGenerateCmd()
+				b := new(bytes.Buffer)
+				generateCmd.SetOut(b)
+				generateCmd.SetErr(b)
+				generateCmd.SetArgs([]string{"--accelerator-path", "acc=" + accDir, "--server-url", ts.URL, "--output-dir", outputDir, "--force"})
+				err := generateCmd.Execute()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("symbolic link " + filepath.Join(accDir, "docs", "passwd") + " points to ../../etc/passwd, which is outside of " + accDir))
+			})
+		})
+
+		When("Executes generate-from-local command and the generated project has a symbolic link outside of it", func() {
+			It("Should output error message", func() {
+				mux := http.NewServeMux()
+				mux.HandleFunc("/api/about", func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(200)
+					w.Write([]byte("{}"))
+				})
+				mux.HandleFunc("/api/accelerators/zip", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					zipWriter := zip.NewWriter(w)
+					header := &zip.FileHeader{Name: "acc/passwd"}
+					header.SetMode(os.ModeSymlink | 0777)
+					entry, _ := zipWriter.CreateHeader(header)
+					entry.Write([]byte("../../etc/passwd"))
+					zipWriter.Close()
+				}))
+				ts := httptest.NewServer(mux)
+				defer ts.Close()
+				outputDir, err := os.MkdirTemp("", "generated")
+				Expect(err).NotTo(HaveOccurred())
+				defer os.RemoveAll(outputDir)
+
+				generateCmd := LocalGenerateCmd()
+				b := new(bytes.Buffer)
+				generateCmd.SetOut(b)
+				generateCmd.SetErr(b)
+				generateCmd.SetArgs([]string{"--accelerator-name", "acc", "--server-url", ts.URL, "--output-dir", outputDir, "--force"})
+				err = generateCmd.Execute()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("invalid symbolic link acc/passwd in generated project, it points to ../../etc/passwd, which is outside of the project directory"))
+			})
+		})
+
+		When("Executes generate-from-local command with a large local accelerator", func() {
+			It("Should stream the upload and the download and show the progress", func() {
+				accDir, err := os.MkdirTemp("", "acc")
+				Expect(err).NotTo(HaveOccurred())
+				defer os.RemoveAll(accDir)
+				outputDir, err := os.MkdirTemp("", "generated")
+				Expect(err).NotTo(HaveOccurred())
+				defer os.RemoveAll(outputDir)
+
+				// random content doesn't compress, so the upload is as large as the files
+				random := rand.New(rand.NewSource(1))
+				digests := map[string]string{}
+				for i := 0; i < 6; i++ {
+					name := fmt.Sprintf("dir-%d/file-%d.bin", i%2, i)
+					content := make([]byte, 4<<20)
+					random.Read(content)
+					Expect(os.MkdirAll(filepath.Dir(filepath.Join(accDir, name)), 0755)).To(Succeed())
+					Expect(os.WriteFile(filepath.Join(accDir, name), content, 0644)).To(Succeed())
+					digests[name] = fmt.Sprintf("%x", sha256.Sum256(content))
+				}
+
+				generated := make([]byte, 8<<20)
+				random.Read(generated)
+				mux := http.NewServeMux()
+				mux.HandleFunc("/api/about", func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(200)
+					w.Write([]byte("{}"))
+				})
+				mux.HandleFunc("/api/accelerators/zip", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					// the body is streamed, so its length is not known in advance
+					Expect(r.ContentLength).To(Equal(int64(-1)))
+					Expect(r.TransferEncoding).To(ConsistOf("chunked"))
+					reader, err := r.MultipartReader()
+					Expect(err).NotTo(HaveOccurred())
+					received := map[string]string{}
+					for part, err := reader.NextPart(); err == nil; part, err = reader.NextPart() {
+						if part.FormName() != "accelerator" {
+							continue
+						}
+						gzr, err := gzip.NewReader(part)
+						Expect(err).NotTo(HaveOccurred())
+						tr := tar.NewReader(gzr)
+						for header, err := tr.Next(); err == nil; header, err = tr.Next() {
+							if header.Typeflag != tar.TypeReg {
+								continue
+							}
+							hash := sha256.New()
+							io.Copy(hash, tr)
+							received[header.Name] = fmt.Sprintf("%x", hash.Sum(nil))
+						}
+					}
+					Expect(received).To(Equal(digests))
+
+					zipped := new(bytes.Buffer)
+					zipWriter := zip.NewWriter(zipped)
+					entry, _ := zipWriter.CreateHeader(&zip.FileHeader{Name: "acc/generated.bin", Method: zip.Store})
+					entry.Write(generated)
+					zipWriter.Close()
+					w.Header().Set("Content-Length", fmt.Sprintf("%d", zipped.Len()))
+					w.Write(zipped.Bytes())
+				}))
+				ts := httptest.NewServer(mux)
+				defer ts.Close()
+
+				generateCmd := LocalGenerateCmd()
+				out := new(bytes.Buffer)
+				errOut := new(bytes.Buffer)
+				generateCmd.SetOut(out)
+				generateCmd.SetErr(errOut)
+				generateCmd.SetArgs([]string{"--accelerator-path", "acc=" + accDir, "--server-url", ts.URL, "--output-dir", outputDir,
+					"--force", "--progress"})
+				Expect(generateCmd.Execute()).To(Succeed())
+				Expect(out.String()).To(Equal("generated project acc\n"))
+				Expect(errOut.String()).To(MatchRegexp(`uploaded 24\.\d MiB\n`))
+				Expect(errOut.String()).To(MatchRegexp(`downloaded 8\.0 MiB of 8\.0 MiB \(100%\)\n$`))
+				content, err := os.ReadFile(filepath.Join(outputDir, "generated.bin"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(bytes.Equal(content, generated)).To(BeTrue())
+			})
+		})
+
+		When("Executes generate-from-local command with --watch", func() {
+			It("Should regenerate the project and list the changed files", func() {
+				mux := http.NewServeMux()
+				mux.HandleFunc("/api/about", func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(200)
+					w.Write([]byte("{}"))
+				})
+				// the generated project contains the files of the local accelerator
+				mux.HandleFunc("/api/accelerators/zip", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					r.ParseMultipartForm(100 << 20)
+					fileAcc, _, err := r.FormFile("accelerator")
+					Expect(err).NotTo(HaveOccurred())
+					gzr, err := gzip.NewReader(fileAcc)
+					Expect(err).NotTo(HaveOccurred())
+					tr := tar.NewReader(gzr)
+					zipWriter := zip.NewWriter(w)
+					for header, err := tr.Next(); err == nil; header, err = tr.Next() {
+						entry, _ := zipWriter.Create("acc/" + header.Name)
+						io.Copy(entry, tr)
+					}
+					zipWriter.Close()
+				}))
+				ts := httptest.NewServer(mux)
+				defer ts.Close()
+
+				accDir, err := os.MkdirTemp("", "acc")
+				Expect(err).NotTo(HaveOccurred())
+				defer os.RemoveAll(accDir)
+				outputDir, err := os.MkdirTemp("", "generated")
+				Expect(err).NotTo(HaveOccurred())
+				defer os.RemoveAll(outputDir)
+				Expect(os.WriteFile(filepath.Join(accDir, "accelerator.yaml"), []byte("accelerator: {}\n"), 0644)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(accDir, "changed.txt"), []byte("before"), 0644)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(accDir, "removed.txt"), []byte("removed"), 0644)).To(Succeed())
+
+				generateCmd := LocalGenerateCmd()
+				out := &syncBuffer{}
+				generateCmd.SetOut(out)
+				generateCmd.SetErr(out)
+				generateCmd.SetArgs([]string{"--accelerator-path", "acc=" + accDir, "--server-url", ts.URL, "--output-dir", outputDir,
+					"--force", "--watch", "--watch-debounce", "200ms"})
+				ctx, cancel := context.WithCancel(context.Background())
+				done := make(chan error)
+				go func() {
+					done <- generateCmd.ExecuteContext(ctx)
+				}()
+				Eventually(out.String, "5s").Should(ContainSubstring("watching " + accDir + " for changes\n"))
+
+				Expect(os.WriteFile(filepath.Join(accDir, "changed.txt"), []byte("after"), 0644)).To(Succeed())
+				Expect(os.Remove(filepath.Join(accDir, "removed.txt"))).To(Succeed())
+				Expect(os.MkdirAll(filepath.Join(accDir, "nested"), 0755)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(accDir, "nested", "added.txt"), []byte("added"), 0644)).To(Succeed())
+
+				Eventually(out.String, "5s").Should(HaveSuffix("generated project acc\n~ changed.txt\n+ nested/added.txt\n- removed.txt\n"))
+				content, err := os.ReadFile(filepath.Join(outputDir, "changed.txt"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(content)).Should(Equal("after"))
+
+				cancel()
+				Eventually(done, "5s").Should(Receive(BeNil()))
+			})
+		})
+	})
+
+	Context("tarToWriter()", func() {
+		// newSourceDir creates a directory with the files, in the order they are listed
+		newSourceDir := func(files [][]string, mode os.FileMode, modified time.Time) string {
+			dir, err := os.MkdirTemp("", "acc")
+			Expect(err).NotTo(HaveOccurred())
+			for _, file := range files {
+				path := filepath.Join(dir, filepath.FromSlash(file[0]))
+				Expect(os.MkdirAll(filepath.Dir(path), 0700)).To(Succeed())
+				fileMode := mode
+				if file[0] == "mvnw" {
+					fileMode = 0700
+				}
+				Expect(os.WriteFile(path, []byte(file[1]), fileMode)).To(Succeed())
+				Expect(os.Chtimes(path, modified, modified)).To(Succeed())
+			}
+			return dir
+		}
+		files := [][]string{
+			{"accelerator.yaml", "accelerator: {}\n"},
+			{"mvnw", "#!/bin/sh\n"},
+			{"src/b.txt", "b"},
+			{"src/a.txt", "a"},
+			{"src.txt", "src"},
+		}
+
+		It("Should produce the same package for the same files", func() {
+			first := newSourceDir(files, 0644, time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+			defer os.RemoveAll(first)
+			reversed := [][]string{}
+			for i := len(files) - 1; i >= 0; i-- {
+				reversed = append(reversed, files[i])
+			}
+			second := newSourceDir(reversed, 0600, time.Now())
+			defer os.RemoveAll(second)
+
+			firstPackage := new(bytes.Buffer)
+			firstDigest, err := tarToWriter(first, firstPackage, packageOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			secondPackage := new(bytes.Buffer)
+			secondDigest, err := tarToWriter(second, secondPackage, packageOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(secondDigest).To(Equal(firstDigest))
+			Expect(bytes.Equal(secondPackage.Bytes(), firstPackage.Bytes())).To(BeTrue())
+			Expect(firstDigest).To(MatchRegexp("^sha256:[0-9a-f]{64}$"))
+
+			gzr, err := gzip.NewReader(firstPackage)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(gzr.Header.Name).To(BeEmpty())
+			Expect(gzr.Header.ModTime.IsZero()).To(BeTrue())
+			Expect(gzr.Header.OS).To(Equal(byte(255)))
+			tr := tar.NewReader(gzr)
+			names := []string{}
+			for header, err := tr.Next(); err == nil; header, err = tr.Next() {
+				names = append(names, header.Name)
+				Expect(header.ModTime.Equal(packageModTime)).To(BeTrue(), "unexpected modification time of %s", header.Name)
+				Expect(header.Uid).To(Equal(0))
+				Expect(header.Gid).To(Equal(0))
+				Expect(header.Uname).To(BeEmpty())
+				Expect(header.Gname).To(BeEmpty())
+				switch header.Name {
+				case "mvnw", "src/":
+					Expect(header.Mode).To(Equal(int64(0755)), "unexpected mode of %s", header.Name)
+				default:
+					Expect(header.Mode).To(Equal(int64(0644)), "unexpected mode of %s", header.Name)
+				}
+			}
+			Expect(names).To(Equal([]string{"accelerator.yaml", "mvnw", "src/", "src/a.txt", "src/b.txt", "src.txt"}))
+		})
+
+		It("Should change the digest when the content changes", func() {
+			dir := newSourceDir(files, 0644, time.Now())
+			defer os.RemoveAll(dir)
+			digest, err := packageDigest(dir, packageOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(os.WriteFile(filepath.Join(dir, "src", "a.txt"), []byte("changed"), 0644)).To(Succeed())
+			changedDigest, err := packageDigest(dir, packageOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(changedDigest).NotTo(Equal(digest))
+
+			excludedDigest, err := packageDigest(dir, packageOptions{excludes: []string{"src/"}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(excludedDigest).NotTo(Equal(changedDigest))
+		})
 	})
 })
+
+// syncBuffer is a bytes.Buffer that can be written by a running command while the test reads it
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
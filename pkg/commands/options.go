@@ -5,6 +5,7 @@ package commands
 
 import (
 	"context"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -27,6 +28,8 @@ type CreateOptions struct {
 	SourceImage string
 	SecretRef   string
 	Tags        []string
+	Labels      []string
+	Annotations []string
 }
 
 type FragmentCreateOptions struct {
@@ -42,6 +45,8 @@ type FragmentCreateOptions struct {
 	// Deprecated: SourceImage is deprecated
 	SourceImage string
 	SecretRef   string
+	Labels      []string
+	Annotations []string
 }
 
 func normalizeGitRepoRun(f *pflag.FlagSet, name string) pflag.NormalizedName {
@@ -66,6 +71,8 @@ func (co *CreateOptions) DefineFlags(ctx context.Context, cmd *cobra.Command, c
 	cmd.Flags().StringVar(&co.SourceImage, "source-image", "", "(DEPRECATED) name of the source image for the accelerator")
 	cmd.Flags().StringVar(&co.SecretRef, "secret-ref", "", "name of secret containing credentials for private Git or image repository")
 	cmd.Flags().StringVar(&co.LocalPath, "local-path", "", "(DEPRECATED) path to the directory containing the source for the accelerator")
+	cmd.Flags().StringArrayVar(&co.Labels, "label", []string{}, "label to set on the accelerator formatted as key=value (can be repeated)")
+	cmd.Flags().StringArrayVar(&co.Annotations, "annotation", []string{}, "annotation to set on the accelerator formatted as key=value (can be repeated)")
 	cmd.Flags().SetNormalizeFunc(normalizeGitRepoRun)
 }
 
@@ -80,6 +87,8 @@ func (co *FragmentCreateOptions) DefineFlags(ctx context.Context, cmd *cobra.Com
 	cmd.Flags().StringVar(&co.SourceImage, "source-image", "", "(DEPRECATED) name of the source image for the accelerator")
 	cmd.Flags().StringVar(&co.SecretRef, "secret-ref", "", "name of secret containing credentials for private Git or image repository")
 	cmd.Flags().StringVar(&co.LocalPath, "local-path", "", "(DEPRECATED) path to the directory containing the source for the accelerator fragment")
+	cmd.Flags().StringArrayVar(&co.Labels, "label", []string{}, "label to set on the accelerator fragment formatted as key=value (can be repeated)")
+	cmd.Flags().StringArrayVar(&co.Annotations, "annotation", []string{}, "annotation to set on the accelerator fragment formatted as key=value (can be repeated)")
 	cmd.Flags().SetNormalizeFunc(normalizeGitRepoRun)
 }
 
@@ -97,6 +106,12 @@ type UpdateOptions struct {
 	SourceImage string
 	SecretRef   string
 	Tags        []string
+	AddTags     []string
+	RemoveTags  []string
+	ClearTags   bool
+	Unset       []string
+	Labels      []string
+	Annotations []string
 	Reconcile   bool
 }
 
@@ -105,7 +120,10 @@ func (uo *UpdateOptions) DefineFlags(ctx context.Context, cmd *cobra.Command, c
 	cmd.Flags().StringVar(&uo.Description, "description", "", "description of this accelerator")
 	cmd.Flags().StringVar(&uo.DisplayName, "display-name", "", "display name for the accelerator")
 	cmd.Flags().StringVar(&uo.IconUrl, "icon-url", "", "URL for icon to use with the accelerator")
-	cmd.Flags().StringSliceVar(&uo.Tags, "tags", []string{}, "tags that can be used to search for accelerators")
+	cmd.Flags().StringSliceVar(&uo.Tags, "tags", []string{}, "tags that can be used to search for accelerators, replacing any existing tags")
+	cmd.Flags().StringSliceVar(&uo.AddTags, "add-tag", []string{}, "tags to add to the existing tags of the accelerator")
+	cmd.Flags().StringSliceVar(&uo.RemoveTags, "remove-tag", []string{}, "tags to remove from the existing tags of the accelerator")
+	cmd.Flags().BoolVar(&uo.ClearTags, "clear-tags", false, "remove all tags from the accelerator")
 	cmd.Flags().StringVar(&uo.GitRepoUrl, "git-repository", "", "Git repository URL for the accelerator")
 	cmd.Flags().StringVar(&uo.GitBranch, "git-branch", "", "Git repository branch to be used")
 	cmd.Flags().StringVar(&uo.GitTag, "git-tag", "", "Git repository tag to be used")
@@ -114,7 +132,13 @@ func (uo *UpdateOptions) DefineFlags(ctx context.Context, cmd *cobra.Command, c
 	cmd.Flags().StringVar(&uo.Interval, "interval", "", "interval for checking for updates to Git or image repository")
 	cmd.Flags().StringVar(&uo.SourceImage, "source-image", "", "(DEPRECATED) name of the source image for the accelerator")
 	cmd.Flags().StringVar(&uo.SecretRef, "secret-ref", "", "name of secret containing credentials for private Git or image repository")
+	cmd.Flags().StringSliceVar(&uo.Unset, "unset", []string{}, "fields to remove from the accelerator (display-name, description, icon-url, git-tag, git-sub-path, interval, secret-ref)")
+	cmd.Flags().StringArrayVar(&uo.Labels, "label", []string{}, "label to set on the accelerator formatted as key=value, or key- to remove the label (can be repeated)")
+	cmd.Flags().StringArrayVar(&uo.Annotations, "annotation", []string{}, "annotation to set on the accelerator formatted as key=value, or key- to remove the annotation (can be repeated)")
 	cmd.Flags().SetNormalizeFunc(normalizeGitRepoRun)
+	cmd.MarkFlagsMutuallyExclusive("tags", "add-tag")
+	cmd.MarkFlagsMutuallyExclusive("tags", "remove-tag")
+	cmd.MarkFlagsMutuallyExclusive("tags", "clear-tags")
 }
 
 type FragmentUpdateOptions struct {
@@ -128,6 +152,9 @@ type FragmentUpdateOptions struct {
 	// Deprecated: SourceImage is deprecated
 	SourceImage string
 	SecretRef   string
+	Unset       []string
+	Labels      []string
+	Annotations []string
 	Reconcile   bool
 }
 
@@ -142,6 +169,9 @@ func (uo *FragmentUpdateOptions) DefineFlags(ctx context.Context, cmd *cobra.Com
 	cmd.Flags().StringVar(&uo.Interval, "interval", "", "interval for checking for updates to Git repository")
 	cmd.Flags().StringVar(&uo.SourceImage, "source-image", "", "(DEPRECATED) name of the source image for the accelerator fragment")
 	cmd.Flags().StringVar(&uo.SecretRef, "secret-ref", "", "name of secret containing credentials for private Git repository")
+	cmd.Flags().StringSliceVar(&uo.Unset, "unset", []string{}, "fields to remove from the accelerator fragment (display-name, git-tag, git-sub-path, interval, secret-ref)")
+	cmd.Flags().StringArrayVar(&uo.Labels, "label", []string{}, "label to set on the accelerator fragment formatted as key=value, or key- to remove the label (can be repeated)")
+	cmd.Flags().StringArrayVar(&uo.Annotations, "annotation", []string{}, "annotation to set on the accelerator fragment formatted as key=value, or key- to remove the annotation (can be repeated)")
 	cmd.Flags().SetNormalizeFunc(normalizeGitRepoRun)
 }
 
@@ -159,15 +189,46 @@ func (po *PushOptions) DefineFlags(ctx context.Context, cmd *cobra.Command, c *c
 
 type DeleteOptions struct {
 	Namespace string
+	All       bool
+	Selector  string
+	Tags      []string
+	Yes       bool
+	DryRun    bool
 }
 
 func (do *DeleteOptions) DefineFlags(ctx context.Context, cmd *cobra.Command, c *cli.Config) {
 	cmd.Flags().StringVarP(&do.Namespace, "namespace", "n", "accelerator-system", "namespace for accelerator system")
+	cmd.Flags().BoolVar(&do.All, "all", false, "delete all accelerators in the namespace")
+	cmd.Flags().StringVarP(&do.Selector, "selector", "l", "", "label selector to match accelerators against (e.g. team=alpha,lifecycle!=stable)")
+	cmd.Flags().StringSliceVarP(&do.Tags, "tags", "t", []string{}, "delete accelerators that have all of the specified tags")
+	cmd.Flags().BoolVarP(&do.Yes, "yes", "y", false, "skip the confirmation prompt when deleting multiple accelerators")
+	cmd.Flags().BoolVar(&do.DryRun, "dry-run", false, "list the accelerators that would be deleted without deleting them")
+}
+
+type FragmentDeleteOptions struct {
+	Namespace string
+	All       bool
+	Selector  string
+	Yes       bool
+	DryRun    bool
+}
 
+func (do *FragmentDeleteOptions) DefineFlags(ctx context.Context, cmd *cobra.Command, c *cli.Config) {
+	cmd.Flags().StringVarP(&do.Namespace, "namespace", "n", "accelerator-system", "namespace for accelerator fragments")
+	cmd.Flags().BoolVar(&do.All, "all", false, "delete all accelerator fragments in the namespace")
+	cmd.Flags().StringVarP(&do.Selector, "selector", "l", "", "label selector to match accelerator fragments against (e.g. team=alpha,lifecycle!=stable)")
+	cmd.Flags().BoolVarP(&do.Yes, "yes", "y", false, "skip the confirmation prompt when deleting multiple accelerator fragments")
+	cmd.Flags().BoolVar(&do.DryRun, "dry-run", false, "list the accelerator fragments that would be deleted without deleting them")
 }
 
 type ListOptions struct {
 	Tags        []string
+	Search      string
+	ReadyOnly   bool
+	SortBy      string
+	Limit       int64
+	Continue    string
+	Selector    string
 	Namespace   string
 	ServerUrl   string
 	FromContext bool
@@ -175,7 +236,13 @@ type ListOptions struct {
 }
 
 func (lo *ListOptions) DefineFlags(ctx context.Context, cmd *cobra.Command, c *cli.Config) {
-	cmd.Flags().StringSliceVarP(&lo.Tags, "tags", "t", []string{}, "accelerator tags to match against")
+	cmd.Flags().StringSliceVarP(&lo.Tags, "tags", "t", []string{}, "accelerator tag expressions to match against, like java, spring|go or !legacy")
+	cmd.Flags().StringVar(&lo.Search, "search", "", "text to search for in the name, display name, description and tags of the accelerators")
+	cmd.Flags().BoolVar(&lo.ReadyOnly, "ready-only", false, "only list the accelerators that are ready")
+	cmd.Flags().StringVar(&lo.SortBy, "sort-by", "name", "sort the accelerators by name, ready, tags or created")
+	cmd.Flags().Int64Var(&lo.Limit, "limit", 0, "maximum number of accelerators to list at a time, the next accelerators are listed with --continue")
+	cmd.Flags().StringVar(&lo.Continue, "continue", "", "token shown by a previous list with --limit to list the next accelerators")
+	cmd.Flags().StringVarP(&lo.Selector, "selector", "l", "", "label selector to match accelerators against, only supported when listing from context")
 	cmd.Flags().StringVarP(&lo.Namespace, "namespace", "n", "accelerator-system", "namespace for accelerator system")
 	cmd.Flags().StringVar(&lo.ServerUrl, "server-url", "", "the URL for the Application Accelerator server")
 	cmd.Flags().BoolVar(&lo.FromContext, "from-context", false, "retrieve resources from current context defined in kubeconfig")
@@ -184,11 +251,13 @@ func (lo *ListOptions) DefineFlags(ctx context.Context, cmd *cobra.Command, c *c
 
 type FragmentListOptions struct {
 	Namespace string
+	Selector  string
 	Verbose   bool
 }
 
 func (lo *FragmentListOptions) DefineFlags(ctx context.Context, cmd *cobra.Command, c *cli.Config) {
 	cmd.Flags().StringVarP(&lo.Namespace, "namespace", "n", "accelerator-system", "namespace for accelerator system")
+	cmd.Flags().StringVarP(&lo.Selector, "selector", "l", "", "label selector to match accelerator fragments against")
 	cmd.Flags().BoolVarP(&lo.Verbose, "verbose", "v", false, "include repository and show long URLs or image digests in the output")
 }
 
@@ -224,3 +293,144 @@ func (appopts *ApplyOptions) DefineFlags(ctx context.Context, cmd *cobra.Command
 	cmd.Flags().StringVarP(&appopts.FileName, "filename", "f", "", "path of manifest file for the resource")
 	cmd.MarkFlagRequired("filename")
 }
+
+type ExportOptions struct {
+	Namespace string
+	Kind      string
+	Tags      []string
+	Selector  string
+	File      string
+	OutputDir string
+}
+
+func (eo *ExportOptions) DefineFlags(ctx context.Context, cmd *cobra.Command, c *cli.Config) {
+	cmd.Flags().StringVarP(&eo.Namespace, "namespace", "n", "accelerator-system", "namespace to export the resources from")
+	cmd.Flags().StringVar(&eo.Kind, "kind", "all", "kind of resources to export, one of accelerator, fragment or all")
+	cmd.Flags().StringSliceVarP(&eo.Tags, "tags", "t", []string{}, "only export accelerators that have all of the specified tags")
+	cmd.Flags().StringVarP(&eo.Selector, "selector", "l", "", "label selector to match resources against (e.g. team=alpha,lifecycle!=stable)")
+	cmd.Flags().StringVarP(&eo.File, "file", "f", "", "path of the file to write the resources to as a multi-document YAML manifest (default is stdout)")
+	cmd.Flags().StringVar(&eo.OutputDir, "output-dir", "", "path of the directory to write the resources to using one file per resource")
+	cmd.MarkFlagsMutuallyExclusive("file", "output-dir")
+}
+
+type SyncOptions struct {
+	Namespace       string
+	TargetContext   string
+	TargetNamespace string
+	Selector        string
+	Prune           bool
+	DryRun          bool
+	Yes             bool
+}
+
+func (so *SyncOptions) DefineFlags(ctx context.Context, cmd *cobra.Command, c *cli.Config) {
+	cmd.Flags().StringVarP(&so.Namespace, "namespace", "n", "accelerator-system", "namespace to sync the resources from")
+	cmd.Flags().StringVar(&so.TargetContext, "target-context", "", "name of the kubeconfig context to sync the resources to (default is the current context)")
+	cmd.Flags().StringVar(&so.TargetNamespace, "target-namespace", "", "namespace to sync the resources to (default is the source namespace)")
+	cmd.Flags().StringVarP(&so.Selector, "selector", "l", "", "label selector to match the resources to sync against (e.g. team=alpha,lifecycle!=stable)")
+	cmd.Flags().BoolVar(&so.Prune, "prune", false, "delete resources in the target that don't exist in the source")
+	cmd.Flags().BoolVar(&so.DryRun, "dry-run", false, "only show the changes that would be made to the target")
+	cmd.Flags().BoolVarP(&so.Yes, "yes", "y", false, "skip the confirmation prompt when pruning resources")
+}
+
+type ValidateOptions struct {
+	Namespace string
+	FileNames []string
+	Output    string
+}
+
+func (vo *ValidateOptions) DefineFlags(ctx context.Context, cmd *cobra.Command, c *cli.Config) {
+	cmd.Flags().StringVarP(&vo.Namespace, "namespace", "n", "accelerator-system", "namespace the resources will be applied to")
+	cmd.Flags().StringSliceVarP(&vo.FileNames, "filename", "f", []string{}, "paths of manifest files or directories containing manifest files to validate")
+	cmd.MarkFlagRequired("filename")
+	cmd.Flags().StringVarP(&vo.Output, "output", "o", "human", "output format, one of human or json")
+}
+
+type ValidateLocalOptions struct {
+	Namespace     string
+	FragmentPaths map[string]string
+	Offline       bool
+	Output        string
+}
+
+func (vo *ValidateLocalOptions) DefineFlags(ctx context.Context, cmd *cobra.Command, c *cli.Config) {
+	cmd.Flags().StringVarP(&vo.Namespace, "namespace", "n", "accelerator-system", "namespace of the accelerator fragments used to check imports")
+	cmd.Flags().StringToStringVar(&vo.FragmentPaths, "fragment-paths", map[string]string{}, "local fragments to check imports against, as fragment name and path of the directory containing its accelerator.yaml")
+	cmd.Flags().BoolVar(&vo.Offline, "offline", false, "don't check imports against the accelerator fragments on the cluster")
+	cmd.Flags().StringVarP(&vo.Output, "output", "o", "human", "output format, one of human or json")
+}
+
+type InitOptions struct {
+	Directory   string
+	DisplayName string
+	Description string
+	Tags        []string
+	Imports     []string
+	GitRepoUrl  string
+	GitBranch   string
+	GitSubPath  string
+	Force       bool
+}
+
+func (ino *InitOptions) DefineFlags(ctx context.Context, cmd *cobra.Command, c *cli.Config) {
+	cmd.Flags().StringVarP(&ino.Directory, "directory", "d", "", "directory to create the accelerator in, defaults to the name of the accelerator")
+	cmd.Flags().StringVar(&ino.DisplayName, "display-name", "", "display name of the accelerator")
+	cmd.Flags().StringVar(&ino.Description, "description", "", "description of the accelerator")
+	cmd.Flags().StringSliceVar(&ino.Tags, "tags", []string{}, "tags of the accelerator")
+	cmd.Flags().StringSliceVar(&ino.Imports, "import", []string{}, "names of the accelerator fragments the accelerator imports")
+	cmd.Flags().StringVar(&ino.GitRepoUrl, "git-repository", "", "Git repository URL the accelerator will be pushed to")
+	cmd.Flags().StringVar(&ino.GitBranch, "git-branch", "main", "Git repository branch to be used")
+	cmd.Flags().StringVar(&ino.GitSubPath, "git-sub-path", "", "Git repository subPath to be used")
+	cmd.Flags().BoolVar(&ino.Force, "force", false, "overwrite existing files in the directory")
+}
+
+type FragmentInitOptions struct {
+	Directory   string
+	DisplayName string
+	GitRepoUrl  string
+	GitBranch   string
+	GitSubPath  string
+	Force       bool
+}
+
+func (ino *FragmentInitOptions) DefineFlags(ctx context.Context, cmd *cobra.Command, c *cli.Config) {
+	cmd.Flags().StringVarP(&ino.Directory, "directory", "d", "", "directory to create the accelerator fragment in, defaults to the name of the accelerator fragment")
+	cmd.Flags().StringVar(&ino.DisplayName, "display-name", "", "display name of the accelerator fragment")
+	cmd.Flags().StringVar(&ino.GitRepoUrl, "git-repository", "", "Git repository URL the accelerator fragment will be pushed to")
+	cmd.Flags().StringVar(&ino.GitBranch, "git-branch", "main", "Git repository branch to be used")
+	cmd.Flags().StringVar(&ino.GitSubPath, "git-sub-path", "", "Git repository subPath to be used")
+	cmd.Flags().BoolVar(&ino.Force, "force", false, "overwrite existing files in the directory")
+}
+
+type PublishOptions struct {
+	Namespace   string
+	LocalPath   string
+	GitRepoUrl  string
+	GitBranch   string
+	GitSubPath  string
+	Message     string
+	SecretRef   string
+	Interval    string
+	DisplayName string
+	Description string
+	Tags        []string
+	Wait        bool
+	WaitTimeout time.Duration
+}
+
+func (po *PublishOptions) DefineFlags(ctx context.Context, cmd *cobra.Command, c *cli.Config) {
+	cmd.Flags().StringVarP(&po.Namespace, "namespace", "n", "accelerator-system", "namespace for accelerator system")
+	cmd.Flags().StringVar(&po.LocalPath, "local-path", ".", "path to the directory containing the accelerator")
+	cmd.Flags().StringVar(&po.GitRepoUrl, "git-repository", "", "Git repository URL to publish the accelerator to")
+	cmd.MarkFlagRequired("git-repository")
+	cmd.Flags().StringVar(&po.GitBranch, "git-branch", "main", "Git repository branch to publish the accelerator to")
+	cmd.Flags().StringVar(&po.GitSubPath, "git-sub-path", "", "directory in the Git repository to publish the accelerator to")
+	cmd.Flags().StringVarP(&po.Message, "message", "m", "", "message of the Git commit (defaults to \"Publish accelerator <name>\")")
+	cmd.Flags().StringVar(&po.SecretRef, "secret-ref", "", "name of secret containing credentials for the Git repository")
+	cmd.Flags().StringVar(&po.Interval, "interval", "", "interval for checking for updates to the Git repository")
+	cmd.Flags().StringVar(&po.DisplayName, "display-name", "", "display name for the accelerator")
+	cmd.Flags().StringVar(&po.Description, "description", "", "description of this accelerator")
+	cmd.Flags().StringSliceVar(&po.Tags, "tags", []string{}, "tags that can be used to search for accelerators")
+	cmd.Flags().BoolVar(&po.Wait, "wait", false, "wait for the accelerator to be ready")
+	cmd.Flags().DurationVar(&po.WaitTimeout, "wait-timeout", 5*time.Minute, "maximum time to wait for the accelerator to be ready")
+}
@@ -0,0 +1,208 @@
+/*
+Copyright 2022-2023 VMware, Inc. All Rights Reserved.
+*/
+package commands
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// applyChange is how a file of the generated project changes the existing project
+type applyChange int
+
+const (
+	applyUnchanged applyChange = iota
+	applyAdded
+	applyConflict
+)
+
+// applyProject applies the files of the generated project to the existing target directory. The files that already
+// exist with a different content, or a different type, are conflicts: nothing is written when there are conflicts
+// unless overwrite is set. The existing files that are not part of the generated project are kept.
+func applyProject(cmd *cobra.Command, zipReader *zip.Reader, targetDirectory string, overwrite bool) error {
+	if fi, err := os.Stat(targetDirectory); err != nil || !fi.IsDir() {
+		return fmt.Errorf("directory %s doesn't exist, the fragments are applied to an existing project", targetDirectory)
+	}
+
+	root, err := filepath.EvalSymlinks(targetDirectory)
+	if err != nil {
+		return err
+	}
+	changes := map[*zip.File]applyChange{}
+	conflicts := []string{}
+	for _, f := range zipReader.File {
+		path := extractedPath(f, targetDirectory)
+		if !isInside(targetDirectory, path) {
+			return fmt.Errorf("invalid file %s in generated project, it is outside of the project directory", f.Name)
+		}
+		// the existing directories the file is written to must not be symbolic links leading outside of the project
+		if filepath.Clean(path) != filepath.Clean(targetDirectory) {
+			inside, err := parentsInside(root, path)
+			if err != nil {
+				return err
+			}
+			if !inside {
+				return fmt.Errorf("cannot apply %s to %s, one of its directories is a symbolic link outside of the project directory", relativeName(f), targetDirectory)
+			}
+		}
+		change, err := compareWithExisting(f, path)
+		if err != nil {
+			return err
+		}
+		changes[f] = change
+		if change == applyConflict {
+			conflicts = append(conflicts, relativeName(f))
+		}
+	}
+	if len(conflicts) > 0 && !overwrite {
+		sort.Strings(conflicts)
+		return fmt.Errorf("%d file(s) in %s conflict with the generated files, use --force to overwrite them:\n  %s", len(conflicts), targetDirectory, strings.Join(conflicts, "\n  "))
+	}
+
+	lines := []string{}
+	unchanged := 0
+	for _, f := range zipReader.File {
+		switch changes[f] {
+		case applyUnchanged:
+			if !f.FileInfo().IsDir() {
+				unchanged++
+			}
+			continue
+		case applyConflict:
+			// an existing path of another type is removed first, a file can replace a directory and the other way
+			// around, and a symbolic link must not be followed when the file is written
+			path := extractedPath(f, targetDirectory)
+			if fi, err := os.Lstat(path); err == nil && !sameType(fi, f) {
+				if err := os.RemoveAll(path); err != nil {
+					return fmt.Errorf("could not remove %s: %v", path, err)
+				}
+			}
+			lines = append(lines, "~ "+relativeName(f))
+		case applyAdded:
+			if !f.FileInfo().IsDir() {
+				lines = append(lines, "+ "+relativeName(f))
+			}
+		}
+		if err := extractFile(f, targetDirectory); err != nil {
+			return err
+		}
+	}
+
+	sort.Slice(lines, func(i, j int) bool {
+		return lines[i][2:] < lines[j][2:]
+	})
+	for _, line := range lines {
+		fmt.Fprintf(cmd.OutOrStdout(), "%s\n", line)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "applied %d file(s) to %s, %d file(s) unchanged\n", len(lines), targetDirectory, unchanged)
+	return nil
+}
+
+// compareWithExisting returns how the zip entry changes the file at path
+func compareWithExisting(f *zip.File, path string) (applyChange, error) {
+	fi, err := os.Lstat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return applyAdded, nil
+	} else if err != nil {
+		return applyConflict, err
+	}
+
+	switch {
+	case f.FileInfo().IsDir():
+		if fi.IsDir() {
+			return applyUnchanged, nil
+		}
+		return applyConflict, nil
+	case f.Mode()&os.ModeSymlink != 0:
+		if fi.Mode()&os.ModeSymlink == 0 {
+			return applyConflict, nil
+		}
+		link, err := readZipEntry(f)
+		if err != nil {
+			return applyConflict, err
+		}
+		existing, err := os.Readlink(path)
+		if err != nil {
+			return applyConflict, err
+		}
+		if filepath.ToSlash(existing) == string(link) {
+			return applyUnchanged, nil
+		}
+		return applyConflict, nil
+	default:
+		if !fi.Mode().IsRegular() {
+			return applyConflict, nil
+		}
+		content, err := readZipEntry(f)
+		if err != nil {
+			return applyConflict, err
+		}
+		existing, err := os.ReadFile(path)
+		if err != nil {
+			return applyConflict, err
+		}
+		if bytes.Equal(content, existing) && fi.Mode().Perm()&0111 == f.Mode().Perm()&0111 {
+			return applyUnchanged, nil
+		}
+		return applyConflict, nil
+	}
+}
+
+// sameType returns true if the existing file and the zip entry are both directories, symbolic links or regular files
+func sameType(fi os.FileInfo, f *zip.File) bool {
+	switch {
+	case f.FileInfo().IsDir():
+		return fi.IsDir()
+	case f.Mode()&os.ModeSymlink != 0:
+		return fi.Mode()&os.ModeSymlink != 0
+	default:
+		return fi.Mode().IsRegular()
+	}
+}
+
+// parentsInside returns true if the nearest existing directory of path, once its symbolic links are resolved, is the
+// resolved root directory or inside of it
+func parentsInside(root string, path string) (bool, error) {
+	dir := filepath.Dir(path)
+	for {
+		if _, err := os.Lstat(dir); err == nil {
+			break
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return false, err
+		}
+		dir = filepath.Dir(dir)
+	}
+	resolved, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return false, err
+	}
+	return isInside(root, resolved), nil
+}
+
+func readZipEntry(f *zip.File) ([]byte, error) {
+	reader, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("could not open file %s", f.Name)
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+// relativeName returns the name of the zip entry without the top level directory
+func relativeName(f *zip.File) string {
+	parts := strings.SplitN(strings.TrimSuffix(f.Name, "/"), "/", 2)
+	if len(parts) < 2 {
+		return ""
+	}
+	return parts[1]
+}
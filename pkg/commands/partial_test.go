@@ -0,0 +1,194 @@
+package commands
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("command run", func() {
+	Context("LocalGenerateCmd() with only fragments", func() {
+		var projectDir string
+		var fragmentDir string
+		var ts *httptest.Server
+		var form map[string][]string
+
+		BeforeEach(func() {
+			var err error
+			projectDir, err = os.MkdirTemp("", "project")
+			Expect(err).NotTo(HaveOccurred())
+			fragmentDir, err = os.MkdirTemp("", "fragment")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(os.WriteFile(filepath.Join(fragmentDir, "accelerator.yaml"), []byte("accelerator: {}\n"), 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(projectDir, "README.md"), []byte("existing"), 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(projectDir, "pom.xml"), []byte("<project/>"), 0644)).To(Succeed())
+			form = nil
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("/api/about", func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(200)
+				w.Write([]byte("{}"))
+			})
+			mux.HandleFunc("/api/accelerators/zip", func(w http.ResponseWriter, r *http.Request) {
+				r.ParseMultipartForm(10 << 20)
+				form = map[string][]string{}
+				for name, values := range r.MultipartForm.Value {
+					form[name] = values
+				}
+				for name := range r.MultipartForm.File {
+					form[name] = []string{"file"}
+				}
+				zipWriter := zip.NewWriter(w)
+				zipWriter.Create("project/")
+				entry, _ := zipWriter.Create("project/config/workload.yaml")
+				entry.Write([]byte("kind: Workload"))
+				entry, _ = zipWriter.Create("project/pom.xml")
+				entry.Write([]byte("<project>java 17</project>"))
+				entry, _ = zipWriter.Create("project/README.md")
+				entry.Write([]byte("existing"))
+				zipWriter.Close()
+			})
+			ts = httptest.NewServer(mux)
+		})
+
+		AfterEach(func() {
+			ts.Close()
+			os.RemoveAll(projectDir)
+			os.RemoveAll(fragmentDir)
+		})
+
+		generate := func(args ...string) (string, error) {
+			generateCmd := LocalGenerateCmd()
+			b := new(bytes.Buffer)
+			generateCmd.SetOut(b)
+			generateCmd.SetErr(b)
+			generateCmd.SetArgs(append([]string{"--server-url", ts.URL, "--fragment-names", "tap-workload", "--fragment-paths", "java-version=" + fragmentDir}, args...))
+			err := generateCmd.Execute()
+			return b.String(), err
+		}
+		read := func(name string) string {
+			content, err := os.ReadFile(filepath.Join(projectDir, name))
+			Expect(err).NotTo(HaveOccurred())
+			return string(content)
+		}
+
+		It("Should refuse to overwrite conflicting files", func() {
+			_, err := generate("--output-dir", projectDir)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("1 file(s) in " + projectDir + " conflict with the generated files, use --force to overwrite them:\n  pom.xml"))
+			Expect(read("pom.xml")).To(Equal("<project/>"))
+			Expect(filepath.Join(projectDir, "config", "workload.yaml")).NotTo(BeAnExistingFile())
+
+			Expect(form).NotTo(HaveKey("accelerator_name"))
+			Expect(form).NotTo(HaveKey("accelerator"))
+			Expect(form).To(HaveKeyWithValue("fragment_names", []string{"tap-workload"}))
+			Expect(form).To(HaveKey("fragment_java-version"))
+			Expect(form["options"][0]).To(MatchJSON(`{"projectName":"` + filepath.Base(projectDir) + `"}`))
+		})
+
+		It("Should apply the generated files to the existing project", func() {
+			Expect(os.Remove(filepath.Join(projectDir, "pom.xml"))).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(projectDir, "Makefile"), []byte("all:"), 0644)).To(Succeed())
+			out, err := generate("--output-dir", projectDir, "--options", `{"projectName":"orders"}`)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(out).To(Equal("generated fragments\n+ config/workload.yaml\n+ pom.xml\napplied 2 file(s) to " + projectDir + ", 1 file(s) unchanged\n"))
+			Expect(read("config/workload.yaml")).To(Equal("kind: Workload"))
+			Expect(read("Makefile")).To(Equal("all:"))
+			Expect(form["options"][0]).To(MatchJSON(`{"projectName":"orders"}`))
+		})
+
+		It("Should overwrite the conflicting files with --force", func() {
+			out, err := generate("--output-dir", projectDir, "--force")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(out).To(Equal("generated fragments\n+ config/workload.yaml\n~ pom.xml\napplied 2 file(s) to " + projectDir + ", 1 file(s) unchanged\n"))
+			Expect(read("pom.xml")).To(Equal("<project>java 17</project>"))
+			Expect(read("README.md")).To(Equal("existing"))
+			// the entries of the generated zip have the mode 0666, only the executable bit is kept
+			for _, name := range []string{"pom.xml", "config/workload.yaml"} {
+				fi, err := os.Stat(filepath.Join(projectDir, name))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(fi.Mode().Perm()).To(Equal(os.FileMode(0644)), "unexpected mode of %s", name)
+			}
+		})
+
+		It("Should replace a symbolic link pointing outside of the project instead of writing through it", func() {
+			outsideDir, err := os.MkdirTemp("", "outside")
+			Expect(err).NotTo(HaveOccurred())
+			defer os.RemoveAll(outsideDir)
+			outsideFile := filepath.Join(outsideDir, "pom.xml")
+			Expect(os.WriteFile(outsideFile, []byte("<outside/>"), 0644)).To(Succeed())
+			Expect(os.Remove(filepath.Join(projectDir, "pom.xml"))).To(Succeed())
+			Expect(os.Symlink(outsideFile, filepath.Join(projectDir, "pom.xml"))).To(Succeed())
+
+			out, err := generate("--output-dir", projectDir, "--force")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(out).To(Equal("generated fragments\n+ config/workload.yaml\n~ pom.xml\napplied 2 file(s) to " + projectDir + ", 1 file(s) unchanged\n"))
+			fi, err := os.Lstat(filepath.Join(projectDir, "pom.xml"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fi.Mode().IsRegular()).To(BeTrue())
+			Expect(read("pom.xml")).To(Equal("<project>java 17</project>"))
+			content, err := os.ReadFile(outsideFile)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(content)).To(Equal("<outside/>"))
+		})
+
+		It("Should refuse to write through a directory that is a symbolic link outside of the project", func() {
+			outsideDir, err := os.MkdirTemp("", "outside")
+			Expect(err).NotTo(HaveOccurred())
+			defer os.RemoveAll(outsideDir)
+			Expect(os.Symlink(outsideDir, filepath.Join(projectDir, "config"))).To(Succeed())
+
+			_, err = generate("--output-dir", projectDir, "--force")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("cannot apply config/workload.yaml to " + projectDir + ", one of its directories is a symbolic link outside of the project directory"))
+			Expect(filepath.Join(outsideDir, "workload.yaml")).NotTo(BeAnExistingFile())
+		})
+
+		It("Should not overwrite the files edited in the project in --watch mode without --force", func() {
+			Expect(os.Remove(filepath.Join(projectDir, "pom.xml"))).To(Succeed())
+			generateCmd := LocalGenerateCmd()
+			out := &syncBuffer{}
+			generateCmd.SetOut(out)
+			generateCmd.SetErr(out)
+			generateCmd.SetArgs([]string{"--server-url", ts.URL, "--fragment-names", "tap-workload", "--fragment-paths", "java-version=" + fragmentDir,
+				"--output-dir", projectDir, "--watch", "--watch-debounce", "200ms"})
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan error)
+			go func() {
+				done <- generateCmd.ExecuteContext(ctx)
+			}()
+			Eventually(out.String, "5s").Should(ContainSubstring("watching " + fragmentDir + " for changes\n"))
+			Expect(read("pom.xml")).To(Equal("<project>java 17</project>"))
+
+			Expect(os.WriteFile(filepath.Join(projectDir, "pom.xml"), []byte("<project>edited</project>"), 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(fragmentDir, "accelerator.yaml"), []byte("accelerator: {}\noptions: []\n"), 0644)).To(Succeed())
+
+			Eventually(out.String, "5s").Should(ContainSubstring("error generating project: 1 file(s) in " + projectDir + " conflict with the generated files, use --force to overwrite them:\n  pom.xml\n"))
+			Expect(read("pom.xml")).To(Equal("<project>edited</project>"))
+
+			cancel()
+			Eventually(done, "5s").Should(Receive(BeNil()))
+		})
+
+		It("Should require an existing output directory", func() {
+			_, err := generate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("no output directory, you must provide --output-dir with the existing project the fragments are applied to"))
+
+			_, err = generate("--output-dir", filepath.Join(projectDir, "missing"))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("directory " + filepath.Join(projectDir, "missing") + " doesn't exist, the fragments are applied to an existing project"))
+
+			_, err = generate("--output-dir", projectDir, "--git-init")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("--git-init and --git-push can't be used when only fragments are generated, the fragments are applied to an existing project"))
+		})
+	})
+})
@@ -0,0 +1,266 @@
+/*
+Copyright 2022-2023 VMware, Inc. All Rights Reserved.
+*/
+package commands
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/spf13/cobra"
+)
+
+// previewFlags are the flags of the commands generating projects that show the generated project instead of writing it
+type previewFlags struct {
+	preview     bool
+	show        []string
+	diffAgainst string
+}
+
+func (f *previewFlags) define(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&f.preview, "preview", false, "print the files of the generated project with their sizes instead of writing it")
+	cmd.Flags().StringArrayVar(&f.show, "show", []string{}, "pattern of the files of the generated project whose content is printed with --preview, like README.md or src/**/*.java (can be used multiple times)")
+	cmd.Flags().StringVar(&f.diffAgainst, "diff-against", "", "compare the generated project with an existing directory instead of writing it, implies --preview")
+}
+
+// enabled returns true if the generated project is previewed instead of written
+func (f *previewFlags) enabled() bool {
+	return f.preview || f.diffAgainst != ""
+}
+
+// validate checks the patterns of the files to show
+func (f *previewFlags) validate() error {
+	if len(f.show) > 0 && !f.enabled() {
+		return fmt.Errorf("--show can only be used with --preview")
+	}
+	for _, pattern := range f.show {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q for --show: %v", pattern, err)
+		}
+	}
+	return nil
+}
+
+// print prints the tree of the files of the generated project and the content of the files matching --show, or the
+// differences with the --diff-against directory. When the generated files are applied to the directory keepExisting
+// is set, its other files are not shown as removed.
+func (f *previewFlags) print(cmd *cobra.Command, zipReader *zip.Reader, projectName string, keepExisting bool) error {
+	if f.diffAgainst != "" {
+		return printProjectDiff(cmd, zipReader, f.diffAgainst, keepExisting)
+	}
+	printProjectTree(cmd, zipReader, projectName)
+	shown := []*zip.File{}
+	for _, file := range zipReader.File {
+		if !file.FileInfo().IsDir() && file.Mode()&os.ModeSymlink == 0 && matchesAny(f.show, relativeName(file)) {
+			shown = append(shown, file)
+		}
+	}
+	sort.Slice(shown, func(i, j int) bool {
+		return relativeName(shown[i]) < relativeName(shown[j])
+	})
+	for _, file := range shown {
+		name := relativeName(file)
+		content, err := readZipEntry(file)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "\n==> %s <==\n", name)
+		if !isText(content) {
+			fmt.Fprintf(cmd.OutOrStdout(), "(binary file, %s)\n", formatSize(int64(len(content))))
+			continue
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "%s", content)
+		if len(content) > 0 && content[len(content)-1] != '\n' {
+			fmt.Fprintf(cmd.OutOrStdout(), "\n")
+		}
+	}
+	return nil
+}
+
+// printProjectTree prints the files of the generated project as a tree, the directories first
+func printProjectTree(cmd *cobra.Command, zipReader *zip.Reader, projectName string) {
+	type entry struct {
+		name  string
+		isDir bool
+		size  int64
+		link  string
+	}
+	entries := map[string]entry{}
+	var totalFiles int
+	var totalSize int64
+	for _, file := range zipReader.File {
+		name := relativeName(file)
+		if name == "" {
+			continue
+		}
+		// the parent directories are not always entries of the zip
+		for dir := path.Dir(name); dir != "."; dir = path.Dir(dir) {
+			entries[dir] = entry{name: dir, isDir: true}
+		}
+		switch {
+		case file.FileInfo().IsDir():
+			entries[name] = entry{name: name, isDir: true}
+		case file.Mode()&os.ModeSymlink != 0:
+			link, _ := readZipEntry(file)
+			entries[name] = entry{name: name, link: string(link)}
+			totalFiles++
+		default:
+			entries[name] = entry{name: name, size: int64(file.UncompressedSize64)}
+			totalFiles++
+			totalSize += int64(file.UncompressedSize64)
+		}
+	}
+
+	names := []string{}
+	for name := range entries {
+		names = append(names, name)
+	}
+	// siblings are sorted with the directories first, every directory is followed by its content
+	sortKey := func(name string) string {
+		parts := strings.Split(name, "/")
+		for i := range parts {
+			if i < len(parts)-1 || entries[name].isDir {
+				parts[i] = "0" + parts[i]
+			} else {
+				parts[i] = "1" + parts[i]
+			}
+		}
+		return strings.Join(parts, "/")
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return sortKey(names[i]) < sortKey(names[j])
+	})
+
+	fmt.Fprintf(cmd.OutOrStdout(), "project %s (%d file(s), %s):\n", projectName, totalFiles, formatSize(totalSize))
+	for _, name := range names {
+		e := entries[name]
+		prefix := strings.Repeat("  ", strings.Count(name, "/")+1)
+		switch {
+		case e.isDir:
+			fmt.Fprintf(cmd.OutOrStdout(), "%s%s/\n", prefix, path.Base(name))
+		case e.link != "":
+			fmt.Fprintf(cmd.OutOrStdout(), "%s%s -> %s\n", prefix, path.Base(name), e.link)
+		default:
+			fmt.Fprintf(cmd.OutOrStdout(), "%s%s (%s)\n", prefix, path.Base(name), formatSize(e.size))
+		}
+	}
+}
+
+// printProjectDiff prints the files of the directory that the generated project would add, change or remove, with
+// the differences of the changed text files. The .git directory of the directory is ignored, and no file is removed
+// when keepExisting is set.
+func printProjectDiff(cmd *cobra.Command, zipReader *zip.Reader, directory string, keepExisting bool) error {
+	if fi, err := os.Stat(directory); err != nil || !fi.IsDir() {
+		return fmt.Errorf("cannot find directory %v", directory)
+	}
+	existing, err := snapshotDirectory(directory)
+	if err != nil {
+		return err
+	}
+	for name := range existing {
+		if name == ".git" || strings.HasPrefix(name, ".git/") {
+			delete(existing, name)
+		}
+	}
+	generated := map[string]*zip.File{}
+	for _, file := range zipReader.File {
+		if !file.FileInfo().IsDir() && file.Mode()&os.ModeSymlink == 0 {
+			generated[relativeName(file)] = file
+		}
+	}
+
+	names := []string{}
+	for name := range generated {
+		names = append(names, name)
+	}
+	for name := range existing {
+		if _, found := generated[name]; !found && !keepExisting {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	changes := 0
+	for _, name := range names {
+		file, isGenerated := generated[name]
+		checksum, isExisting := existing[name]
+		if !isGenerated {
+			fmt.Fprintf(cmd.OutOrStdout(), "- %s\n", name)
+			changes++
+			continue
+		}
+		content, err := readZipEntry(file)
+		if err != nil {
+			return err
+		}
+		if !isExisting {
+			fmt.Fprintf(cmd.OutOrStdout(), "+ %s\n", name)
+			changes++
+			continue
+		}
+		if fmt.Sprintf("%x", sha256.Sum256(content)) == checksum {
+			continue
+		}
+		changes++
+		fmt.Fprintf(cmd.OutOrStdout(), "~ %s\n", name)
+		existingContent, err := os.ReadFile(filepath.Join(directory, filepath.FromSlash(name)))
+		if err != nil {
+			return err
+		}
+		if isText(existingContent) && isText(content) {
+			diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+				A:        diffLines(existingContent),
+				B:        diffLines(content),
+				FromFile: "existing/" + name,
+				ToFile:   "generated/" + name,
+				Context:  3,
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "%s\n", indent(strings.TrimSuffix(diff, "\n"), "    "))
+		}
+	}
+	if changes == 0 {
+		fmt.Fprintf(cmd.OutOrStdout(), "no differences with %s\n", directory)
+	}
+	return nil
+}
+
+// matchesAny returns true if the name matches one of the patterns, ** matches any number of directories
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matchPath(strings.Split(pattern, "/"), strings.Split(name, "/")) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchPath(pattern []string, name []string) bool {
+	if len(pattern) == 0 {
+		return len(name) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(name); i++ {
+			if matchPath(pattern[1:], name[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(name) == 0 {
+		return false
+	}
+	if matched, _ := path.Match(pattern[0], name[0]); !matched {
+		return false
+	}
+	return matchPath(pattern[1:], name[1:])
+}
@@ -0,0 +1,170 @@
+package commands
+
+import (
+	"archive/zip"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("command run", func() {
+	Context("previewing the generated project", func() {
+		var workDir string
+		var ts *httptest.Server
+		var invoked int32
+
+		BeforeEach(func() {
+			var err error
+			workDir, err = os.MkdirTemp("", "preview")
+			Expect(err).NotTo(HaveOccurred())
+			atomic.StoreInt32(&invoked, 0)
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("/api/about", func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(200)
+				w.Write([]byte("{}"))
+			})
+			mux.HandleFunc("/api/accelerators", func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"_embedded":{"accelerators":[{"name":"java-rest","archiveUrl":"http://source-controller/java-rest/1234.tar.gz"}]}}`))
+			})
+			mux.HandleFunc("/api/accelerators/zip", func(w http.ResponseWriter, r *http.Request) {
+				zipWriter := zip.NewWriter(w)
+				zipWriter.Create("orders/")
+				for name, content := range map[string]string{
+					"orders/README.md":                     "# Orders\nGenerated\n",
+					"orders/pom.xml":                       "<project/>",
+					"orders/src/main/java/App.java":        "class App {}\n",
+					"orders/src/main/resources/logo.png":   "\x89PNG\x00\x00",
+					"orders/src/test/java/AppTest.java":    "class AppTest {}\n",
+					"orders/config/workload/workload.yaml": "kind: Workload\n",
+				} {
+					entry, _ := zipWriter.Create(name)
+					entry.Write([]byte(content))
+				}
+				zipWriter.Close()
+			})
+			mux.HandleFunc("/api/accelerators/invoked", func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&invoked, 1)
+				w.WriteHeader(200)
+			})
+			ts = httptest.NewServer(mux)
+		})
+
+		AfterEach(func() {
+			ts.Close()
+			os.Unsetenv("ACC_CACHE_DIR")
+			os.RemoveAll(workDir)
+		})
+
+		tree := "project orders (6 file(s), 80 B):\n" +
+			"  config/\n" +
+			"    workload/\n" +
+			"      workload.yaml (15 B)\n" +
+			"  src/\n" +
+			"    main/\n" +
+			"      java/\n" +
+			"        App.java (13 B)\n" +
+			"      resources/\n" +
+			"        logo.png (6 B)\n" +
+			"    test/\n" +
+			"      java/\n" +
+			"        AppTest.java (17 B)\n" +
+			"  README.md (19 B)\n" +
+			"  pom.xml (10 B)\n"
+
+		It("Should print the files generated from local files without writing them", func() {
+			cacheDir := filepath.Join(workDir, "cache")
+			os.Setenv("ACC_CACHE_DIR", cacheDir)
+			outputDir := filepath.Join(workDir, "generated")
+			generateCmd := LocalGenerateCmd()
+			b := new(bytes.Buffer)
+			generateCmd.SetOut(b)
+			generateCmd.SetErr(b)
+			generateCmd.SetArgs([]string{"--server-url", ts.URL, "--accelerator-name", "java-rest", "--options", `{"projectName":"orders"}`,
+				"--output-dir", outputDir, "--cache", "--preview", "--show", "README.md", "--show", "src/**/*.java", "--show", "**/*.png"})
+			Expect(generateCmd.Execute()).To(Succeed())
+			Expect(b.String()).To(Equal(tree +
+				"\n==> README.md <==\n# Orders\nGenerated\n" +
+				"\n==> src/main/java/App.java <==\nclass App {}\n" +
+				"\n==> src/main/resources/logo.png <==\n(binary file, 6 B)\n" +
+				"\n==> src/test/java/AppTest.java <==\nclass AppTest {}\n"))
+			Expect(outputDir).NotTo(BeAnExistingFile())
+			Expect(cacheDir).NotTo(BeAnExistingFile())
+		})
+
+		It("Should print the files generated from a registered accelerator without writing them", func() {
+			generateCmd := GenerateCmd()
+			b := new(bytes.Buffer)
+			generateCmd.SetOut(b)
+			generateCmd.SetErr(b)
+			generateCmd.SetArgs([]string{"java-rest", "--server-url", ts.URL, "--options", `{"projectName":"orders"}`, "--output-dir", workDir, "--preview"})
+			Expect(generateCmd.Execute()).To(Succeed())
+			Expect(b.String()).To(Equal(tree))
+			Expect(filepath.Join(workDir, "orders.zip")).NotTo(BeAnExistingFile())
+			Expect(atomic.LoadInt32(&invoked)).To(BeZero())
+		})
+
+		It("Should compare the generated project with an existing directory", func() {
+			existing := filepath.Join(workDir, "existing")
+			for name, content := range map[string]string{
+				"README.md":                     "# Orders\nOld\n",
+				"pom.xml":                       "<project/>",
+				"src/main/java/App.java":        "class App {}\n",
+				"src/main/resources/logo.png":   "\x89PNG\x00\x01",
+				"src/main/java/Removed.java":    "class Removed {}\n",
+				"config/workload/workload.yaml": "kind: Workload\n",
+				".git/HEAD":                     "ref: refs/heads/main\n",
+			} {
+				path := filepath.Join(existing, filepath.FromSlash(name))
+				Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+				Expect(os.WriteFile(path, []byte(content), 0644)).To(Succeed())
+			}
+			generateCmd := GenerateCmd()
+			b := new(bytes.Buffer)
+			generateCmd.SetOut(b)
+			generateCmd.SetErr(b)
+			generateCmd.SetArgs([]string{"java-rest", "--server-url", ts.URL, "--options", `{"projectName":"orders"}`, "--diff-against", existing})
+			Expect(generateCmd.Execute()).To(Succeed())
+			Expect(b.String()).To(Equal("~ README.md\n" +
+				"    --- existing/README.md\n" +
+				"    +++ generated/README.md\n" +
+				"    @@ -1,2 +1,2 @@\n" +
+				"     # Orders\n" +
+				"    -Old\n" +
+				"    +Generated\n" +
+				"- src/main/java/Removed.java\n" +
+				"~ src/main/resources/logo.png\n" +
+				"+ src/test/java/AppTest.java\n"))
+
+			Expect(os.Remove(filepath.Join(existing, "src", "main", "java", "Removed.java"))).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(existing, "README.md"), []byte("# Orders\nGenerated\n"), 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(existing, "src", "main", "resources", "logo.png"), []byte("\x89PNG\x00\x00"), 0644)).To(Succeed())
+			Expect(os.MkdirAll(filepath.Join(existing, "src", "test", "java"), 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(existing, "src", "test", "java", "AppTest.java"), []byte("class AppTest {}\n"), 0644)).To(Succeed())
+			b.Reset()
+			generateCmd = GenerateCmd()
+			generateCmd.SetOut(b)
+			generateCmd.SetErr(b)
+			generateCmd.SetArgs([]string{"java-rest", "--server-url", ts.URL, "--options", `{"projectName":"orders"}`, "--diff-against", existing})
+			Expect(generateCmd.Execute()).To(Succeed())
+			Expect(b.String()).To(Equal("no differences with " + existing + "\n"))
+		})
+
+		It("Should only show files with --preview", func() {
+			generateCmd := GenerateCmd()
+			b := new(bytes.Buffer)
+			generateCmd.SetOut(b)
+			generateCmd.SetErr(b)
+			generateCmd.SetArgs([]string{"java-rest", "--server-url", ts.URL, "--show", "README.md"})
+			err := generateCmd.Execute()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("--show can only be used with --preview"))
+		})
+	})
+})
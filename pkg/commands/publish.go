@@ -0,0 +1,262 @@
+/*
+Copyright 2021-2023 VMware, Inc. All Rights Reserved.
+*/
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	acceleratorv1alpha1 "github.com/pivotal/acc-controller/api/v1alpha1"
+	fluxcdv1beta1 "github.com/pivotal/acc-controller/fluxcd/api/v1beta2"
+	"github.com/spf13/cobra"
+	cli "github.com/vmware-tanzu/apps-cli-plugin/pkg/cli-runtime"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var (
+	// publishWaitInterval is the time between checks of the accelerator when waiting for it to be ready
+	publishWaitInterval = 2 * time.Second
+	// publishNow returns the time a reconcile is requested at
+	publishNow = time.Now
+)
+
+func PublishCmd(ctx context.Context, c *cli.Config) *cobra.Command {
+	opts := PublishOptions{}
+	requestedAtAnnotation := "reconcile.accelerator.apps.tanzu.vmware.com/requestedAt"
+	var publishCmd = &cobra.Command{
+		Use:   "publish",
+		Short: "Publish a local accelerator to a Git repository and register it",
+		Long: `Publish the files of a local accelerator directory to a Git repository and create or update the accelerator
+resource using it.
+
+The repository is cloned, the files in --local-path are copied to the --git-branch of the repository, or to the
+--git-sub-path directory of it, replacing the files that were published before, and the changes are committed and
+pushed. Files in .gitignore and the .git directory are not published. The branch is created when it doesn't exist.
+The git command is used to clone and push, so the credentials configured for git are used.
+
+When the accelerator exists it is updated to use the repository, and a reconcile is requested so that the published
+changes are picked up right away. Otherwise the accelerator is created. Use --wait to wait for the accelerator to be
+ready.
+`,
+		Example: `tanzu accelerator publish <accelerator-name> --local-path . --git-repository https://github.com/example/accelerators --git-sub-path my-accelerator
+tanzu accelerator publish <accelerator-name> --local-path . --git-repository git@github.com:example/my-accelerator.git --secret-ref git-credentials --wait`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 1 {
+				return errors.New("you must specify the name of the accelerator")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+			if info, err := os.Stat(opts.LocalPath); err != nil || !info.IsDir() {
+				return fmt.Errorf("cannot find directory %v", opts.LocalPath)
+			}
+			subPath := filepath.ToSlash(filepath.Clean(opts.GitSubPath))
+			if subPath == "." {
+				subPath = ""
+			}
+			if filepath.IsAbs(opts.GitSubPath) || subPath == ".." || strings.HasPrefix(subPath, "../") {
+				return fmt.Errorf("invalid Git sub path %q, must be a path inside the repository", opts.GitSubPath)
+			}
+			var interval *v1.Duration
+			if opts.Interval != "" {
+				duration, err := time.ParseDuration(opts.Interval)
+				if err != nil {
+					return fmt.Errorf("invalid interval %q: %v", opts.Interval, err)
+				}
+				interval = &v1.Duration{Duration: duration}
+			}
+
+			message := opts.Message
+			if message == "" {
+				message = fmt.Sprintf("Publish accelerator %s", name)
+			}
+			commit, err := publishToGit(ctx, opts.LocalPath, opts.GitRepoUrl, opts.GitBranch, subPath, message)
+			if err != nil {
+				return err
+			}
+			if commit == "" {
+				fmt.Fprintf(cmd.OutOrStdout(), "no changes to publish to %s branch %s\n", opts.GitRepoUrl, opts.GitBranch)
+			} else {
+				fmt.Fprintf(cmd.OutOrStdout(), "published %s to %s branch %s (commit %s)\n", opts.LocalPath, opts.GitRepoUrl, opts.GitBranch, commit)
+			}
+
+			// the accelerator uses the published files, replacing the source it used before
+			setGit := func(accelerator *acceleratorv1alpha1.Accelerator) {
+				git := &acceleratorv1alpha1.Git{
+					URL: opts.GitRepoUrl,
+					Reference: &fluxcdv1beta1.GitRepositoryRef{
+						Branch: opts.GitBranch,
+					},
+					Interval: interval,
+				}
+				if subPath != "" {
+					git.SubPath = &subPath
+				}
+				if opts.SecretRef != "" {
+					git.SecretRef = &meta.LocalObjectReference{Name: opts.SecretRef}
+				} else if accelerator.Spec.Git != nil && accelerator.Spec.Git.URL == opts.GitRepoUrl {
+					git.SecretRef = accelerator.Spec.Git.SecretRef
+				}
+				if interval == nil && accelerator.Spec.Git != nil {
+					git.Interval = accelerator.Spec.Git.Interval
+				}
+				accelerator.Spec.Git = git
+				accelerator.Spec.Source = nil
+				if opts.DisplayName != "" {
+					accelerator.Spec.DisplayName = opts.DisplayName
+				}
+				if opts.Description != "" {
+					accelerator.Spec.Description = opts.Description
+				}
+				if len(opts.Tags) > 0 {
+					accelerator.Spec.Tags = opts.Tags
+				}
+			}
+
+			var accelerator *acceleratorv1alpha1.Accelerator
+			key := client.ObjectKey{Namespace: opts.Namespace, Name: name}
+			newAccelerator := func() client.Object {
+				accelerator = &acceleratorv1alpha1.Accelerator{}
+				return accelerator
+			}
+			err = updateWithRetry(ctx, c, "accelerator", key, newAccelerator, func() error {
+				setGit(accelerator)
+				if accelerator.ObjectMeta.Annotations == nil {
+					accelerator.ObjectMeta.Annotations = make(map[string]string)
+				}
+				accelerator.ObjectMeta.Annotations[requestedAtAnnotation] = publishNow().UTC().Format(time.RFC3339)
+				return nil
+			})
+			if k8serrors.IsNotFound(err) {
+				accelerator = &acceleratorv1alpha1.Accelerator{
+					ObjectMeta: v1.ObjectMeta{
+						Namespace: opts.Namespace,
+						Name:      name,
+					},
+				}
+				setGit(accelerator)
+				if err := c.Create(ctx, accelerator); err != nil {
+					fmt.Fprintf(cmd.OutOrStderr(), "Error creating accelerator %s\n", name)
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "created accelerator %s in namespace %s\n", name, opts.Namespace)
+			} else if err != nil {
+				fmt.Fprintf(cmd.OutOrStderr(), "there was an error updating accelerator %s\n", name)
+				return err
+			} else {
+				fmt.Fprintf(cmd.OutOrStdout(), "accelerator %s updated successfully\n", name)
+			}
+
+			if opts.Wait {
+				fmt.Fprintf(cmd.OutOrStdout(), "waiting for accelerator %s to be ready...\n", name)
+				if err := waitForAcceleratorReady(ctx, c, key, opts.WaitTimeout); err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "accelerator %s is ready\n", name)
+			}
+			return nil
+		},
+	}
+	opts.DefineFlags(ctx, publishCmd, c)
+	return publishCmd
+}
+
+// publishToGit replaces the files in the sub path of the branch with the files in the local path and pushes the
+// changes, returning the short hash of the new commit or an empty string when there was nothing to commit
+func publishToGit(ctx context.Context, localPath string, url string, branch string, subPath string, message string) (string, error) {
+	workDir, err := os.MkdirTemp("", "accelerator-publish")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(workDir)
+
+	if _, err := runGit(ctx, workDir, "clone", "--quiet", url, "."); err != nil {
+		return "", err
+	}
+	if _, err := runGit(ctx, workDir, "rev-parse", "--verify", "--quiet", "refs/remotes/origin/"+branch); err == nil {
+		if _, err := runGit(ctx, workDir, "checkout", "--quiet", "-B", branch, "origin/"+branch); err != nil {
+			return "", err
+		}
+	} else if _, err := runGit(ctx, workDir, "checkout", "--quiet", "-b", branch); err != nil {
+		// the new branch starts from the default branch, or is the first branch of an empty repository
+		return "", err
+	}
+
+	target := filepath.Join(workDir, filepath.FromSlash(subPath))
+	if subPath == "" {
+		entries, err := os.ReadDir(workDir)
+		if err != nil {
+			return "", err
+		}
+		for _, entry := range entries {
+			if entry.Name() != ".git" {
+				if err := os.RemoveAll(filepath.Join(workDir, entry.Name())); err != nil {
+					return "", err
+				}
+			}
+		}
+	} else if err := os.RemoveAll(target); err != nil {
+		return "", err
+	}
+	err = walkSourceDir(localPath, packageOptions{}, func(file string, name string, fi os.FileInfo) error {
+		return copySourceFile(file, filepath.Join(target, filepath.FromSlash(name)), fi)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := runGit(ctx, workDir, "add", "--all"); err != nil {
+		return "", err
+	}
+	if _, err := runGit(ctx, workDir, "diff", "--cached", "--quiet"); err == nil {
+		return "", nil
+	}
+	if _, err := runGit(ctx, workDir, "commit", "--quiet", "--message", message); err != nil {
+		return "", err
+	}
+	if _, err := runGit(ctx, workDir, "push", "--quiet", "origin", branch); err != nil {
+		return "", err
+	}
+	return runGit(ctx, workDir, "rev-parse", "--short", "HEAD")
+}
+
+// waitForAcceleratorReady polls the accelerator until its Ready condition is true for the latest generation
+func waitForAcceleratorReady(ctx context.Context, c *cli.Config, key client.ObjectKey, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		accelerator := &acceleratorv1alpha1.Accelerator{}
+		if err := c.Get(ctx, key, accelerator); err != nil {
+			return err
+		}
+		message := "the accelerator has not been reconciled yet"
+		if accelerator.Status.ObservedGeneration >= accelerator.Generation {
+			for _, condition := range accelerator.Status.Conditions {
+				if condition.Type != "Ready" {
+					continue
+				}
+				if condition.Status == v1.ConditionTrue {
+					return nil
+				}
+				message = condition.Message
+			}
+		}
+		if time.Now().Add(publishWaitInterval).After(deadline) {
+			return fmt.Errorf("timed out waiting for accelerator %s to be ready: %s", key.Name, message)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(publishWaitInterval):
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package commands
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	acceleratorv1alpha1 "github.com/pivotal/acc-controller/api/v1alpha1"
+	"github.com/pivotal/acc-controller/fluxcd/api/v1beta2"
+	"github.com/pivotal/acc-controller/sourcecontroller/api/v1alpha1"
+	cli "github.com/vmware-tanzu/apps-cli-plugin/pkg/cli-runtime"
+	clitesting "github.com/vmware-tanzu/apps-cli-plugin/pkg/cli-runtime/testing"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// newBareRepository creates a bare Git repository to publish to, with a commit on the main branch when files are
+// provided, and configures the identity used for the commits
+func newBareRepository(t *testing.T, files map[string]string) string {
+	t.Setenv("GIT_AUTHOR_NAME", "Test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+	repository := filepath.Join(t.TempDir(), "repository.git")
+	git := func(dir string, args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v: %s", args, err, out)
+		}
+	}
+	git(t.TempDir(), "init", "--quiet", "--bare", "--initial-branch", "main", repository)
+	if len(files) > 0 {
+		workDir := t.TempDir()
+		git(workDir, "clone", "--quiet", repository, ".")
+		for name, content := range files {
+			path := filepath.Join(workDir, filepath.FromSlash(name))
+			os.MkdirAll(filepath.Dir(path), 0755)
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		git(workDir, "add", "--all")
+		git(workDir, "commit", "--quiet", "--message", "initial commit")
+		git(workDir, "push", "--quiet", "origin", "HEAD:main")
+	}
+	return repository
+}
+
+// showFile returns the content of the file in the branch of the repository, or an empty string when it doesn't exist
+func showFile(t *testing.T, repository string, branch string, name string) string {
+	out, err := exec.Command("git", "--git-dir", repository, "show", branch+":"+name).Output()
+	if err != nil {
+		return ""
+	}
+	return string(out)
+}
+
+func TestPublishCommand(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = acceleratorv1alpha1.AddToScheme(scheme)
+
+	acceleratorName := "test-accelerator"
+	namespace := "accelerator-system"
+	localPath := "testdata/test-acc"
+	subPath := "accelerators/test"
+	requestedAt := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+	defer func(now func() time.Time, interval time.Duration) {
+		publishNow = now
+		publishWaitInterval = interval
+	}(publishNow, publishWaitInterval)
+	publishNow = func() time.Time { return requestedAt }
+	publishWaitInterval = 10 * time.Millisecond
+
+	emptyRepository := newBareRepository(t, nil)
+	repository := newBareRepository(t, map[string]string{
+		"README.md":                          "other files in the repository\n",
+		"accelerators/test/accelerator.yaml": "outdated\n",
+		"accelerators/test/removed.txt":      "removed\n",
+	})
+	accelerator := func(repository string, subPath string) *acceleratorv1alpha1.Accelerator {
+		acc := &acceleratorv1alpha1.Accelerator{
+			ObjectMeta: v1.ObjectMeta{
+				Namespace: namespace,
+				Name:      acceleratorName,
+			},
+			Spec: acceleratorv1alpha1.AcceleratorSpec{
+				Git: &acceleratorv1alpha1.Git{
+					URL: repository,
+					Reference: &v1beta2.GitRepositoryRef{
+						Branch: "main",
+					},
+				},
+			},
+		}
+		if subPath != "" {
+			acc.Spec.Git.SubPath = &subPath
+		}
+		return acc
+	}
+	reconciled := func(acc *acceleratorv1alpha1.Accelerator) *acceleratorv1alpha1.Accelerator {
+		acc.Annotations = map[string]string{"reconcile.accelerator.apps.tanzu.vmware.com/requestedAt": "2023-05-01T12:00:00Z"}
+		return acc
+	}
+	ready := func(acc *acceleratorv1alpha1.Accelerator, status v1.ConditionStatus) *acceleratorv1alpha1.Accelerator {
+		acc.Status.Conditions = []v1.Condition{{Type: "Ready", Status: status, Message: "failed to checkout repository"}}
+		return acc
+	}
+	imageAccelerator := accelerator(repository, "")
+	imageAccelerator.Spec.Git = nil
+	imageAccelerator.Spec.Source = &v1alpha1.ImageRepositorySpec{Image: "test-image"}
+	imageAccelerator.Spec.Description = "Lorem Ipsum"
+	secretAccelerator := accelerator(repository, subPath)
+	secretAccelerator.Spec.Git.SecretRef = &meta.LocalObjectReference{Name: "git-credentials"}
+
+	table := clitesting.CommandTestSuite{
+		{
+			Name:        "Missing args",
+			Args:        []string{"--git-repository", repository},
+			ShouldError: true,
+		},
+		{
+			Name:        "Missing Git repository",
+			Args:        []string{acceleratorName, "--local-path", localPath},
+			ShouldError: true,
+		},
+		{
+			Name:        "Error missing local path",
+			Args:        []string{acceleratorName, "--local-path", "testdata/missing", "--git-repository", repository},
+			ShouldError: true,
+		},
+		{
+			Name:        "Error invalid sub path",
+			Args:        []string{acceleratorName, "--local-path", localPath, "--git-repository", repository, "--git-sub-path", "../outside"},
+			ShouldError: true,
+		},
+		{
+			Name:        "Error invalid interval",
+			Args:        []string{acceleratorName, "--local-path", localPath, "--git-repository", repository, "--interval", "often"},
+			ShouldError: true,
+		},
+		{
+			Name:        "Error publishing to missing repository",
+			Args:        []string{acceleratorName, "--local-path", localPath, "--git-repository", filepath.Join(t.TempDir(), "missing.git")},
+			ShouldError: true,
+			Verify: func(t *testing.T, output string, err error) {
+				if err == nil || !strings.HasPrefix(err.Error(), "git clone failed") {
+					t.Errorf("expected git clone error, got %v", err)
+				}
+			},
+		},
+		{
+			Name:          "Publish to empty repository and create accelerator",
+			Args:          []string{acceleratorName, "--local-path", localPath, "--git-repository", emptyRepository},
+			ExpectCreates: []client.Object{accelerator(emptyRepository, "")},
+			Verify: func(t *testing.T, output string, err error) {
+				for _, expected := range []string{
+					"published testdata/test-acc to " + emptyRepository + " branch main (commit ",
+					"created accelerator test-accelerator in namespace accelerator-system\n",
+				} {
+					if !strings.Contains(output, expected) {
+						t.Errorf("expected output to contain %q, got %q", expected, output)
+					}
+				}
+				if content := showFile(t, emptyRepository, "main", "accelerator.yaml"); !strings.Contains(content, "displayName: Test") {
+					t.Errorf("expected accelerator.yaml to be published, got %q", content)
+				}
+			},
+		},
+		{
+			Name:         "Publish to sub path and update accelerator",
+			Args:         []string{acceleratorName, "--local-path", localPath, "--git-repository", repository, "--git-sub-path", subPath, "--message", "Update test accelerator"},
+			GivenObjects: []client.Object{imageAccelerator},
+			ExpectUpdates: []client.Object{
+				func() client.Object {
+					acc := reconciled(accelerator(repository, subPath))
+					acc.Spec.Description = "Lorem Ipsum"
+					return acc
+				}(),
+			},
+			Verify: func(t *testing.T, output string, err error) {
+				if !strings.Contains(output, "accelerator test-accelerator updated successfully\n") {
+					t.Errorf("expected accelerator to be updated, got %q", output)
+				}
+				if content := showFile(t, repository, "main", "accelerators/test/accelerator.yaml"); !strings.Contains(content, "displayName: Test") {
+					t.Errorf("expected accelerator.yaml to be replaced, got %q", content)
+				}
+				if content := showFile(t, repository, "main", "accelerators/test/removed.txt"); content != "" {
+					t.Errorf("expected removed.txt to be removed, got %q", content)
+				}
+				if content := showFile(t, repository, "main", "README.md"); content != "other files in the repository\n" {
+					t.Errorf("expected files outside of the sub path to be kept, got %q", content)
+				}
+				if err := exec.Command("git", "--git-dir", repository, "cat-file", "-e", "main:accelerators/test/inner/foo.txt").Run(); err != nil {
+					t.Errorf("expected nested files to be published")
+				}
+				out, _ := exec.Command("git", "--git-dir", repository, "log", "-1", "--format=%s", "main").Output()
+				if string(out) != "Update test accelerator\n" {
+					t.Errorf("expected commit message %q, got %q", "Update test accelerator", out)
+				}
+			},
+		},
+		{
+			Name:          "Publish unchanged files to existing accelerator keeps secret",
+			Args:          []string{acceleratorName, "--local-path", localPath, "--git-repository", repository, "--git-sub-path", subPath},
+			GivenObjects:  []client.Object{secretAccelerator},
+			ExpectUpdates: []client.Object{reconciled(secretAccelerator.DeepCopy())},
+			ExpectOutput: `
+no changes to publish to ` + repository + ` branch main
+accelerator test-accelerator updated successfully
+`,
+		},
+		{
+			Name:          "Publish to new branch and wait for ready accelerator",
+			Args:          []string{acceleratorName, "--local-path", localPath, "--git-repository", repository, "--git-branch", "release", "--wait"},
+			GivenObjects:  []client.Object{ready(accelerator(repository, ""), v1.ConditionTrue)},
+			ExpectUpdates: []client.Object{reconciled(ready(accelerator(repository, ""), v1.ConditionTrue))},
+			Prepare: func(t *testing.T, ctx context.Context, config *cli.Config, tc *clitesting.CommandTestCase) (context.Context, error) {
+				// the expected accelerator uses the new branch
+				tc.ExpectUpdates[0].(*acceleratorv1alpha1.Accelerator).Spec.Git.Reference.Branch = "release"
+				return ctx, nil
+			},
+			Verify: func(t *testing.T, output string, err error) {
+				if !strings.HasSuffix(output, "waiting for accelerator test-accelerator to be ready...\naccelerator test-accelerator is ready\n") {
+					t.Errorf("expected accelerator to be ready, got %q", output)
+				}
+				if content := showFile(t, repository, "release", "accelerator.yaml"); !strings.Contains(content, "displayName: Test") {
+					t.Errorf("expected accelerator.yaml to be published to the release branch, got %q", content)
+				}
+				if content := showFile(t, repository, "release", "README.md"); content != "" {
+					t.Errorf("expected the branch to only contain the accelerator, got README.md %q", content)
+				}
+			},
+		},
+		{
+			Name:          "Error waiting for accelerator that is not ready",
+			Args:          []string{acceleratorName, "--local-path", localPath, "--git-repository", repository, "--git-branch", "release", "--wait", "--wait-timeout", "50ms"},
+			GivenObjects:  []client.Object{ready(accelerator(repository, ""), v1.ConditionFalse)},
+			ExpectUpdates: []client.Object{reconciled(ready(accelerator(repository, ""), v1.ConditionFalse))},
+			Prepare: func(t *testing.T, ctx context.Context, config *cli.Config, tc *clitesting.CommandTestCase) (context.Context, error) {
+				tc.ExpectUpdates[0].(*acceleratorv1alpha1.Accelerator).Spec.Git.Reference.Branch = "release"
+				return ctx, nil
+			},
+			ShouldError: true,
+			Verify: func(t *testing.T, output string, err error) {
+				if err == nil || err.Error() != "timed out waiting for accelerator test-accelerator to be ready: failed to checkout repository" {
+					t.Errorf("expected timeout error, got %v", err)
+				}
+			},
+		},
+	}
+	table.Run(t, scheme, PublishCmd)
+}
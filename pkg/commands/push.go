@@ -15,7 +15,7 @@ func PushCmd(ctx context.Context, c *cli.Config) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:     "push",
 		Short:   "(DEPRECTAED) Push local path to source image",
-		Long:    "(DEPRECATED) Push source code from local path to source image used by an accelerator",
+		Long:    "(DEPRECATED) Push source code from local path to source image used by an accelerator. Use publish to publish a local accelerator to a Git repository instead.",
 		Example: "tanzu accelerator push --local-path <local path> --source-image <image>",
 		Args: func(cmd *cobra.Command, args []string) error {
 			return nil
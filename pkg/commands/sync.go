@@ -0,0 +1,273 @@
+/*
+Copyright 2021-2023 VMware, Inc. All Rights Reserved.
+*/
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	acceleratorv1alpha1 "github.com/pivotal/acc-controller/api/v1alpha1"
+	"github.com/spf13/cobra"
+	cli "github.com/vmware-tanzu/apps-cli-plugin/pkg/cli-runtime"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	syncAdded   = "+"
+	syncChanged = "~"
+	syncRemoved = "-"
+)
+
+type syncChange struct {
+	Action string
+	Kind   string
+	Name   string
+	// Desired is the state of the resource in the target after the sync, nil for resources that are removed
+	Desired client.Object
+	Target  client.Object
+}
+
+// newTargetClient returns the client for the target of the sync, the client of the config is used when no context is
+// specified
+var newTargetClient = func(c *cli.Config, context string) cli.Client {
+	if context == "" {
+		return c.Client
+	}
+	return cli.NewClient(c.KubeConfigFile, context, c.Scheme)
+}
+
+func SyncCmd(ctx context.Context, c *cli.Config) *cobra.Command {
+	opts := SyncOptions{}
+	var syncCmd = &cobra.Command{
+		Use:   "sync",
+		Short: "Sync accelerators and fragments to another context or namespace",
+		Long: `Sync the accelerator and accelerator fragment resources from a namespace in the current context to a target
+context and/or namespace.
+
+The accelerators and accelerator fragments in the source and the target are compared, and the plan is shown with
+the resources that will be added (+), changed (~) and removed (-) in the target. The target is then updated to
+match the source. Resources that only exist in the target are only removed when --prune is used, after asking for
+confirmation unless --yes is provided. Use --dry-run to only show the plan.
+
+The spec, labels and annotations of the resources are synced, except for the last-applied-configuration annotation
+of kubectl and the requestedAt annotation set by --reconcile, which are specific to each cluster. Use --selector to
+only sync the resources matching a label selector, this applies to both the source and the target.
+`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if opts.TargetContext == "" && (opts.TargetNamespace == "" || opts.TargetNamespace == opts.Namespace) {
+				return errors.New("the source and target are the same, you must specify a different --target-context or --target-namespace")
+			}
+			return nil
+		},
+		Example: `tanzu accelerator sync --target-context production
+tanzu accelerator sync --namespace accelerators-staging --target-namespace accelerators --prune --dry-run`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			targetNamespace := opts.TargetNamespace
+			if targetNamespace == "" {
+				targetNamespace = opts.Namespace
+			}
+			target := fmt.Sprintf("namespace %s", targetNamespace)
+			if opts.TargetContext != "" {
+				target = fmt.Sprintf("namespace %s in context %s", targetNamespace, opts.TargetContext)
+			}
+			targetConfig := *c
+			targetConfig.Client = newTargetClient(c, opts.TargetContext)
+
+			sourceFragments, sourceAccelerators, err := listSyncResources(ctx, c, opts.Namespace, opts.Selector)
+			if err != nil {
+				fmt.Fprintf(cmd.OutOrStderr(), "There was an error listing resources in namespace %s\n", opts.Namespace)
+				return err
+			}
+			targetFragments, targetAccelerators, err := listSyncResources(ctx, &targetConfig, targetNamespace, opts.Selector)
+			if err != nil {
+				fmt.Fprintf(cmd.OutOrStderr(), "There was an error listing resources in %s\n", target)
+				return err
+			}
+
+			fragmentChanges := planSync("accelerator fragment", sourceFragments, targetFragments, targetNamespace)
+			acceleratorChanges := planSync("accelerator", sourceAccelerators, targetAccelerators, targetNamespace)
+			// fragments are synced first since accelerators can import them, and removed last for the same reason
+			changes := []syncChange{}
+			removals := []syncChange{}
+			for _, change := range append(fragmentChanges, acceleratorChanges...) {
+				if change.Action != syncRemoved {
+					changes = append(changes, change)
+				}
+			}
+			for _, change := range append(acceleratorChanges, fragmentChanges...) {
+				if change.Action == syncRemoved {
+					removals = append(removals, change)
+				}
+			}
+			if !opts.Prune {
+				if len(removals) > 0 {
+					fmt.Fprintf(cmd.OutOrStdout(), "%d resource(s) in %s don't exist in namespace %s, use --prune to remove them\n", len(removals), target, opts.Namespace)
+				}
+				removals = nil
+			}
+			changes = append(changes, removals...)
+
+			if len(changes) == 0 {
+				c.Infof("No changes, %s is in sync with namespace %s\n", target, opts.Namespace)
+				return nil
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Syncing namespace %s to %s:\n", opts.Namespace, target)
+			for _, change := range changes {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s %s %s\n", change.Action, change.Kind, change.Name)
+			}
+			if opts.DryRun {
+				return nil
+			}
+
+			if len(removals) > 0 && !opts.Yes {
+				okToPrune := false
+				err = cli.NewConfirmSurvey(c, "Really remove %d resource(s) from %s?", len(removals), target).Resolve(&okToPrune)
+				if err != nil || !okToPrune {
+					c.Infof("Skipping sync to %s\n", target)
+					return nil
+				}
+			}
+
+			for _, change := range changes {
+				err = applySyncChange(ctx, &targetConfig, change)
+				if err != nil {
+					fmt.Fprintf(cmd.OutOrStderr(), "There was an error syncing %s %s to %s\n", change.Kind, change.Name, target)
+					return err
+				}
+				switch change.Action {
+				case syncAdded:
+					fmt.Fprintf(cmd.OutOrStdout(), "created %s %s in namespace %s\n", change.Kind, change.Name, targetNamespace)
+				case syncChanged:
+					fmt.Fprintf(cmd.OutOrStdout(), "updated %s %s in namespace %s\n", change.Kind, change.Name, targetNamespace)
+				case syncRemoved:
+					fmt.Fprintf(cmd.OutOrStdout(), "deleted %s %s in namespace %s\n", change.Kind, change.Name, targetNamespace)
+				}
+			}
+			return nil
+		},
+	}
+	opts.DefineFlags(ctx, syncCmd, c)
+	return syncCmd
+}
+
+func listSyncResources(ctx context.Context, c *cli.Config, namespace string, selector string) ([]client.Object, []client.Object, error) {
+	listOpts, err := selectorListOptions(namespace, selector)
+	if err != nil {
+		return nil, nil, err
+	}
+	fragmentList := &acceleratorv1alpha1.FragmentList{}
+	err = c.List(ctx, fragmentList, listOpts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	fragments := []client.Object{}
+	for i := range fragmentList.Items {
+		fragments = append(fragments, &fragmentList.Items[i])
+	}
+	acceleratorList := &acceleratorv1alpha1.AcceleratorList{}
+	err = c.List(ctx, acceleratorList, listOpts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	accelerators := []client.Object{}
+	for i := range acceleratorList.Items {
+		accelerators = append(accelerators, &acceleratorList.Items[i])
+	}
+	return fragments, accelerators, nil
+}
+
+// planSync compares the source and target resources and returns the changes needed for the target to match the source
+func planSync(kind string, source []client.Object, target []client.Object, targetNamespace string) []syncChange {
+	targetByName := map[string]client.Object{}
+	for _, obj := range target {
+		targetByName[obj.GetName()] = obj
+	}
+	sourceNames := map[string]bool{}
+	changes := []syncChange{}
+	for _, obj := range source {
+		sourceNames[obj.GetName()] = true
+		desired := syncedState(obj, targetNamespace)
+		existing, found := targetByName[obj.GetName()]
+		if !found {
+			changes = append(changes, syncChange{Action: syncAdded, Kind: kind, Name: obj.GetName(), Desired: desired})
+		} else if !equality.Semantic.DeepEqual(desired, syncedState(existing, targetNamespace)) {
+			changes = append(changes, syncChange{Action: syncChanged, Kind: kind, Name: obj.GetName(), Desired: desired, Target: existing})
+		}
+	}
+	for _, obj := range target {
+		if !sourceNames[obj.GetName()] {
+			changes = append(changes, syncChange{Action: syncRemoved, Kind: kind, Name: obj.GetName(), Target: obj})
+		}
+	}
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].Name < changes[j].Name
+	})
+	return changes
+}
+
+// syncedState returns a copy of the resource in the namespace with only the fields that are synced, the annotations
+// that are specific to the cluster, like the last-applied-configuration of kubectl, are not synced
+func syncedState(obj client.Object, namespace string) client.Object {
+	meta := metav1.ObjectMeta{
+		Name:        obj.GetName(),
+		Namespace:   namespace,
+		Labels:      obj.GetLabels(),
+		Annotations: withoutClusterAnnotations(obj.GetAnnotations()),
+	}
+	switch resource := obj.(type) {
+	case *acceleratorv1alpha1.Accelerator:
+		return &acceleratorv1alpha1.Accelerator{ObjectMeta: meta, Spec: resource.DeepCopy().Spec}
+	case *acceleratorv1alpha1.Fragment:
+		return &acceleratorv1alpha1.Fragment{ObjectMeta: meta, Spec: resource.DeepCopy().Spec}
+	}
+	return nil
+}
+
+func applySyncChange(ctx context.Context, c *cli.Config, change syncChange) error {
+	switch change.Action {
+	case syncAdded:
+		return c.Create(ctx, change.Desired)
+	case syncRemoved:
+		return c.Delete(ctx, change.Target)
+	}
+
+	var current client.Object
+	newObj := func() client.Object {
+		switch change.Desired.(type) {
+		case *acceleratorv1alpha1.Accelerator:
+			current = &acceleratorv1alpha1.Accelerator{}
+		case *acceleratorv1alpha1.Fragment:
+			current = &acceleratorv1alpha1.Fragment{}
+		}
+		return current
+	}
+	return updateWithRetry(ctx, c, change.Kind, client.ObjectKeyFromObject(change.Desired), newObj, func() error {
+		// the annotations that are specific to the cluster are kept in the target
+		annotations := map[string]string{}
+		for name, value := range change.Desired.GetAnnotations() {
+			annotations[name] = value
+		}
+		for name, value := range current.GetAnnotations() {
+			if containsString(exportedAnnotationsToRemove, name) {
+				annotations[name] = value
+			}
+		}
+		if len(annotations) == 0 {
+			annotations = nil
+		}
+		current.SetLabels(change.Desired.GetLabels())
+		current.SetAnnotations(annotations)
+		switch resource := current.(type) {
+		case *acceleratorv1alpha1.Accelerator:
+			resource.Spec = change.Desired.(*acceleratorv1alpha1.Accelerator).DeepCopy().Spec
+		case *acceleratorv1alpha1.Fragment:
+			resource.Spec = change.Desired.(*acceleratorv1alpha1.Fragment).DeepCopy().Spec
+		}
+		return nil
+	})
+}
@@ -0,0 +1,222 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	acceleratorv1alpha1 "github.com/pivotal/acc-controller/api/v1alpha1"
+	"github.com/pivotal/acc-controller/fluxcd/api/v1beta2"
+	rtesting "github.com/vmware-labs/reconciler-runtime/testing"
+	clitesting "github.com/vmware-tanzu/apps-cli-plugin/pkg/cli-runtime/testing"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestSyncCommand(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = acceleratorv1alpha1.AddToScheme(scheme)
+
+	source := "accelerators-staging"
+	target := "accelerator-system"
+
+	newAccelerator := func(namespace string, name string, description string) *acceleratorv1alpha1.Accelerator {
+		return &acceleratorv1alpha1.Accelerator{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: namespace,
+				Name:      name,
+			},
+			Spec: acceleratorv1alpha1.AcceleratorSpec{
+				Description: description,
+				Git: &acceleratorv1alpha1.Git{
+					URL: "https://www.test.com",
+					Reference: &v1beta2.GitRepositoryRef{
+						Branch: "main",
+					},
+				},
+			},
+		}
+	}
+	newFragment := func(namespace string, name string) *acceleratorv1alpha1.Fragment {
+		return &acceleratorv1alpha1.Fragment{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: namespace,
+				Name:      name,
+			},
+			Spec: acceleratorv1alpha1.FragmentSpec{
+				Git: &acceleratorv1alpha1.Git{
+					URL: "https://www.test.com",
+					Reference: &v1beta2.GitRepositoryRef{
+						Branch: "main",
+					},
+				},
+			},
+		}
+	}
+
+	withAnnotations := func(accelerator *acceleratorv1alpha1.Accelerator, annotations map[string]string) *acceleratorv1alpha1.Accelerator {
+		accelerator.Annotations = annotations
+		return accelerator
+	}
+
+	table := clitesting.CommandTestSuite{
+		{
+			Name:        "Error same source and target",
+			Args:        []string{"--namespace", source, "--target-namespace", source},
+			ShouldError: true,
+		},
+		{
+			Name: "Nothing to sync",
+			Args: []string{"--namespace", source, "--target-namespace", target},
+			GivenObjects: []client.Object{
+				newAccelerator(source, "first", "Lorem Ipsum"),
+				newAccelerator(target, "first", "Lorem Ipsum"),
+			},
+			ExpectOutput: `
+No changes, namespace accelerator-system is in sync with namespace accelerators-staging
+`,
+		},
+		{
+			Name: "Nothing to sync when only the cluster specific annotations differ",
+			Args: []string{"--namespace", source, "--target-namespace", target},
+			GivenObjects: []client.Object{
+				withAnnotations(newAccelerator(source, "first", "Lorem Ipsum"), map[string]string{
+					"kubectl.kubernetes.io/last-applied-configuration":        `{"metadata":{"namespace":"accelerators-staging"}}`,
+					"reconcile.accelerator.apps.tanzu.vmware.com/requestedAt": "2023-03-01T10:00:00Z",
+				}),
+				withAnnotations(newAccelerator(target, "first", "Lorem Ipsum"), map[string]string{
+					"kubectl.kubernetes.io/last-applied-configuration": `{"metadata":{"namespace":"accelerator-system"}}`,
+				}),
+			},
+			ExpectOutput: `
+No changes, namespace accelerator-system is in sync with namespace accelerators-staging
+`,
+		},
+		{
+			Name: "Sync keeps the cluster specific annotations of the target",
+			Args: []string{"--namespace", source, "--target-namespace", target},
+			GivenObjects: []client.Object{
+				withAnnotations(newAccelerator(source, "first", "new description"), map[string]string{
+					"owner": "alpha-team@example.com",
+					"kubectl.kubernetes.io/last-applied-configuration":        `{"metadata":{"namespace":"accelerators-staging"}}`,
+					"reconcile.accelerator.apps.tanzu.vmware.com/requestedAt": "2023-03-01T10:00:00Z",
+				}),
+				withAnnotations(newAccelerator(source, "second", "Lorem Ipsum"), map[string]string{
+					"kubectl.kubernetes.io/last-applied-configuration": `{"metadata":{"namespace":"accelerators-staging"}}`,
+				}),
+				withAnnotations(newAccelerator(target, "first", "old description"), map[string]string{
+					"kubectl.kubernetes.io/last-applied-configuration": `{"metadata":{"namespace":"accelerator-system"}}`,
+				}),
+			},
+			ExpectCreates: []client.Object{
+				newAccelerator(target, "second", "Lorem Ipsum"),
+			},
+			ExpectUpdates: []client.Object{
+				withAnnotations(newAccelerator(target, "first", "new description"), map[string]string{
+					"owner": "alpha-team@example.com",
+					"kubectl.kubernetes.io/last-applied-configuration": `{"metadata":{"namespace":"accelerator-system"}}`,
+				}),
+			},
+			ExpectOutput: `
+Syncing namespace accelerators-staging to namespace accelerator-system:
+~ accelerator first
++ accelerator second
+updated accelerator first in namespace accelerator-system
+created accelerator second in namespace accelerator-system
+`,
+		},
+		{
+			Name: "Error listing resources in source",
+			Args: []string{"--namespace", source, "--target-namespace", target},
+			WithReactors: []clitesting.ReactionFunc{
+				clitesting.InduceFailure("list", "FragmentList"),
+			},
+			ShouldError:  true,
+			ExpectOutput: "There was an error listing resources in namespace accelerators-staging\n",
+		},
+		{
+			Name: "Sync adds and changes resources",
+			Args: []string{"--namespace", source, "--target-namespace", target},
+			GivenObjects: []client.Object{
+				newFragment(source, "fragment"),
+				newAccelerator(source, "first", "Lorem Ipsum"),
+				newAccelerator(source, "second", "new description"),
+				newAccelerator(target, "second", "old description"),
+				newAccelerator(target, "third", "Lorem Ipsum"),
+			},
+			ExpectCreates: []client.Object{
+				newFragment(target, "fragment"),
+				newAccelerator(target, "first", "Lorem Ipsum"),
+			},
+			ExpectUpdates: []client.Object{
+				newAccelerator(target, "second", "new description"),
+			},
+			ExpectOutput: `
+1 resource(s) in namespace accelerator-system don't exist in namespace accelerators-staging, use --prune to remove them
+Syncing namespace accelerators-staging to namespace accelerator-system:
++ accelerator fragment fragment
++ accelerator first
+~ accelerator second
+created accelerator fragment fragment in namespace accelerator-system
+created accelerator first in namespace accelerator-system
+updated accelerator second in namespace accelerator-system
+`,
+		},
+		{
+			Name: "Dry run of sync with prune",
+			Args: []string{"--namespace", source, "--target-namespace", target, "--prune", "--dry-run"},
+			GivenObjects: []client.Object{
+				newAccelerator(source, "first", "Lorem Ipsum"),
+				newAccelerator(target, "third", "Lorem Ipsum"),
+				newFragment(target, "fragment"),
+			},
+			ExpectOutput: `
+Syncing namespace accelerators-staging to namespace accelerator-system:
++ accelerator first
+- accelerator third
+- accelerator fragment fragment
+`,
+		},
+		{
+			Name: "Sync with prune",
+			Args: []string{"--namespace", source, "--target-namespace", target, "--prune", "--yes"},
+			GivenObjects: []client.Object{
+				newAccelerator(source, "first", "Lorem Ipsum"),
+				newAccelerator(target, "first", "Lorem Ipsum"),
+				newAccelerator(target, "third", "Lorem Ipsum"),
+			},
+			ExpectDeletes: []rtesting.DeleteRef{
+				{
+					Group:     "accelerator.apps.tanzu.vmware.com",
+					Kind:      "Accelerator",
+					Namespace: target,
+					Name:      "third",
+				},
+			},
+			ExpectOutput: `
+Syncing namespace accelerators-staging to namespace accelerator-system:
+- accelerator third
+deleted accelerator third in namespace accelerator-system
+`,
+		},
+		{
+			Name: "Skip sync with prune when not confirmed",
+			Args: []string{"--namespace", source, "--target-namespace", target, "--prune"},
+			GivenObjects: []client.Object{
+				newAccelerator(target, "third", "Lorem Ipsum"),
+			},
+			Stdin: []byte("n\n"),
+			Verify: func(t *testing.T, output string, err error) {
+				for _, expected := range []string{
+					"Really remove 1 resource(s) from namespace accelerator-system?",
+					"Skipping sync to namespace accelerator-system\n",
+				} {
+					if !strings.Contains(output, expected) {
+						t.Errorf("expected output to contain %q, got %q", expected, output)
+					}
+				}
+			},
+		},
+	}
+	table.Run(t, scheme, SyncCmd)
+}
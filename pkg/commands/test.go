@@ -0,0 +1,427 @@
+/*
+Copyright 2022-2023 VMware, Inc. All Rights Reserved.
+*/
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	acceleratorTestsDirectory = "accelerator-tests"
+	acceleratorTestFile       = "test.yaml"
+	acceleratorTestExpected   = "expected"
+)
+
+// acceleratorTestCase is the test.yaml of a test case, fragment paths are relative to the accelerator directory
+type acceleratorTestCase struct {
+	Options       map[string]interface{} `json:"options,omitempty"`
+	FragmentNames []string               `json:"fragmentNames,omitempty"`
+	FragmentPaths map[string]string      `json:"fragmentPaths,omitempty"`
+}
+
+type acceleratorTestResult struct {
+	name     string
+	duration time.Duration
+	err      error
+	failures []string
+}
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Errors   int             `xml:"errors,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Content string `xml:",chardata"`
+}
+
+func AcceleratorTestCmd() *cobra.Command {
+	var uiServer string
+	var accServerUrl string
+	var testsDirectory string
+	var runTests []string
+	var localFragments map[string]string
+	var update bool
+	var junitReport string
+	var testCommand = &cobra.Command{
+		Use:   "test [path]",
+		Short: "Test that an accelerator generates the expected projects",
+		Long: `Generate projects from a local accelerator and compare them with the expected projects.
+
+The path is the directory containing the accelerator.yaml file and defaults to the current directory. Every
+subdirectory of the accelerator-tests directory next to the accelerator.yaml is a test case, containing a test.yaml
+and an expected directory with the files of the expected project. The test.yaml provides the options and the
+fragments used to generate the project, for example:
+
+    options:
+      projectName: test
+      includeKubernetes: true
+    fragmentNames: [tap-workload]
+    fragmentPaths:
+      java-version: ../fragments/java-version
+
+Fragment paths are relative to the accelerator directory, fragments provided with --fragment-paths are added to every
+test case. The projects are generated the same way as with generate-from-local, the test cases are not packaged with
+the accelerator when the tests directory is inside the accelerator directory. The accelerator is named after the
+Accelerator resource in the k8s-resource.yaml file of the accelerator directory, or after the directory when there is
+no such file.
+
+The generated files are compared file by file and the differences are shown as unified diffs. Use --update to replace
+the expected files with the generated ones after reviewing the differences, and --junit-report to write the results
+as JUnit XML for CI systems.
+
+The test command needs access to the Application Accelerator server. You can specify the --server-url flag or set
+an ACC_SERVER_URL environment variable.
+`,
+		Example: `tanzu accelerator test ./my-accelerator --server-url https://accelerator.example.com
+tanzu accelerator test --run basic,with-workload --update
+tanzu accelerator test --junit-report accelerator-tests.xml`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			acceleratorPath := "."
+			if len(args) > 0 {
+				acceleratorPath = args[0]
+			}
+			if _, err := os.Stat(filepath.Join(acceleratorPath, acceleratorMetadataFile)); err != nil {
+				return fmt.Errorf("%s does not contain an %s", acceleratorPath, acceleratorMetadataFile)
+			}
+			serverUrl := accServerUrl
+			if uiServer != "" {
+				serverUrl = uiServer
+			}
+
+			testsPath := testsDirectory
+			if testsPath == "" {
+				testsPath = filepath.Join(acceleratorPath, acceleratorTestsDirectory)
+			}
+			entries, err := os.ReadDir(testsPath)
+			if err != nil {
+				return fmt.Errorf("could not read the test cases in %s: %v", testsPath, err)
+			}
+			names := []string{}
+			for _, entry := range entries {
+				if entry.IsDir() && (len(runTests) == 0 || contains(runTests, []string{entry.Name()})) {
+					names = append(names, entry.Name())
+				}
+			}
+			if len(names) == 0 {
+				return fmt.Errorf("no test cases found in %s", testsPath)
+			}
+
+			// the test cases are not part of the accelerator, they are excluded from the packaged files
+			excludes := []string{}
+			if relative, err := filepath.Rel(acceleratorPath, testsPath); err == nil && isInside(acceleratorPath, testsPath) {
+				excludes = append(excludes, "/"+filepath.ToSlash(relative)+"/")
+			}
+
+			acceleratorName, err := testedAcceleratorName(acceleratorPath)
+			if err != nil {
+				return err
+			}
+			results := []acceleratorTestResult{}
+			for _, name := range names {
+				start := time.Now()
+				result := runAcceleratorTest(cmd, acceleratorName, acceleratorPath, filepath.Join(testsPath, name), serverUrl, localFragments, excludes, update)
+				result.name = name
+				result.duration = time.Since(start)
+				results = append(results, result)
+
+				switch {
+				case result.err != nil:
+					fmt.Fprintf(cmd.OutOrStdout(), "ERROR %s (%.2fs): %v\n", name, result.duration.Seconds(), result.err)
+				case len(result.failures) > 0:
+					fmt.Fprintf(cmd.OutOrStdout(), "FAIL %s (%.2fs)\n", name, result.duration.Seconds())
+					for _, failure := range result.failures {
+						fmt.Fprintf(cmd.OutOrStdout(), "%s\n", indent(failure, "    "))
+					}
+				case update:
+					fmt.Fprintf(cmd.OutOrStdout(), "UPDATED %s (%.2fs)\n", name, result.duration.Seconds())
+				default:
+					fmt.Fprintf(cmd.OutOrStdout(), "PASS %s (%.2fs)\n", name, result.duration.Seconds())
+				}
+			}
+
+			if junitReport != "" {
+				if err := writeJUnitReport(junitReport, acceleratorPath, results); err != nil {
+					return err
+				}
+			}
+
+			failed := 0
+			for _, result := range results {
+				if result.err != nil || len(result.failures) > 0 {
+					failed++
+				}
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "%d test(s), %d passed, %d failed\n", len(results), len(results)-failed, failed)
+			if failed > 0 {
+				// the failures have been reported already, the usage would only hide them
+				cmd.SilenceUsage = true
+				return fmt.Errorf("%d of %d test(s) failed", failed, len(results))
+			}
+			return nil
+		},
+	}
+	testCommand.Flags().StringVar(&uiServer, "server-url", "", "the URL for the Application Accelerator server")
+	testCommand.Flags().StringVar(&testsDirectory, "tests-dir", "", "the directory containing the test cases (defaults to the accelerator-tests directory of the accelerator)")
+	testCommand.Flags().StringSliceVar(&runTests, "run", []string{}, "names of the test cases to run (defaults to all test cases)")
+	testCommand.Flags().StringToStringVar(&localFragments, "fragment-paths", map[string]string{}, "key value pairs of the name and path to the directory containing each fragment, used for every test case")
+	testCommand.Flags().BoolVar(&update, "update", false, "replace the expected files of the test cases with the generated files")
+	testCommand.Flags().StringVar(&junitReport, "junit-report", "", "path of the file to write a JUnit XML report to")
+	accServerUrl = EnvVar("ACC_SERVER_URL", "")
+	return testCommand
+}
+
+// testedAcceleratorName returns the name every test case generates the accelerator with: the name of the Accelerator
+// resource in the scaffolded manifest of the accelerator, or the name of the accelerator directory
+func testedAcceleratorName(acceleratorPath string) (string, error) {
+	if content, err := os.ReadFile(filepath.Join(acceleratorPath, scaffoldManifestFile)); err == nil {
+		manifest := struct {
+			Kind     string `json:"kind"`
+			Metadata struct {
+				Name string `json:"name"`
+			} `json:"metadata"`
+		}{}
+		if yaml.Unmarshal(content, &manifest) == nil && manifest.Kind == "Accelerator" && manifest.Metadata.Name != "" {
+			return manifest.Metadata.Name, nil
+		}
+	}
+	path, err := filepath.Abs(acceleratorPath)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Base(path), nil
+}
+
+// runAcceleratorTest generates the project of the test case using generate-from-local and compares it with the
+// expected directory, or replaces the expected directory when updating
+func runAcceleratorTest(cmd *cobra.Command, acceleratorName string, acceleratorPath string, testPath string, serverUrl string, localFragments map[string]string, excludes []string, update bool) acceleratorTestResult {
+	testCase := acceleratorTestCase{}
+	if content, err := os.ReadFile(filepath.Join(testPath, acceleratorTestFile)); err == nil {
+		if err := yaml.UnmarshalStrict(content, &testCase); err != nil {
+			return acceleratorTestResult{err: fmt.Errorf("invalid %s: %v", acceleratorTestFile, err)}
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return acceleratorTestResult{err: err}
+	}
+	if testCase.Options == nil {
+		testCase.Options = map[string]interface{}{}
+	}
+	options, err := json.Marshal(testCase.Options)
+	if err != nil {
+		return acceleratorTestResult{err: err}
+	}
+
+	outputDirectory, err := os.MkdirTemp("", "accelerator-test")
+	if err != nil {
+		return acceleratorTestResult{err: err}
+	}
+	defer os.RemoveAll(outputDirectory)
+
+	args := []string{
+		"--accelerator-path", fmt.Sprintf("%s=%s", acceleratorName, acceleratorPath),
+		"--options", string(options),
+		"--output-dir", outputDirectory,
+		"--force",
+		// the tests check what the server generates, independently of the cache and of the hooks of the user
+		"--no-cache",
+		"--no-hooks",
+	}
+	if serverUrl != "" {
+		args = append(args, "--server-url", serverUrl)
+	}
+	for _, exclude := range excludes {
+		args = append(args, "--exclude", exclude)
+	}
+	for _, fragmentName := range testCase.FragmentNames {
+		args = append(args, "--fragment-names", fragmentName)
+	}
+	for fragmentName, fragmentPath := range localFragments {
+		args = append(args, "--fragment-paths", fmt.Sprintf("%s=%s", fragmentName, fragmentPath))
+	}
+	for fragmentName, fragmentPath := range testCase.FragmentPaths {
+		args = append(args, "--fragment-paths", fmt.Sprintf("%s=%s", fragmentName, filepath.Join(acceleratorPath, fragmentPath)))
+	}
+	generateCmd := LocalGenerateCmd()
+	generateCmd.SetArgs(args)
+	generateCmd.SetOut(io.Discard)
+	generateCmd.SetErr(io.Discard)
+	generateCmd.SilenceUsage = true
+	generateCmd.SilenceErrors = true
+	if err := generateCmd.ExecuteContext(cmd.Context()); err != nil {
+		return acceleratorTestResult{err: fmt.Errorf("could not generate project: %v", strings.TrimSpace(err.Error()))}
+	}
+
+	expectedDirectory := filepath.Join(testPath, acceleratorTestExpected)
+	if update {
+		if err := os.RemoveAll(expectedDirectory); err != nil {
+			return acceleratorTestResult{err: err}
+		}
+		if err := copyDirectory(outputDirectory, expectedDirectory); err != nil {
+			return acceleratorTestResult{err: err}
+		}
+		return acceleratorTestResult{}
+	}
+	failures, err := compareDirectories(expectedDirectory, outputDirectory)
+	return acceleratorTestResult{err: err, failures: failures}
+}
+
+// compareDirectories returns a description of every file that is missing, unexpected or different in the actual
+// directory, including a unified diff for files that are different
+func compareDirectories(expectedDirectory string, actualDirectory string) ([]string, error) {
+	if _, err := os.Stat(expectedDirectory); err != nil {
+		return nil, fmt.Errorf("no expected files in %s, use --update to create them", expectedDirectory)
+	}
+	expected, err := snapshotDirectory(expectedDirectory)
+	if err != nil {
+		return nil, err
+	}
+	actual, err := snapshotDirectory(actualDirectory)
+	if err != nil {
+		return nil, err
+	}
+	paths := []string{}
+	for path := range expected {
+		paths = append(paths, path)
+	}
+	for path := range actual {
+		if _, found := expected[path]; !found {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	failures := []string{}
+	for _, path := range paths {
+		expectedChecksum, inExpected := expected[path]
+		actualChecksum, inActual := actual[path]
+		switch {
+		case !inActual:
+			failures = append(failures, fmt.Sprintf("- %s: missing from the generated project", path))
+		case !inExpected:
+			failures = append(failures, fmt.Sprintf("+ %s: not expected in the generated project", path))
+		case expectedChecksum != actualChecksum:
+			expectedContent, err := os.ReadFile(filepath.Join(expectedDirectory, filepath.FromSlash(path)))
+			if err != nil {
+				return nil, err
+			}
+			actualContent, err := os.ReadFile(filepath.Join(actualDirectory, filepath.FromSlash(path)))
+			if err != nil {
+				return nil, err
+			}
+			failure := fmt.Sprintf("~ %s: differs from the expected file", path)
+			if isText(expectedContent) && isText(actualContent) {
+				diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+					A:        diffLines(expectedContent),
+					B:        diffLines(actualContent),
+					FromFile: "expected/" + path,
+					ToFile:   "generated/" + path,
+					Context:  3,
+				})
+				if err != nil {
+					return nil, err
+				}
+				failure += "\n" + strings.TrimSuffix(diff, "\n")
+			}
+			failures = append(failures, failure)
+		}
+	}
+	return failures, nil
+}
+
+// diffLines splits the content into lines keeping the line endings, difflib.SplitLines would add an empty line to
+// content ending with a newline
+func diffLines(content []byte) []string {
+	lines := strings.SplitAfter(string(content), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// isText reports whether the content looks like text, binary files are not shown as diffs
+func isText(content []byte) bool {
+	return !bytes.Contains(content, []byte{0})
+}
+
+func indent(text string, prefix string) string {
+	return prefix + strings.ReplaceAll(text, "\n", "\n"+prefix)
+}
+
+// copyDirectory copies the regular files in the source directory to the target directory
+func copyDirectory(source string, target string) error {
+	return filepath.Walk(source, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(source, path)
+		if err != nil {
+			return err
+		}
+		return copySourceFile(path, filepath.Join(target, rel), fi)
+	})
+}
+
+func writeJUnitReport(file string, acceleratorPath string, results []acceleratorTestResult) error {
+	suite := junitTestSuite{Name: filepath.Base(filepath.Clean(acceleratorPath)), Tests: len(results)}
+	var total time.Duration
+	for _, result := range results {
+		total += result.duration
+		testCase := junitTestCase{
+			Name:      result.name,
+			ClassName: suite.Name,
+			Time:      fmt.Sprintf("%.3f", result.duration.Seconds()),
+		}
+		if result.err != nil {
+			suite.Errors++
+			testCase.Error = &junitMessage{Message: result.err.Error()}
+		} else if len(result.failures) > 0 {
+			suite.Failures++
+			testCase.Failure = &junitMessage{
+				Message: fmt.Sprintf("%d file(s) differ from the expected project", len(result.failures)),
+				Content: strings.Join(result.failures, "\n"),
+			}
+		}
+		suite.Cases = append(suite.Cases, testCase)
+	}
+	suite.Time = fmt.Sprintf("%.3f", total.Seconds())
+
+	content, err := xml.MarshalIndent(junitTestSuites{Suites: []junitTestSuite{suite}}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, append([]byte(xml.Header), append(content, '\n')...), 0644)
+}
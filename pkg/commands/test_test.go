@@ -0,0 +1,286 @@
+package commands
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// newTemplatingServer returns a server generating projects from the files of the local accelerator, replacing
+// "hello-world" with the projectName option and skipping the accelerator-tests directory
+func newTemplatingServer() *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/about", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+		w.Write([]byte("{}"))
+	})
+	mux.HandleFunc("/api/accelerators/zip", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseMultipartForm(100 << 20)
+		options := map[string]interface{}{}
+		json.Unmarshal([]byte(r.FormValue("options")), &options)
+		projectName, _ := options["projectName"].(string)
+		fileAcc, _, err := r.FormFile("accelerator")
+		Expect(err).NotTo(HaveOccurred())
+		gzr, err := gzip.NewReader(fileAcc)
+		Expect(err).NotTo(HaveOccurred())
+		tr := tar.NewReader(gzr)
+		zipWriter := zip.NewWriter(w)
+		for header, err := tr.Next(); err == nil; header, err = tr.Next() {
+			// the test cases are not packaged with the accelerator
+			Expect(header.Name).NotTo(HavePrefix("accelerator-tests/"))
+			if header.Name == "accelerator.yaml" {
+				continue
+			}
+			content, _ := io.ReadAll(tr)
+			entry, _ := zipWriter.Create(projectName + "/" + header.Name)
+			entry.Write(bytes.ReplaceAll(content, []byte("hello-world"), []byte(projectName)))
+		}
+		zipWriter.Close()
+	}))
+	return httptest.NewServer(mux)
+}
+
+// newTestAccelerator creates an accelerator with a test case that passes and one with a wrong expected file
+func newTestAccelerator() string {
+	accDir, err := os.MkdirTemp("", "acc")
+	Expect(err).NotTo(HaveOccurred())
+	files := map[string]string{
+		"accelerator.yaml":                  "accelerator: {}\nengine:\n  exclude: [\"accelerator-tests/**\"]\n",
+		"README.md":                         "# hello-world\n\nGenerated project.\n",
+		"src/main.txt":                      "main of hello-world\n",
+		"accelerator-tests/basic/test.yaml": "options:\n  projectName: basic\n",
+		"accelerator-tests/basic/expected/README.md":    "# basic\n\nGenerated project.\n",
+		"accelerator-tests/basic/expected/src/main.txt": "main of basic\n",
+		"accelerator-tests/outdated/test.yaml":          "options:\n  projectName: outdated\n",
+		"accelerator-tests/outdated/expected/README.md": "# hello-world\n\nGenerated project.\n",
+		"accelerator-tests/outdated/expected/extra.txt": "extra\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(accDir, filepath.FromSlash(name))
+		Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+		Expect(os.WriteFile(path, []byte(content), 0644)).To(Succeed())
+	}
+	return accDir
+}
+
+var _ = Describe("command run", func() {
+	Context("AcceleratorTestCmd()", func() {
+		When("Executes test command for a directory without accelerator.yaml", func() {
+			It("Should output error message", func() {
+				testCmd := AcceleratorTestCmd()
+				testCmd.SetArgs([]string{"./testdata/validate"})
+				err := testCmd.Execute()
+				Expect(err).NotTo(BeNil())
+				Expect(err.Error()).Should(Equal("./testdata/validate does not contain an accelerator.yaml"))
+			})
+		})
+
+		When("Executes test command for an accelerator without test cases", func() {
+			It("Should output error message", func() {
+				testCmd := AcceleratorTestCmd()
+				testCmd.SetArgs([]string{"./testdata/test-acc"})
+				err := testCmd.Execute()
+				Expect(err).NotTo(BeNil())
+				Expect(err.Error()).Should(ContainSubstring("could not read the test cases in testdata/test-acc/accelerator-tests"))
+			})
+		})
+
+		When("Executes test command with a passing test case", func() {
+			It("Should report the test case as passed", func() {
+				ts := newTemplatingServer()
+				defer ts.Close()
+				accDir := newTestAccelerator()
+				defer os.RemoveAll(accDir)
+
+				testCmd := AcceleratorTestCmd()
+				b := new(bytes.Buffer)
+				testCmd.SetOut(b)
+				testCmd.SetErr(b)
+				testCmd.SetArgs([]string{accDir, "--server-url", ts.URL, "--run", "basic"})
+				Expect(testCmd.Execute()).To(Succeed())
+				Expect(b.String()).Should(MatchRegexp(`^PASS basic \(\d+\.\d\ds\)\n1 test\(s\), 1 passed, 0 failed\n$`))
+			})
+		})
+
+		When("Executes test command with several test cases", func() {
+			// recordUploads returns a server that records the file names of the uploaded accelerators before
+			// generating the project with the templating server
+			recordUploads := func(ts *httptest.Server, uploaded *[]string) *httptest.Server {
+				return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					if r.URL.Path == "/api/accelerators/zip" {
+						r.ParseMultipartForm(100 << 20)
+						_, header, err := r.FormFile("accelerator")
+						Expect(err).NotTo(HaveOccurred())
+						*uploaded = append(*uploaded, header.Filename)
+					}
+					ts.Config.Handler.ServeHTTP(w, r)
+				}))
+			}
+
+			It("Should generate every test case with the name of the Accelerator resource", func() {
+				ts := newTemplatingServer()
+				defer ts.Close()
+				uploaded := []string{}
+				recorder := recordUploads(ts, &uploaded)
+				defer recorder.Close()
+				accDir := newTestAccelerator()
+				defer os.RemoveAll(accDir)
+				Expect(os.WriteFile(filepath.Join(accDir, scaffoldManifestFile), []byte("apiVersion: accelerator.apps.tanzu.vmware.com/v1alpha1\nkind: Accelerator\nmetadata:\n  name: hello-world\n"), 0644)).To(Succeed())
+
+				testCmd := AcceleratorTestCmd()
+				b := new(bytes.Buffer)
+				testCmd.SetOut(b)
+				testCmd.SetErr(b)
+				testCmd.SetArgs([]string{accDir, "--server-url", recorder.URL})
+				Expect(testCmd.Execute()).NotTo(Succeed())
+				Expect(uploaded).To(Equal([]string{"hello-world.tar.gz", "hello-world.tar.gz"}))
+			})
+
+			It("Should generate every test case with the name of the accelerator directory without a manifest", func() {
+				ts := newTemplatingServer()
+				defer ts.Close()
+				uploaded := []string{}
+				recorder := recordUploads(ts, &uploaded)
+				defer recorder.Close()
+				accDir := newTestAccelerator()
+				defer os.RemoveAll(accDir)
+
+				testCmd := AcceleratorTestCmd()
+				b := new(bytes.Buffer)
+				testCmd.SetOut(b)
+				testCmd.SetErr(b)
+				testCmd.SetArgs([]string{accDir, "--server-url", recorder.URL})
+				Expect(testCmd.Execute()).NotTo(Succeed())
+				name := filepath.Base(accDir) + ".tar.gz"
+				Expect(uploaded).To(Equal([]string{name, name}))
+			})
+		})
+
+		When("Executes test command with post-generation hooks in the hooks file", func() {
+			It("Should not run the hooks", func() {
+				ts := newTemplatingServer()
+				defer ts.Close()
+				accDir := newTestAccelerator()
+				defer os.RemoveAll(accDir)
+				hooksDir, err := os.MkdirTemp("", "hooks")
+				Expect(err).NotTo(HaveOccurred())
+				defer os.RemoveAll(hooksDir)
+				marker := filepath.Join(hooksDir, "hook-ran")
+				hooksFile := filepath.Join(hooksDir, "hooks.yaml")
+				Expect(os.WriteFile(hooksFile, []byte("postGenerate:\n- command: touch extra.txt "+marker+"\n"), 0644)).To(Succeed())
+				os.Setenv("ACC_HOOKS_FILE", hooksFile)
+				defer os.Unsetenv("ACC_HOOKS_FILE")
+
+				testCmd := AcceleratorTestCmd()
+				b := new(bytes.Buffer)
+				testCmd.SetOut(b)
+				testCmd.SetErr(b)
+				testCmd.SetArgs([]string{accDir, "--server-url", ts.URL, "--run", "basic"})
+				Expect(testCmd.Execute()).To(Succeed())
+				Expect(b.String()).Should(MatchRegexp(`^PASS basic \(\d+\.\d\ds\)\n`))
+				Expect(marker).NotTo(BeAnExistingFile())
+			})
+		})
+
+		When("Executes test command with a failing test case", func() {
+			It("Should report the differences and write a JUnit report", func() {
+				ts := newTemplatingServer()
+				defer ts.Close()
+				accDir := newTestAccelerator()
+				defer os.RemoveAll(accDir)
+				report := filepath.Join(accDir, "report.xml")
+
+				testCmd := AcceleratorTestCmd()
+				b := new(bytes.Buffer)
+				testCmd.SetOut(b)
+				testCmd.SetErr(b)
+				testCmd.SetArgs([]string{accDir, "--server-url", ts.URL, "--junit-report", report})
+				err := testCmd.Execute()
+				Expect(err).NotTo(BeNil())
+				Expect(err.Error()).Should(Equal("1 of 2 test(s) failed"))
+				Expect(b.String()).Should(ContainSubstring("PASS basic"))
+				Expect(b.String()).Should(ContainSubstring("FAIL outdated"))
+				Expect(b.String()).Should(ContainSubstring(`
+    ~ README.md: differs from the expected file
+    --- expected/README.md
+    +++ generated/README.md
+    @@ -1,3 +1,3 @@
+    -# hello-world
+    +# outdated
+     
+     Generated project.
+    - extra.txt: missing from the generated project
+    + src/main.txt: not expected in the generated project
+2 test(s), 1 passed, 1 failed
+`))
+
+				content, err := os.ReadFile(report)
+				Expect(err).NotTo(HaveOccurred())
+				suites := junitTestSuites{}
+				Expect(xml.Unmarshal(content, &suites)).To(Succeed())
+				Expect(suites.Suites).To(HaveLen(1))
+				Expect(suites.Suites[0].Tests).To(Equal(2))
+				Expect(suites.Suites[0].Failures).To(Equal(1))
+				Expect(suites.Suites[0].Cases[0].Name).To(Equal("basic"))
+				Expect(suites.Suites[0].Cases[0].Failure).To(BeNil())
+				Expect(suites.Suites[0].Cases[1].Name).To(Equal("outdated"))
+				Expect(suites.Suites[0].Cases[1].Failure.Message).To(Equal("3 file(s) differ from the expected project"))
+			})
+		})
+
+		When("Executes test command with --update", func() {
+			It("Should replace the expected files", func() {
+				ts := newTemplatingServer()
+				defer ts.Close()
+				accDir := newTestAccelerator()
+				defer os.RemoveAll(accDir)
+
+				testCmd := AcceleratorTestCmd()
+				b := new(bytes.Buffer)
+				testCmd.SetOut(b)
+				testCmd.SetErr(b)
+				testCmd.SetArgs([]string{accDir, "--server-url", ts.URL, "--run", "outdated", "--update"})
+				Expect(testCmd.Execute()).To(Succeed())
+				Expect(b.String()).Should(ContainSubstring("UPDATED outdated"))
+				Expect(filepath.Join(accDir, "accelerator-tests/outdated/expected/extra.txt")).ShouldNot(BeAnExistingFile())
+				content, err := os.ReadFile(filepath.Join(accDir, "accelerator-tests/outdated/expected/src/main.txt"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(content)).Should(Equal("main of outdated\n"))
+
+				testCmd = AcceleratorTestCmd()
+				testCmd.SetOut(b)
+				testCmd.SetErr(b)
+				testCmd.SetArgs([]string{accDir, "--server-url", ts.URL})
+				Expect(testCmd.Execute()).To(Succeed())
+			})
+		})
+
+		When("Executes test command and the generation fails", func() {
+			It("Should report the test case as an error", func() {
+				accDir := newTestAccelerator()
+				defer os.RemoveAll(accDir)
+
+				testCmd := AcceleratorTestCmd()
+				b := new(bytes.Buffer)
+				testCmd.SetOut(b)
+				testCmd.SetErr(b)
+				testCmd.SetArgs([]string{accDir, "--server-url", "localhost", "--run", "basic"})
+				err := testCmd.Execute()
+				Expect(err).NotTo(BeNil())
+				Expect(b.String()).Should(ContainSubstring("ERROR basic"))
+				Expect(b.String()).Should(ContainSubstring(`the URL needs to include the protocol ("http://" or "https://")`))
+			})
+		})
+	})
+})
@@ -7,20 +7,23 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/fluxcd/pkg/apis/meta"
-	"github.com/imdario/mergo"
 	acceleratorv1alpha1 "github.com/pivotal/acc-controller/api/v1alpha1"
 	fluxcdv1beta1 "github.com/pivotal/acc-controller/fluxcd/api/v1beta2"
 	"github.com/pivotal/acc-controller/sourcecontroller/api/v1alpha1"
 	"github.com/spf13/cobra"
 	"github.com/vmware-tanzu/apps-cli-plugin/pkg/cli-runtime"
 	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+var acceleratorUnsetFields = []string{"display-name", "description", "icon-url", "git-tag", "git-sub-path", "interval", "secret-ref"}
+
 func UpdateCmd(ctx context.Context, c *cli.Config) *cobra.Command {
 	opts := UpdateOptions{}
 	requestedAtAnnotation := "reconcile.accelerator.apps.tanzu.vmware.com/requestedAt"
@@ -32,6 +35,16 @@ func UpdateCmd(ctx context.Context, c *cli.Config) *cobra.Command {
 Accelerator configuration options include:
 - Git repository URL and branch/tag where accelerator code and metadata is defined
 - Metadata like description, display-name, tags and icon-url
+- Labels and annotations for the accelerator resource
+
+Tags can be replaced using --tags, or edited incrementally using --add-tag, --remove-tag and --clear-tags.
+Fields can be removed from the accelerator using --unset, for example --unset description,git-sub-path,secret-ref.
+Supported fields are display-name, description, icon-url, git-tag, git-sub-path, interval and secret-ref.
+
+Only the changed fields are sent to the cluster, so changes made by someone else to the other fields of the
+accelerator are kept.
+When the accelerator was changed by someone else while it was being updated, the update is retried using the
+latest version of the accelerator.
 
 The update command also provides a --reoncile flag that will force the accelerator to be refreshed
 with any changes made to the associated Git repository.
@@ -43,108 +56,166 @@ with any changes made to the associated Git repository.
 			}
 			return nil
 		},
-		Example: "tanzu accelerator update <accelerator-name> --description \"Lorem Ipsum\"",
+		Example: `tanzu accelerator update <accelerator-name> --description "Lorem Ipsum"
+tanzu accelerator update <accelerator-name> --unset git-sub-path,secret-ref`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if opts.GitRepoUrl != "" && opts.SourceImage != "" {
 				return errors.New("you may only provide one of --git-repository or --source-image")
 			}
 
-			accelerator := &acceleratorv1alpha1.Accelerator{}
-			err := c.Get(context.Background(), client.ObjectKey{Namespace: opts.Namespace, Name: args[0]}, accelerator)
-			if err != nil {
-				fmt.Fprintf(cmd.OutOrStderr(), "accelerator %s not found\n", args[0])
+			if err := validateUnset(cmd, opts.Unset, acceleratorUnsetFields); err != nil {
 				return err
 			}
-			updatedAccelerator := &acceleratorv1alpha1.Accelerator{
-				TypeMeta: v1.TypeMeta{
-					APIVersion: "accelerator.tanzu.vmware.com/v1alpha1",
-					Kind:       "Accelerator",
-				},
-				ObjectMeta: v1.ObjectMeta{
-					Namespace: opts.Namespace,
-				},
-				Spec: acceleratorv1alpha1.AcceleratorSpec{
-					DisplayName: opts.DisplayName,
-					Description: opts.Description,
-					IconUrl:     opts.IconUrl,
-					Tags:        opts.Tags,
-				},
+			unset := func(field string) bool {
+				return contains(opts.Unset, []string{field})
 			}
 
-			if opts.GitRepoUrl != "" {
-				updatedAccelerator.Spec.Git = &acceleratorv1alpha1.Git{
-					URL: opts.GitRepoUrl,
-					Reference: &fluxcdv1beta1.GitRepositoryRef{
-						Branch: opts.GitBranch,
-						Tag:    opts.GitTag,
-					},
-				}
-				if opts.GitSubPath != "" {
-					updatedAccelerator.Spec.Git.SubPath = &opts.GitSubPath
+			var interval *v1.Duration
+			if opts.Interval != "" {
+				duration, err := time.ParseDuration(opts.Interval)
+				if err != nil {
+					return fmt.Errorf("invalid interval %q: %v", opts.Interval, err)
 				}
-				accelerator.Spec.Source = nil
+				interval = &v1.Duration{Duration: duration}
 			}
 
-			if opts.SourceImage != "" {
-				updatedAccelerator.Spec.Source = &v1alpha1.ImageRepositorySpec{
-					Image: opts.SourceImage,
-				}
-				accelerator.Spec.Git = nil
+			var accelerator *acceleratorv1alpha1.Accelerator
+			key := client.ObjectKey{Namespace: opts.Namespace, Name: args[0]}
+			newAccelerator := func() client.Object {
+				accelerator = &acceleratorv1alpha1.Accelerator{}
+				return accelerator
 			}
+			err := patchResource(ctx, c, "accelerator", key, newAccelerator, func() error {
+				// the requested changes are applied to the live accelerator and sent as a merge patch, so that
+				// anything that is unset is removed from the resource on the cluster as well
+				if opts.DisplayName != "" {
+					accelerator.Spec.DisplayName = opts.DisplayName
+				}
+				if opts.Description != "" {
+					accelerator.Spec.Description = opts.Description
+				}
+				if opts.IconUrl != "" {
+					accelerator.Spec.IconUrl = opts.IconUrl
+				}
+				if len(opts.Tags) > 0 {
+					accelerator.Spec.Tags = opts.Tags
+				}
+				if unset("display-name") {
+					accelerator.Spec.DisplayName = ""
+				}
+				if unset("description") {
+					accelerator.Spec.Description = ""
+				}
+				if unset("icon-url") {
+					accelerator.Spec.IconUrl = ""
+				}
 
-			if opts.Reconcile {
-				if accelerator.ObjectMeta.Annotations == nil {
-					accelerator.ObjectMeta.Annotations = make(map[string]string)
+				if opts.GitRepoUrl != "" {
+					if accelerator.Spec.Git == nil {
+						accelerator.Spec.Git = &acceleratorv1alpha1.Git{}
+					}
+					accelerator.Spec.Git.URL = opts.GitRepoUrl
+					if accelerator.Spec.Git.Reference == nil {
+						accelerator.Spec.Git.Reference = &fluxcdv1beta1.GitRepositoryRef{}
+					}
+					accelerator.Spec.Source = nil
 				}
-				accelerator.ObjectMeta.Annotations[requestedAtAnnotation] = time.Now().UTC().Format(time.RFC3339)
-			}
 
-			mergo.Merge(updatedAccelerator, *accelerator)
+				if opts.SourceImage != "" {
+					if accelerator.Spec.Source == nil {
+						accelerator.Spec.Source = &v1alpha1.ImageRepositorySpec{}
+					}
+					accelerator.Spec.Source.Image = opts.SourceImage
+					accelerator.Spec.Git = nil
+				}
 
-			if opts.GitRepoUrl == "" && opts.GitBranch != "" {
-				updatedAccelerator.Spec.Git.Reference.Branch = opts.GitBranch
-			}
+				if opts.GitBranch != "" || opts.GitTag != "" || opts.GitSubPath != "" || unset("git-tag") || unset("git-sub-path") {
+					if accelerator.Spec.Git == nil {
+						return fmt.Errorf("accelerator %s does not use a Git repository, use --git-repository to provide one", args[0])
+					}
+					if accelerator.Spec.Git.Reference == nil {
+						accelerator.Spec.Git.Reference = &fluxcdv1beta1.GitRepositoryRef{}
+					}
+					if opts.GitBranch != "" {
+						accelerator.Spec.Git.Reference.Branch = opts.GitBranch
+					}
+					if opts.GitTag != "" {
+						accelerator.Spec.Git.Reference.Tag = opts.GitTag
+					}
+					if opts.GitSubPath != "" {
+						accelerator.Spec.Git.SubPath = &opts.GitSubPath
+					}
+					if unset("git-tag") {
+						accelerator.Spec.Git.Reference.Tag = ""
+					}
+					if unset("git-sub-path") {
+						accelerator.Spec.Git.SubPath = nil
+					}
+				}
 
-			if opts.GitRepoUrl == "" && opts.GitTag != "" {
-				updatedAccelerator.Spec.Git.Reference.Tag = opts.GitTag
-			}
+				if interval != nil || unset("interval") {
+					if accelerator.Spec.Source != nil {
+						accelerator.Spec.Source.Interval = interval
+					}
+					if accelerator.Spec.Git != nil {
+						accelerator.Spec.Git.Interval = interval
+					}
+				}
 
-			if opts.GitRepoUrl == "" && opts.GitSubPath != "" {
-				updatedAccelerator.Spec.Git.SubPath = &opts.GitSubPath
-			}
+				if opts.SecretRef != "" {
+					if accelerator.Spec.Source != nil {
+						ref := corev1.LocalObjectReference{
+							Name: opts.SecretRef,
+						}
+						accelerator.Spec.Source.ImagePullSecrets = []corev1.LocalObjectReference{ref}
+					}
 
-			if opts.Interval != "" {
-				duration, _ := time.ParseDuration(opts.Interval)
-				interval := v1.Duration{
-					Duration: duration,
+					if accelerator.Spec.Git != nil {
+						ref := meta.LocalObjectReference{
+							Name: opts.SecretRef,
+						}
+						accelerator.Spec.Git.SecretRef = &ref
+					}
+				}
+				if unset("secret-ref") {
+					if accelerator.Spec.Source != nil {
+						accelerator.Spec.Source.ImagePullSecrets = nil
+					}
+					if accelerator.Spec.Git != nil {
+						accelerator.Spec.Git.SecretRef = nil
+					}
 				}
 
-				if updatedAccelerator.Spec.Source != nil {
-					updatedAccelerator.Spec.Source.Interval = &interval
+				if opts.ClearTags {
+					accelerator.Spec.Tags = nil
 				}
+				accelerator.Spec.Tags = editTags(accelerator.Spec.Tags, opts.AddTags, opts.RemoveTags)
 
-				if updatedAccelerator.Spec.Git != nil {
-					updatedAccelerator.Spec.Git.Interval = &interval
+				labels, err := applyMetadataChanges(accelerator.ObjectMeta.Labels, opts.Labels, "label")
+				if err != nil {
+					return err
 				}
-			}
+				accelerator.ObjectMeta.Labels = labels
 
-			if opts.SecretRef != "" {
-				if updatedAccelerator.Spec.Source != nil {
-					ref := corev1.LocalObjectReference{
-						Name: opts.SecretRef,
-					}
-					updatedAccelerator.Spec.Source.ImagePullSecrets = []corev1.LocalObjectReference{ref}
+				annotations, err := applyMetadataChanges(accelerator.ObjectMeta.Annotations, opts.Annotations, "annotation")
+				if err != nil {
+					return err
 				}
+				accelerator.ObjectMeta.Annotations = annotations
 
-				if updatedAccelerator.Spec.Git != nil {
-					ref := meta.LocalObjectReference{
-						Name: opts.SecretRef,
+				if opts.Reconcile {
+					if accelerator.ObjectMeta.Annotations == nil {
+						accelerator.ObjectMeta.Annotations = make(map[string]string)
 					}
-					updatedAccelerator.Spec.Git.SecretRef = &ref
+					accelerator.ObjectMeta.Annotations[requestedAtAnnotation] = time.Now().UTC().Format(time.RFC3339)
 				}
-			}
 
-			err = c.Update(ctx, updatedAccelerator)
+				return nil
+			})
+			if k8serrors.IsNotFound(err) {
+				fmt.Fprintf(cmd.OutOrStderr(), "accelerator %s not found\n", args[0])
+				return err
+			}
 			if err != nil {
 				fmt.Fprintf(cmd.OutOrStderr(), "there was an error updating accelerator %s\n", args[0])
 				return err
@@ -156,3 +227,31 @@ with any changes made to the associated Git repository.
 	opts.DefineFlags(ctx, updateCmd, c)
 	return updateCmd
 }
+
+// editTags removes and then adds the provided tags, keeping the order of the existing tags and ignoring
+// tags that are already present
+func editTags(tags []string, add []string, remove []string) []string {
+	result := []string{}
+	for _, tag := range tags {
+		removed := false
+		for _, removeTag := range remove {
+			if strings.TrimSpace(removeTag) == tag {
+				removed = true
+				break
+			}
+		}
+		if !removed {
+			result = append(result, tag)
+		}
+	}
+	for _, addTag := range add {
+		addTag = strings.TrimSpace(addTag)
+		if addTag != "" && !contains(result, []string{addTag}) {
+			result = append(result, addTag)
+		}
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
@@ -1,6 +1,10 @@
 package commands
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
 	"testing"
 	"time"
 
@@ -8,10 +12,16 @@ import (
 	acceleratorv1alpha1 "github.com/pivotal/acc-controller/api/v1alpha1"
 	"github.com/pivotal/acc-controller/fluxcd/api/v1beta2"
 	"github.com/pivotal/acc-controller/sourcecontroller/api/v1alpha1"
+	"github.com/spf13/cobra"
+	rtesting "github.com/vmware-labs/reconciler-runtime/testing"
+	cli "github.com/vmware-tanzu/apps-cli-plugin/pkg/cli-runtime"
 	clitesting "github.com/vmware-tanzu/apps-cli-plugin/pkg/cli-runtime/testing"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -30,7 +40,18 @@ func TestUpdateCmd(t *testing.T) {
 	scheme := runtime.NewScheme()
 	_ = acceleratorv1alpha1.AddToScheme(scheme)
 
-	table := clitesting.CommandTestSuite{
+	acceleratorPatch := func(patch string) rtesting.PatchRef {
+		return rtesting.PatchRef{
+			Group:     "accelerator.apps.tanzu.vmware.com",
+			Kind:      "Accelerator",
+			Namespace: namespace,
+			Name:      acceleratorName,
+			PatchType: types.MergePatchType,
+			Patch:     []byte(patch),
+		}
+	}
+
+	table := patchCommandTestSuite{
 		{
 			Name:        "Missing args",
 			Args:        []string{},
@@ -51,7 +72,7 @@ func TestUpdateCmd(t *testing.T) {
 			Name: "Error updating accelerator",
 			Args: []string{acceleratorName, "--description", testDescription},
 			WithReactors: []clitesting.ReactionFunc{
-				clitesting.InduceFailure("update", "Accelerator"),
+				clitesting.InduceFailure("patch", "Accelerator"),
 			},
 			GivenObjects: []client.Object{
 				&acceleratorv1alpha1.Accelerator{
@@ -70,14 +91,23 @@ func TestUpdateCmd(t *testing.T) {
 					},
 				},
 			},
-			ExpectUpdates: []client.Object{
+			ExpectPatches: []rtesting.PatchRef{
+				acceleratorPatch(`{"metadata":{"resourceVersion":"999"},"spec":{"description":"another description"}}`),
+			},
+			ShouldError:  true,
+			ExpectOutput: "there was an error updating accelerator test-accelerator\n",
+		},
+		{
+			Name: "Updates accelerator",
+			Args: []string{acceleratorName, "--description", testDescription, "--interval", interval},
+			GivenObjects: []client.Object{
 				&acceleratorv1alpha1.Accelerator{
 					ObjectMeta: metav1.ObjectMeta{
 						Name:      acceleratorName,
 						Namespace: namespace,
 					},
 					Spec: acceleratorv1alpha1.AcceleratorSpec{
-						Description: testDescription,
+						Description: "first description",
 						Git: &acceleratorv1alpha1.Git{
 							URL: "https://www.test.com",
 							Reference: &v1beta2.GitRepositoryRef{
@@ -87,12 +117,14 @@ func TestUpdateCmd(t *testing.T) {
 					},
 				},
 			},
-			ShouldError:  true,
-			ExpectOutput: "there was an error updating accelerator test-accelerator\n",
+			ExpectPatches: []rtesting.PatchRef{
+				acceleratorPatch(`{"metadata":{"resourceVersion":"999"},"spec":{"description":"another description","git":{"interval":"2m0s"}}}`),
+			},
+			ExpectOutput: "accelerator test-accelerator updated successfully\n",
 		},
 		{
-			Name: "Updates accelerator",
-			Args: []string{acceleratorName, "--description", testDescription, "--interval", interval},
+			Name: "Updates repo url from accelerator",
+			Args: []string{acceleratorName, "--git-repository", repositoryUrl, "--secret-ref", secretRef},
 			GivenObjects: []client.Object{
 				&acceleratorv1alpha1.Accelerator{
 					ObjectMeta: metav1.ObjectMeta{
@@ -101,20 +133,58 @@ func TestUpdateCmd(t *testing.T) {
 					},
 					Spec: acceleratorv1alpha1.AcceleratorSpec{
 						Description: "first description",
+						Source: &v1alpha1.ImageRepositorySpec{
+							Image: "some-image",
+						},
+					},
+				},
+			},
+			ExpectPatches: []rtesting.PatchRef{
+				acceleratorPatch(`{"metadata":{"resourceVersion":"999"},"spec":{"git":{"ref":{},"secretRef":{"name":"mysecret"},"url":"http://www.test.com"},"source":null}}`),
+			},
+			ExpectOutput: "accelerator test-accelerator updated successfully\n",
+		},
+		{
+			Name: "Updates repo image name from accelerator",
+			Args: []string{acceleratorName, "--source-image", imageName, "--secret-ref", secretRef, "--interval", interval},
+			GivenObjects: []client.Object{
+				&acceleratorv1alpha1.Accelerator{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      acceleratorName,
+						Namespace: namespace,
+					},
+					Spec: acceleratorv1alpha1.AcceleratorSpec{
+						Description: testDescription,
 						Git: &acceleratorv1alpha1.Git{
-							URL: "https://www.test.com",
+							URL: "http://www.test.com",
 							Reference: &v1beta2.GitRepositoryRef{
 								Branch: "main",
 							},
+							SecretRef: &meta.LocalObjectReference{
+								Name: secretRef,
+							},
 						},
 					},
 				},
 			},
-			ExpectUpdates: []client.Object{
+			ExpectPatches: []rtesting.PatchRef{
+				acceleratorPatch(`{"metadata":{"resourceVersion":"999"},"spec":{"git":null,"source":{"image":"test-image","imagePullSecrets":[{"name":"mysecret"}],"interval":"2m0s"}}}`),
+			},
+			ExpectOutput: "accelerator test-accelerator updated successfully\n",
+		},
+		{
+			Name: "Updates labels and annotations",
+			Args: []string{acceleratorName, "--label", "team=beta", "--label", "lifecycle-", "--annotation", "owner=beta-team@example.com"},
+			GivenObjects: []client.Object{
 				&acceleratorv1alpha1.Accelerator{
 					ObjectMeta: metav1.ObjectMeta{
 						Name:      acceleratorName,
 						Namespace: namespace,
+						Labels: map[string]string{
+							"team":      "alpha",
+							"lifecycle": "experimental",
+							"tier":      "gold",
+						},
 					},
 					Spec: acceleratorv1alpha1.AcceleratorSpec{
 						Description: testDescription,
@@ -123,16 +193,18 @@ func TestUpdateCmd(t *testing.T) {
 							Reference: &v1beta2.GitRepositoryRef{
 								Branch: "main",
 							},
-							Interval: expectedInterval,
 						},
 					},
 				},
 			},
+			ExpectPatches: []rtesting.PatchRef{
+				acceleratorPatch(`{"metadata":{"annotations":{"owner":"beta-team@example.com"},"labels":{"lifecycle":null,"team":"beta"},"resourceVersion":"999"}}`),
+			},
 			ExpectOutput: "accelerator test-accelerator updated successfully\n",
 		},
 		{
-			Name: "Updates repo url from accelerator",
-			Args: []string{acceleratorName, "--git-repository", repositoryUrl, "--secret-ref", secretRef},
+			Name: "Error updating accelerator with invalid annotation",
+			Args: []string{acceleratorName, "--annotation", "-owner"},
 			GivenObjects: []client.Object{
 				&acceleratorv1alpha1.Accelerator{
 					ObjectMeta: metav1.ObjectMeta{
@@ -140,39 +212,132 @@ func TestUpdateCmd(t *testing.T) {
 						Namespace: namespace,
 					},
 					Spec: acceleratorv1alpha1.AcceleratorSpec{
-						Description: "first description",
-						Source: &v1alpha1.ImageRepositorySpec{
-							Image: "some-image",
+						Git: &acceleratorv1alpha1.Git{
+							URL: "https://www.test.com",
+							Reference: &v1beta2.GitRepositoryRef{
+								Branch: "main",
+							},
 						},
 					},
 				},
 			},
-			ExpectUpdates: []client.Object{
+			ShouldError: true,
+		},
+		{
+			Name: "Adds and removes tags",
+			Args: []string{acceleratorName, "--add-tag", "spring,java", "--remove-tag", "legacy"},
+			GivenObjects: []client.Object{
 				&acceleratorv1alpha1.Accelerator{
 					ObjectMeta: metav1.ObjectMeta{
 						Name:      acceleratorName,
 						Namespace: namespace,
 					},
 					Spec: acceleratorv1alpha1.AcceleratorSpec{
-						Description: "first description",
+						Tags: []string{"java", "legacy"},
 						Git: &acceleratorv1alpha1.Git{
-							URL: "http://www.test.com",
+							URL: "https://www.test.com",
 							Reference: &v1beta2.GitRepositoryRef{
-								Branch: "",
+								Branch: "main",
 							},
-							SecretRef: &meta.LocalObjectReference{
-								Name: secretRef,
+						},
+					},
+				},
+			},
+			ExpectPatches: []rtesting.PatchRef{
+				acceleratorPatch(`{"metadata":{"resourceVersion":"999"},"spec":{"tags":["java","spring"]}}`),
+			},
+			ExpectOutput: "accelerator test-accelerator updated successfully\n",
+		},
+		{
+			Name: "Clears tags",
+			Args: []string{acceleratorName, "--clear-tags"},
+			GivenObjects: []client.Object{
+				&acceleratorv1alpha1.Accelerator{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      acceleratorName,
+						Namespace: namespace,
+					},
+					Spec: acceleratorv1alpha1.AcceleratorSpec{
+						Tags: []string{"java", "legacy"},
+						Git: &acceleratorv1alpha1.Git{
+							URL: "https://www.test.com",
+							Reference: &v1beta2.GitRepositoryRef{
+								Branch: "main",
 							},
 						},
-						Source: nil,
 					},
 				},
 			},
+			ExpectPatches: []rtesting.PatchRef{
+				acceleratorPatch(`{"metadata":{"resourceVersion":"999"},"spec":{"tags":null}}`),
+			},
 			ExpectOutput: "accelerator test-accelerator updated successfully\n",
 		},
 		{
-			Name: "Updates repo image name from accelerator",
-			Args: []string{acceleratorName, "--source-image", imageName, "--secret-ref", secretRef, "--interval", interval},
+			Name:        "Error replacing and adding tags",
+			Args:        []string{acceleratorName, "--tags", "java", "--add-tag", "spring"},
+			ShouldError: true,
+		},
+		{
+			Name: "Retries adding tags on conflict",
+			Args: []string{acceleratorName, "--add-tag", "spring"},
+			WithReactors: []clitesting.ReactionFunc{
+				induceConflictOnce("Accelerator"),
+			},
+			GivenObjects: []client.Object{
+				&acceleratorv1alpha1.Accelerator{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      acceleratorName,
+						Namespace: namespace,
+					},
+					Spec: acceleratorv1alpha1.AcceleratorSpec{
+						Tags: []string{"java"},
+						Git: &acceleratorv1alpha1.Git{
+							URL: "https://www.test.com",
+							Reference: &v1beta2.GitRepositoryRef{
+								Branch: "main",
+							},
+						},
+					},
+				},
+			},
+			ExpectPatches: []rtesting.PatchRef{
+				acceleratorPatch(`{"metadata":{"resourceVersion":"999"},"spec":{"tags":["java","spring"]}}`),
+				acceleratorPatch(`{"metadata":{"resourceVersion":"999"},"spec":{"tags":["java","spring"]}}`),
+			},
+			ExpectOutput: "accelerator test-accelerator updated successfully\n",
+		},
+		{
+			Name: "Only patches the changed fields",
+			Args: []string{acceleratorName, "--add-tag", "spring"},
+			GivenObjects: []client.Object{
+				&acceleratorv1alpha1.Accelerator{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      acceleratorName,
+						Namespace: namespace,
+						Labels: map[string]string{
+							"team": "alpha",
+						},
+					},
+					Spec: acceleratorv1alpha1.AcceleratorSpec{
+						Tags: []string{"java"},
+						Git: &acceleratorv1alpha1.Git{
+							URL: "https://www.test.com",
+							Reference: &v1beta2.GitRepositoryRef{
+								Branch: "main",
+							},
+						},
+					},
+				},
+			},
+			ExpectPatches: []rtesting.PatchRef{
+				acceleratorPatch(`{"metadata":{"resourceVersion":"999"},"spec":{"tags":["java","spring"]}}`),
+			},
+			ExpectOutput: "accelerator test-accelerator updated successfully\n",
+		},
+		{
+			Name: "Unsets fields",
+			Args: []string{acceleratorName, "--unset", "description,git-sub-path,secret-ref,interval"},
 			GivenObjects: []client.Object{
 				&acceleratorv1alpha1.Accelerator{
 					ObjectMeta: metav1.ObjectMeta{
@@ -181,26 +346,36 @@ func TestUpdateCmd(t *testing.T) {
 					},
 					Spec: acceleratorv1alpha1.AcceleratorSpec{
 						Description: testDescription,
+						IconUrl:     "http://icon-url.png",
 						Git: &acceleratorv1alpha1.Git{
-							URL: "http://www.test.com",
+							URL: repositoryUrl,
 							Reference: &v1beta2.GitRepositoryRef{
 								Branch: "main",
 							},
+							SubPath: &acceleratorName,
 							SecretRef: &meta.LocalObjectReference{
 								Name: secretRef,
 							},
+							Interval: expectedInterval,
 						},
 					},
 				},
 			},
-			ExpectUpdates: []client.Object{
+			ExpectPatches: []rtesting.PatchRef{
+				acceleratorPatch(`{"metadata":{"resourceVersion":"999"},"spec":{"description":null,"git":{"interval":null,"secretRef":null,"subPath":null}}}`),
+			},
+			ExpectOutput: "accelerator test-accelerator updated successfully\n",
+		},
+		{
+			Name: "Unsets secret ref of source image",
+			Args: []string{acceleratorName, "--unset", "secret-ref"},
+			GivenObjects: []client.Object{
 				&acceleratorv1alpha1.Accelerator{
 					ObjectMeta: metav1.ObjectMeta{
 						Name:      acceleratorName,
 						Namespace: namespace,
 					},
 					Spec: acceleratorv1alpha1.AcceleratorSpec{
-						Description: testDescription,
 						Source: &v1alpha1.ImageRepositorySpec{
 							Image: imageName,
 							ImagePullSecrets: []corev1.LocalObjectReference{
@@ -208,15 +383,149 @@ func TestUpdateCmd(t *testing.T) {
 									Name: secretRef,
 								},
 							},
-							Interval: expectedInterval,
 						},
-						Git: nil,
 					},
 				},
 			},
+			ExpectPatches: []rtesting.PatchRef{
+				acceleratorPatch(`{"metadata":{"resourceVersion":"999"},"spec":{"source":{"imagePullSecrets":null}}}`),
+			},
 			ExpectOutput: "accelerator test-accelerator updated successfully\n",
 		},
+		{
+			Name:        "Error unsetting unsupported field",
+			Args:        []string{acceleratorName, "--unset", "git-repository"},
+			ShouldError: true,
+		},
+		{
+			Name:        "Error setting and unsetting the same field",
+			Args:        []string{acceleratorName, "--description", testDescription, "--unset", "description"},
+			ShouldError: true,
+		},
+		{
+			Name:        "Error updating accelerator with invalid interval",
+			Args:        []string{acceleratorName, "--interval", "often"},
+			ShouldError: true,
+		},
+		{
+			Name: "Error updating git branch of source image accelerator",
+			Args: []string{acceleratorName, "--git-branch", "main"},
+			GivenObjects: []client.Object{
+				&acceleratorv1alpha1.Accelerator{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      acceleratorName,
+						Namespace: namespace,
+					},
+					Spec: acceleratorv1alpha1.AcceleratorSpec{
+						Source: &v1alpha1.ImageRepositorySpec{
+							Image: imageName,
+						},
+					},
+				},
+			},
+			ShouldError:  true,
+			ExpectOutput: "there was an error updating accelerator test-accelerator\n",
+		},
+		{
+			Name: "Error updating accelerator includes name and namespace",
+			Args: []string{acceleratorName, "--description", testDescription},
+			WithReactors: []clitesting.ReactionFunc{
+				clitesting.InduceFailure("patch", "Accelerator"),
+			},
+			GivenObjects: []client.Object{
+				&acceleratorv1alpha1.Accelerator{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      acceleratorName,
+						Namespace: namespace,
+					},
+				},
+			},
+			ExpectPatches: []rtesting.PatchRef{
+				acceleratorPatch(`{"metadata":{"resourceVersion":"999"},"spec":{"description":"another description"}}`),
+			},
+			ShouldError: true,
+			Verify: func(t *testing.T, output string, err error) {
+				if !strings.Contains(err.Error(), "error updating accelerator test-accelerator in namespace accelerator-system") {
+					t.Errorf("expected error to include the accelerator name and namespace, got %q", err.Error())
+				}
+			},
+		},
 	}
 
 	table.Run(t, scheme, UpdateCmd)
 }
+
+// induceConflictOnce fails the first update or patch of the kind with a conflict, as if someone else updated the
+// resource in the meantime
+func induceConflictOnce(kind string) clitesting.ReactionFunc {
+	conflicted := false
+	return func(action clitesting.Action) (bool, runtime.Object, error) {
+		if conflicted || !(action.Matches("update", kind) || action.Matches("patch", kind)) {
+			return false, nil, nil
+		}
+		conflicted = true
+		gr := schema.GroupResource{Group: "accelerator.apps.tanzu.vmware.com", Resource: strings.ToLower(kind) + "s"}
+		return true, nil, apierrors.NewConflict(gr, "", errors.New("the object has been modified"))
+	}
+}
+
+// patchCommandTestCase is a command test case that also asserts the patches sent to the cluster, which the
+// clitesting command test cases don't support
+type patchCommandTestCase struct {
+	Name          string
+	Args          []string
+	GivenObjects  []client.Object
+	WithReactors  []clitesting.ReactionFunc
+	ExpectPatches []rtesting.PatchRef
+	ShouldError   bool
+	ExpectOutput  string
+	Verify        func(t *testing.T, output string, err error)
+}
+
+type patchCommandTestSuite []patchCommandTestCase
+
+func (ts patchCommandTestSuite) Run(t *testing.T, scheme *runtime.Scheme, cmdFactory func(context.Context, *cli.Config) *cobra.Command) {
+	t.Helper()
+	for _, tc := range ts {
+		tc.Run(t, scheme, cmdFactory)
+	}
+}
+
+func (tc patchCommandTestCase) Run(t *testing.T, scheme *runtime.Scheme, cmdFactory func(context.Context, *cli.Config) *cobra.Command) {
+	t.Run(tc.Name, func(t *testing.T) {
+		expectConfig := &rtesting.ExpectConfig{
+			Name:          tc.Name,
+			Scheme:        scheme,
+			GivenObjects:  tc.GivenObjects,
+			WithReactors:  tc.WithReactors,
+			ExpectPatches: tc.ExpectPatches,
+		}
+		c := cli.NewDefaultConfig("test", scheme)
+		c.Client = clitesting.NewFakeCliClient(expectConfig.Config().Client)
+		output := &bytes.Buffer{}
+		c.Stdout = output
+		c.Stderr = output
+
+		cmd := cmdFactory(context.Background(), c)
+		cmd.SilenceErrors = true
+		cmd.SilenceUsage = true
+		cmd.SetArgs(append([]string{}, tc.Args...))
+		cmd.SetOutput(output)
+		err := cmd.Execute()
+
+		if expected, actual := tc.ShouldError, err != nil; expected != actual {
+			if expected {
+				t.Errorf("expected command to error, actual %v", err)
+			} else {
+				t.Errorf("expected command not to error, actual %q", err)
+			}
+		}
+		expectConfig.AssertClientExpectations(t)
+		if tc.ExpectOutput != "" && tc.ExpectOutput != output.String() {
+			t.Errorf("expected output %q, actual %q", tc.ExpectOutput, output.String())
+		}
+		if tc.Verify != nil {
+			tc.Verify(t, output.String(), err)
+		}
+	})
+}
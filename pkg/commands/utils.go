@@ -8,10 +8,12 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"net/http"
 	"os"
+	"os/exec"
 	"strings"
 	"time"
 
@@ -21,6 +23,11 @@ import (
 	"github.com/vmware-tanzu/apps-cli-plugin/pkg/logger"
 	"github.com/vmware-tanzu/apps-cli-plugin/pkg/source"
 	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/registry"
+	"golang.org/x/term"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/util/retry"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -173,6 +180,134 @@ func SuggestFragmentNamesFromConfig(ctx context.Context, c *cli.Config) func(*co
 	}
 }
 
+// selectorListOptions returns the options for listing resources in the namespace, restricted to the
+// resources matching the label selector when one is provided
+func selectorListOptions(namespace string, selector string) ([]client.ListOption, error) {
+	listOpts := []client.ListOption{client.InNamespace(namespace)}
+	if selector != "" {
+		labelSelector, err := labels.Parse(selector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selector \"%s\": %v", selector, err)
+		}
+		listOpts = append(listOpts, client.MatchingLabelsSelector{Selector: labelSelector})
+	}
+	return listOpts, nil
+}
+
+// applyMetadataChanges applies a list of "key=value" entries to the labels or annotations of a resource. An entry
+// of the form "key-" removes the key. The kind is used for validation and error messages and must be either
+// "label" or "annotation".
+func applyMetadataChanges(current map[string]string, changes []string, kind string) (map[string]string, error) {
+	result := map[string]string{}
+	for key, value := range current {
+		result[key] = value
+	}
+	for _, change := range changes {
+		if strings.HasSuffix(change, "-") && !strings.Contains(change, "=") {
+			key := strings.TrimSuffix(change, "-")
+			if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+				return nil, fmt.Errorf("invalid %s key \"%s\": %s", kind, key, strings.Join(errs, "; "))
+			}
+			delete(result, key)
+			continue
+		}
+		kv := strings.SplitN(change, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("%s must be formatted as key=value or key- to remove the %s", change, kind)
+		}
+		if errs := validation.IsQualifiedName(kv[0]); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid %s key \"%s\": %s", kind, kv[0], strings.Join(errs, "; "))
+		}
+		if kind == "label" {
+			if errs := validation.IsValidLabelValue(kv[1]); len(errs) > 0 {
+				return nil, fmt.Errorf("invalid %s value \"%s\": %s", kind, kv[1], strings.Join(errs, "; "))
+			}
+		}
+		result[kv[0]] = kv[1]
+	}
+	if len(result) == 0 {
+		return nil, nil
+	}
+	return result, nil
+}
+
+// validateUnset checks that only supported fields are unset and that they are not set by a flag at the same time
+func validateUnset(cmd *cobra.Command, unset []string, supported []string) error {
+	for _, field := range unset {
+		if !contains(supported, []string{field}) {
+			return fmt.Errorf("field %q can not be unset, supported fields are %s", field, strings.Join(supported, ", "))
+		}
+		if flag := cmd.Flags().Lookup(field); flag != nil && flag.Changed {
+			return fmt.Errorf("you may not both set and unset %s", field)
+		}
+	}
+	return nil
+}
+
+// updateWithRetry reads the resource returned by newObj, applies the changes made by mutate and updates the resource
+// on the cluster. When the update conflicts with a change made by someone else in the meantime, for example the
+// controller updating the status, the resource is read again and the changes are re-applied.
+func updateWithRetry(ctx context.Context, c *cli.Config, kind string, key client.ObjectKey, newObj func() client.Object, mutate func() error) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		obj := newObj()
+		err := c.Get(ctx, key, obj)
+		if err != nil {
+			if k8serrors.IsNotFound(err) {
+				return fmt.Errorf("%s %s not found in namespace %s: %w", kind, key.Name, key.Namespace, err)
+			}
+			return fmt.Errorf("error getting %s %s in namespace %s: %w", kind, key.Name, key.Namespace, err)
+		}
+		err = mutate()
+		if err != nil {
+			return err
+		}
+		err = c.Update(ctx, obj)
+		if err != nil {
+			return fmt.Errorf("error updating %s %s in namespace %s: %w", kind, key.Name, key.Namespace, err)
+		}
+		return nil
+	})
+}
+
+// patchResource reads the resource returned by newObj, applies the changes made by mutate and sends them to the
+// cluster as a JSON merge patch. Only the changed fields are part of the patch, with the fields cleared by mutate set
+// to null, so that changes made by someone else to the other fields of the resource are kept. The patch carries the
+// resourceVersion that was read, since lists like the tags are replaced as a whole: when the resource was changed in
+// the meantime, the resource is read again and the changes are re-applied.
+func patchResource(ctx context.Context, c *cli.Config, kind string, key client.ObjectKey, newObj func() client.Object, mutate func() error) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		obj := newObj()
+		err := c.Get(ctx, key, obj)
+		if err != nil {
+			if k8serrors.IsNotFound(err) {
+				return fmt.Errorf("%s %s not found in namespace %s: %w", kind, key.Name, key.Namespace, err)
+			}
+			return fmt.Errorf("error getting %s %s in namespace %s: %w", kind, key.Name, key.Namespace, err)
+		}
+		original := obj.DeepCopyObject().(client.Object)
+		err = mutate()
+		if err != nil {
+			return err
+		}
+		err = c.Patch(ctx, obj, client.MergeFromWithOptions(original, client.MergeFromWithOptimisticLock{}))
+		if err != nil {
+			return fmt.Errorf("error updating %s %s in namespace %s: %w", kind, key.Name, key.Namespace, err)
+		}
+		return nil
+	})
+}
+
+// confirmDeletion lists the resources that are about to be deleted and asks the user to confirm the deletion
+func confirmDeletion(c *cli.Config, cmd *cobra.Command, kind string, namespace string, names []string) bool {
+	fmt.Fprintf(cmd.OutOrStdout(), "The following %ss in namespace %s will be deleted:\n", kind, namespace)
+	for _, name := range names {
+		fmt.Fprintf(cmd.OutOrStdout(), "  %s\n", name)
+	}
+	okToDelete := false
+	err := cli.NewConfirmSurvey(c, "Really delete %d %s(s)?", len(names), kind).Resolve(&okToDelete)
+	return err == nil && okToDelete
+}
+
 func (opts CreateOptions) PublishLocalSource(ctx context.Context, c *cli.Config) error {
 	digestedImage, err := pushSourceImage(ctx, c, opts.SourceImage, opts.LocalPath)
 	if err != nil {
@@ -237,3 +372,44 @@ func DetermineApiServerPrefix(url string) string {
 	// assume it is a tap-gui url
 	return "api/proxy"
 }
+
+// runGit runs the git command in the directory and returns its trimmed output, the error includes the output of git
+func runGit(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git %s failed: %v: %s", args[0], err, strings.TrimSpace(string(out)))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// formatSize returns the size in bytes in a human readable form, using binary units
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+// isTerminal returns true if the writer is a terminal
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	return ok && term.IsTerminal(int(f.Fd()))
+}
+
+// containsString returns true if the value is one of the values
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
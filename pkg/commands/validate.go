@@ -0,0 +1,280 @@
+/*
+Copyright 2021-2023 VMware, Inc. All Rights Reserved.
+*/
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	acceleratorv1alpha1 "github.com/pivotal/acc-controller/api/v1alpha1"
+	"github.com/spf13/cobra"
+	cli "github.com/vmware-tanzu/apps-cli-plugin/pkg/cli-runtime"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+type validationProblem struct {
+	File     string `json:"file"`
+	Document int    `json:"document,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+	Kind     string `json:"kind,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Severity string `json:"severity"`
+	Field    string `json:"field,omitempty"`
+	Message  string `json:"message"`
+}
+
+type validationReport struct {
+	Resources int                 `json:"resources"`
+	Errors    int                 `json:"errors"`
+	Warnings  int                 `json:"warnings"`
+	Problems  []validationProblem `json:"problems"`
+	// unit describes what was validated in the human output, e.g. "resource(s)"
+	unit string
+}
+
+func ValidateCmd(ctx context.Context, c *cli.Config) *cobra.Command {
+	opts := ValidateOptions{}
+	var validateCmd = &cobra.Command{
+		Use:   "validate",
+		Short: "Validate accelerator and fragment manifests",
+		Long: `Validate accelerator and accelerator fragment manifests without accessing a cluster.
+
+The manifests are checked for unknown fields and for problems that would otherwise only be reported when applying
+them, like a missing or invalid name, a namespace that doesn't match --namespace, specifying both a Git repository
+and a source image, invalid intervals, missing secret references and ambiguous Git branch and tag references.
+
+Directories are searched for files with a .yaml or .yml extension. Use --output json for output that can be
+processed by other tools. The command exits with an error when any errors are found, warnings are only reported.
+`,
+		Example: `tanzu accelerator validate --filename accelerator.yaml
+tanzu accelerator validate --filename ./manifests --output json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.Output != "human" && opts.Output != "json" {
+				return fmt.Errorf("invalid output format %q, must be one of human or json", opts.Output)
+			}
+			report := validateManifests(opts.FileNames, opts.Namespace)
+			return printValidationReport(cmd, report, opts.Output)
+		},
+	}
+	opts.DefineFlags(ctx, validateCmd, c)
+	return validateCmd
+}
+
+// printValidationReport prints the problems in the report and returns an error when any of the problems are errors
+func printValidationReport(cmd *cobra.Command, report validationReport, output string) error {
+	for _, problem := range report.Problems {
+		if problem.Severity == "error" {
+			report.Errors++
+		} else {
+			report.Warnings++
+		}
+	}
+	if output == "json" {
+		content, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "%s\n", content)
+	} else {
+		for _, problem := range report.Problems {
+			fmt.Fprintf(cmd.OutOrStdout(), "%s\n", problem)
+		}
+		if report.Errors == 0 && report.Warnings == 0 {
+			fmt.Fprintf(cmd.OutOrStdout(), "%d %s validated, no problems found\n", report.Resources, report.unit)
+		} else {
+			fmt.Fprintf(cmd.OutOrStdout(), "%d %s validated, %d error(s), %d warning(s)\n", report.Resources, report.unit, report.Errors, report.Warnings)
+		}
+	}
+	if report.Errors > 0 {
+		// the problems have been reported already, the usage would only hide them
+		cmd.SilenceUsage = true
+		return fmt.Errorf("validation failed with %d error(s)", report.Errors)
+	}
+	return nil
+}
+
+func (p validationProblem) String() string {
+	location := p.File
+	if p.Document > 0 {
+		location = fmt.Sprintf("%s (document %d)", p.File, p.Document)
+	}
+	if p.Line > 0 {
+		location = fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Column)
+	}
+	parts := []string{location, p.Severity}
+	if p.Kind != "" {
+		parts = append(parts, strings.TrimSpace(p.Kind+" "+p.Name))
+	}
+	if p.Field != "" {
+		parts = append(parts, p.Field)
+	}
+	return strings.Join(append(parts, p.Message), ": ")
+}
+
+func validateManifests(paths []string, namespace string) validationReport {
+	report := validationReport{Problems: []validationProblem{}, unit: "resource(s)"}
+	files := []string{}
+	for _, path := range paths {
+		found, err := manifestFiles(path)
+		if err != nil {
+			report.Problems = append(report.Problems, validationProblem{File: path, Severity: "error", Message: err.Error()})
+			continue
+		}
+		files = append(files, found...)
+	}
+	for _, file := range files {
+		documents, err := loadResourcesFromFile(file)
+		if err != nil {
+			report.Problems = append(report.Problems, validationProblem{File: file, Severity: "error", Message: err.Error()})
+			continue
+		}
+		for i, document := range documents {
+			report.Resources++
+			report.Problems = append(report.Problems, validateManifest(file, i+1, document.Raw, namespace)...)
+		}
+	}
+	return report
+}
+
+// manifestFiles returns the path itself for files, or the YAML files in the directory and its subdirectories
+func manifestFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+	files := []string{}
+	err = filepath.WalkDir(path, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && (strings.HasSuffix(file, ".yaml") || strings.HasSuffix(file, ".yml")) {
+			files = append(files, file)
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+func validateManifest(file string, document int, raw []byte, namespace string) []validationProblem {
+	problems := []validationProblem{}
+	obj := map[string]interface{}{}
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return append(problems, validationProblem{File: file, Document: document, Severity: "error", Message: fmt.Sprintf("not a valid resource: %v", err)})
+	}
+	kind, _, _ := unstructured.NestedString(obj, "kind")
+	name, _, _ := unstructured.NestedString(obj, "metadata", "name")
+	report := func(severity string, field string, format string, a ...interface{}) {
+		problems = append(problems, validationProblem{File: file, Document: document, Kind: kind, Name: name, Severity: severity, Field: field, Message: fmt.Sprintf(format, a...)})
+	}
+
+	if kind != "Accelerator" && kind != "Fragment" {
+		report("error", "kind", "the resource kind %q does not match \"Accelerator\" or \"Fragment\"", kind)
+		return problems
+	}
+	if apiVersion, _, _ := unstructured.NestedString(obj, "apiVersion"); apiVersion != acceleratorv1alpha1.GroupVersion.String() {
+		report("error", "apiVersion", "must be %s", acceleratorv1alpha1.GroupVersion.String())
+	}
+	if name == "" {
+		report("error", "metadata.name", "is required")
+	} else if msgs := validation.IsDNS1123Subdomain(name); len(msgs) > 0 {
+		report("error", "metadata.name", "invalid name %q: %s", name, strings.Join(msgs, ", "))
+	}
+	if ns, _, _ := unstructured.NestedString(obj, "metadata", "namespace"); ns != "" && ns != namespace {
+		report("error", "metadata.namespace", "namespace %q does not match the namespace %q, apply requires --namespace=%s", ns, namespace, ns)
+	}
+
+	git, hasGit, _ := unstructured.NestedMap(obj, "spec", "git")
+	source, hasSource, _ := unstructured.NestedMap(obj, "spec", "source")
+	if hasGit && hasSource {
+		report("error", "spec", "only one of git or source may be specified")
+	}
+	if !hasGit && !hasSource {
+		report("error", "spec", "one of git or source is required")
+	}
+	if hasGit {
+		repoUrl, _, _ := unstructured.NestedString(git, "url")
+		if repoUrl == "" {
+			report("error", "spec.git.url", "is required")
+		}
+		branch, _, _ := unstructured.NestedString(git, "ref", "branch")
+		tag, _, _ := unstructured.NestedString(git, "ref", "tag")
+		if branch == "" && tag == "" {
+			report("warning", "spec.git.ref", "no branch or tag specified, the default branch of the repository is used")
+		}
+		if branch != "" && tag != "" {
+			report("warning", "spec.git.ref", "both branch %q and tag %q are specified, the tag takes precedence", branch, tag)
+		}
+		secretRef, hasSecretRef, _ := unstructured.NestedMap(git, "secretRef")
+		if secretName, _, _ := unstructured.NestedString(secretRef, "name"); hasSecretRef && secretName == "" {
+			report("error", "spec.git.secretRef.name", "is required")
+		}
+		if !hasSecretRef && (strings.HasPrefix(repoUrl, "ssh://") || strings.HasPrefix(repoUrl, "git@")) {
+			report("error", "spec.git.secretRef", "is required for SSH repository URLs")
+		}
+	}
+	if hasSource {
+		if image, _, _ := unstructured.NestedString(source, "image"); image == "" {
+			report("error", "spec.source.image", "is required")
+		}
+		secrets, _, _ := unstructured.NestedSlice(source, "imagePullSecrets")
+		for i, secret := range secrets {
+			entry, _ := secret.(map[string]interface{})
+			if secretName, _, _ := unstructured.NestedString(entry, "name"); secretName == "" {
+				report("error", fmt.Sprintf("spec.source.imagePullSecrets[%d].name", i), "is required")
+			}
+		}
+	}
+	for _, field := range [][]string{{"spec", "git", "interval"}, {"spec", "source", "interval"}} {
+		interval, found, _ := unstructured.NestedString(obj, field...)
+		if !found {
+			continue
+		}
+		if _, err := time.ParseDuration(interval); err != nil {
+			report("error", strings.Join(field, "."), "invalid interval %q: %v", interval, err)
+			// replace the interval so that the invalid value doesn't hide other problems when decoding the resource
+			unstructured.SetNestedField(obj, "0s", field...)
+		}
+	}
+	if iconUrl, _, _ := unstructured.NestedString(obj, "spec", "iconUrl"); iconUrl != "" {
+		if u, err := url.ParseRequestURI(iconUrl); err != nil || u.Host == "" {
+			report("error", "spec.iconUrl", "invalid URL %q", iconUrl)
+		}
+	}
+	tags, _, _ := unstructured.NestedStringSlice(obj, "spec", "tags")
+	for i, tag := range tags {
+		if strings.TrimSpace(tag) == "" {
+			report("error", fmt.Sprintf("spec.tags[%d]", i), "tags must not be empty")
+		}
+	}
+
+	// decode the resource to find unknown fields and values of the wrong type
+	content, _ := json.Marshal(obj)
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.DisallowUnknownFields()
+	var err error
+	if kind == "Accelerator" {
+		err = decoder.Decode(&acceleratorv1alpha1.Accelerator{})
+	} else {
+		err = decoder.Decode(&acceleratorv1alpha1.Fragment{})
+	}
+	if err != nil {
+		report("error", "", "%s", strings.TrimPrefix(err.Error(), "json: "))
+	}
+	return problems
+}
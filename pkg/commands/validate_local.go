@@ -0,0 +1,358 @@
+/*
+Copyright 2021-2023 VMware, Inc. All Rights Reserved.
+*/
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	acceleratorv1alpha1 "github.com/pivotal/acc-controller/api/v1alpha1"
+	"github.com/spf13/cobra"
+	cli "github.com/vmware-tanzu/apps-cli-plugin/pkg/cli-runtime"
+	"gopkg.in/yaml.v3"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const acceleratorMetadataFile = "accelerator.yaml"
+
+var (
+	optionNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+	knownDataTypes    = []string{"string", "number", "boolean"}
+	knownInputTypes   = []string{"text", "textarea", "checkbox", "select", "radio", "toggle", "tag"}
+	knownTransforms   = []string{"Combo", "Include", "Exclude", "Merge", "Chain", "Let", "InvokeFragment", "Loop",
+		"ReplaceText", "RewritePath", "OpenRewriteRecipe", "YTT", "UseEncoding", "UniquePath", "Provenance"}
+)
+
+func ValidateLocalCmd(ctx context.Context, c *cli.Config) *cobra.Command {
+	opts := ValidateLocalOptions{}
+	var validateLocalCmd = &cobra.Command{
+		Use:   "validate-local [path]",
+		Short: "Validate the accelerator.yaml of a local accelerator or fragment",
+		Long: `Validate the accelerator.yaml file of an accelerator or fragment that is being authored locally.
+
+The path is the directory containing the accelerator.yaml file, or the file itself, and defaults to the current
+directory. The option definitions are checked for missing or duplicate names, unknown data types, default values
+that don't match their data type and choice lists, and the engine for unknown transform types.
+
+Imported fragments are looked up in the directories provided with --fragment-paths, which are validated as well,
+and in the fragments available in the namespace on the cluster. Use --offline to skip the cluster lookup, imports
+that can't be resolved are then only reported as warnings.
+
+Problems are reported with the file, line and column they were found at. The command exits with an error when any
+errors are found, warnings are only reported.
+`,
+		Example: `tanzu accelerator validate-local
+tanzu accelerator validate-local ./my-accelerator --fragment-paths java-version=./fragments/java-version --offline`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.Output != "human" && opts.Output != "json" {
+				return fmt.Errorf("invalid output format %q, must be one of human or json", opts.Output)
+			}
+			path := "."
+			if len(args) > 0 {
+				path = args[0]
+			}
+
+			fragments := map[string]bool{}
+			fragmentsChecked := false
+			if !opts.Offline {
+				fragmentList := &acceleratorv1alpha1.FragmentList{}
+				if err := c.List(ctx, fragmentList, client.InNamespace(opts.Namespace)); err != nil {
+					fmt.Fprintf(cmd.OutOrStderr(), "unable to list accelerator fragments in namespace %s, imports are not checked against the cluster: %v\n", opts.Namespace, err)
+				} else {
+					fragmentsChecked = true
+					for _, fragment := range fragmentList.Items {
+						fragments[fragment.Name] = true
+					}
+				}
+			}
+
+			report := validationReport{Problems: []validationProblem{}, unit: "file(s)"}
+			names := []string{}
+			for name := range opts.FragmentPaths {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				fragments[name] = true
+			}
+			for _, name := range names {
+				report.Resources++
+				report.Problems = append(report.Problems, validateMetadataFile(opts.FragmentPaths[name], fragments, fragmentsChecked)...)
+			}
+			report.Resources++
+			report.Problems = append(report.Problems, validateMetadataFile(path, fragments, fragmentsChecked)...)
+			return printValidationReport(cmd, report, opts.Output)
+		},
+	}
+	opts.DefineFlags(ctx, validateLocalCmd, c)
+	return validateLocalCmd
+}
+
+// metadataValidator collects the problems found in a single accelerator.yaml file
+type metadataValidator struct {
+	file     string
+	problems []validationProblem
+}
+
+func (v *metadataValidator) report(node *yaml.Node, severity string, field string, format string, a ...interface{}) {
+	problem := validationProblem{File: v.file, Severity: severity, Field: field, Message: fmt.Sprintf(format, a...)}
+	if node != nil {
+		problem.Line = node.Line
+		problem.Column = node.Column
+	}
+	v.problems = append(v.problems, problem)
+}
+
+// validateMetadataFile validates the accelerator.yaml in the path, where the path is either the file or the directory
+// containing it. Imports are resolved against the provided fragments, unresolved imports are only errors when the
+// fragments on the cluster were checked.
+func validateMetadataFile(path string, fragments map[string]bool, fragmentsChecked bool) []validationProblem {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		path = filepath.Join(path, acceleratorMetadataFile)
+	}
+	v := &metadataValidator{file: path, problems: []validationProblem{}}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		v.report(nil, "error", "", "%v", err)
+		return v.problems
+	}
+	document := &yaml.Node{}
+	if err := yaml.Unmarshal(content, document); err != nil {
+		v.report(nil, "error", "", "does not contain valid YAML: %v", err)
+		return v.problems
+	}
+	if len(document.Content) == 0 || document.Content[0].Kind != yaml.MappingNode {
+		v.report(nil, "error", "", "must contain a mapping with accelerator and engine sections")
+		return v.problems
+	}
+	root := document.Content[0]
+
+	accelerator := yamlField(root, "accelerator")
+	if accelerator == nil {
+		v.report(root, "error", "accelerator", "is required")
+	} else if accelerator.Kind != yaml.MappingNode {
+		v.report(accelerator, "error", "accelerator", "must be a mapping")
+		accelerator = nil
+	}
+	if engine := yamlField(root, "engine"); engine == nil {
+		v.report(root, "error", "engine", "is required")
+	}
+
+	types := map[string]bool{}
+	if accelerator != nil {
+		if typesNode := yamlField(accelerator, "types"); typesNode != nil {
+			for i, typeNode := range yamlItems(typesNode) {
+				if name := yamlField(typeNode, "name"); name != nil {
+					types[name.Value] = true
+				} else {
+					v.report(typeNode, "error", fmt.Sprintf("accelerator.types[%d].name", i), "is required")
+				}
+			}
+		}
+	}
+
+	imported := map[string]bool{}
+	importsField, importsNode := "imports", yamlField(root, "imports")
+	if accelerator != nil && importsNode == nil {
+		importsField, importsNode = "accelerator.imports", yamlField(accelerator, "imports")
+	}
+	for i, importNode := range yamlItems(importsNode) {
+		field := fmt.Sprintf("%s[%d].name", importsField, i)
+		name := yamlField(importNode, "name")
+		if name == nil || name.Value == "" {
+			v.report(importNode, "error", field, "is required")
+			continue
+		}
+		imported[name.Value] = true
+		if !fragments[name.Value] {
+			if fragmentsChecked {
+				v.report(name, "error", field, "fragment %q is not available on the cluster or in --fragment-paths", name.Value)
+			} else {
+				v.report(name, "warning", field, "fragment %q could not be checked, it is not provided with --fragment-paths", name.Value)
+			}
+		}
+	}
+
+	if accelerator != nil {
+		v.validateOptions(yamlField(accelerator, "options"), types)
+	}
+	v.validateTransforms(yamlField(root, "engine"), "engine", imported)
+	return v.problems
+}
+
+func (v *metadataValidator) validateOptions(optionsNode *yaml.Node, types map[string]bool) {
+	if optionsNode != nil && optionsNode.Kind != yaml.SequenceNode {
+		v.report(optionsNode, "error", "accelerator.options", "must be a list")
+		return
+	}
+	options := yamlItems(optionsNode)
+	names := map[string]bool{}
+	for _, option := range options {
+		if name := yamlField(option, "name"); name != nil {
+			names[name.Value] = true
+		}
+	}
+	seen := map[string]bool{}
+	for i, option := range options {
+		field := func(name string) string {
+			return fmt.Sprintf("accelerator.options[%d].%s", i, name)
+		}
+		name := yamlField(option, "name")
+		if name == nil || name.Value == "" {
+			v.report(option, "error", field("name"), "is required")
+		} else if !optionNamePattern.MatchString(name.Value) {
+			v.report(name, "error", field("name"), "invalid name %q, must start with a letter or underscore and contain only letters, digits and underscores", name.Value)
+		} else if seen[name.Value] {
+			v.report(name, "error", field("name"), "duplicate option %q", name.Value)
+		} else {
+			seen[name.Value] = true
+		}
+
+		dataType := "string"
+		list := false
+		if dataTypeNode := yamlField(option, "dataType"); dataTypeNode != nil {
+			switch {
+			case dataTypeNode.Kind == yaml.ScalarNode:
+				dataType = dataTypeNode.Value
+			case dataTypeNode.Kind == yaml.SequenceNode && len(dataTypeNode.Content) == 1 && dataTypeNode.Content[0].Kind == yaml.ScalarNode:
+				dataType = dataTypeNode.Content[0].Value
+				list = true
+			default:
+				dataType = ""
+			}
+			if !contains(knownDataTypes, []string{dataType}) && !types[dataType] {
+				v.report(dataTypeNode, "error", field("dataType"), "unknown data type, must be one of %s, a list of one of them or a type defined in accelerator.types", strings.Join(knownDataTypes, ", "))
+				dataType = ""
+			}
+		}
+
+		choices := map[string]bool{}
+		choicesNode := yamlField(option, "choices")
+		for j, choice := range yamlItems(choicesNode) {
+			value := yamlField(choice, "value")
+			if value == nil || value.Kind != yaml.ScalarNode {
+				v.report(choice, "error", fmt.Sprintf("accelerator.options[%d].choices[%d].value", i, j), "is required")
+				continue
+			}
+			choices[value.Value] = true
+		}
+
+		inputType := yamlField(option, "inputType")
+		if inputType != nil {
+			if !contains(knownInputTypes, []string{inputType.Value}) {
+				v.report(inputType, "error", field("inputType"), "unknown input type %q, must be one of %s", inputType.Value, strings.Join(knownInputTypes, ", "))
+			} else if (inputType.Value == "select" || inputType.Value == "radio") && len(choices) == 0 {
+				v.report(inputType, "error", field("choices"), "are required for input type %q", inputType.Value)
+			}
+		}
+
+		if defaultValue := yamlField(option, "defaultValue"); defaultValue != nil && dataType != "" && !types[dataType] {
+			values := []*yaml.Node{defaultValue}
+			if list {
+				if defaultValue.Kind != yaml.SequenceNode {
+					v.report(defaultValue, "error", field("defaultValue"), "must be a list for data type [%s]", dataType)
+					values = nil
+				} else {
+					values = defaultValue.Content
+				}
+			}
+			for _, value := range values {
+				if !matchesDataType(value, dataType) {
+					v.report(value, "error", field("defaultValue"), "%q is not a valid %s", value.Value, dataType)
+				} else if len(choices) > 0 && !choices[value.Value] {
+					v.report(value, "error", field("defaultValue"), "%q is not one of the choices", value.Value)
+				}
+			}
+		}
+
+		if dependsOn := yamlField(option, "dependsOn"); dependsOn != nil {
+			if dependency := yamlField(dependsOn, "name"); dependency == nil {
+				v.report(dependsOn, "error", field("dependsOn.name"), "is required")
+			} else if !names[dependency.Value] {
+				v.report(dependency, "error", field("dependsOn.name"), "option %q is not defined", dependency.Value)
+			}
+		}
+
+		if validationRegex := yamlField(option, "validationRegex"); validationRegex != nil {
+			if _, err := regexp.Compile(validationRegex.Value); err != nil {
+				v.report(validationRegex, "warning", field("validationRegex"), "invalid regular expression: %v", err)
+			}
+		}
+	}
+}
+
+// validateTransforms walks the engine, checking the type of every transform that specifies one and that fragments
+// invoked with InvokeFragment are imported
+func (v *metadataValidator) validateTransforms(node *yaml.Node, field string, imported map[string]bool) {
+	if node == nil {
+		return
+	}
+	switch node.Kind {
+	case yaml.MappingNode:
+		transformType := yamlField(node, "type")
+		if transformType != nil && transformType.Kind == yaml.ScalarNode {
+			if !contains(knownTransforms, []string{transformType.Value}) {
+				v.report(transformType, "error", field+".type", "unknown transform type %q", transformType.Value)
+			}
+			if transformType.Value == "InvokeFragment" {
+				if reference := yamlField(node, "reference"); reference == nil {
+					v.report(node, "error", field+".reference", "is required")
+				} else if !imported[reference.Value] {
+					v.report(reference, "error", field+".reference", "fragment %q is not imported", reference.Value)
+				}
+			}
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			v.validateTransforms(node.Content[i+1], field+"."+node.Content[i].Value, imported)
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			v.validateTransforms(item, fmt.Sprintf("%s[%d]", field, i), imported)
+		}
+	}
+}
+
+// yamlField returns the value of the key in the mapping node, or nil when the node isn't a mapping or the key is
+// missing
+func yamlField(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// yamlItems returns the items of the sequence node, or nil when the node isn't a sequence
+func yamlItems(node *yaml.Node) []*yaml.Node {
+	if node == nil || node.Kind != yaml.SequenceNode {
+		return nil
+	}
+	return node.Content
+}
+
+func matchesDataType(node *yaml.Node, dataType string) bool {
+	if node.Kind != yaml.ScalarNode {
+		return false
+	}
+	switch dataType {
+	case "number":
+		_, err := strconv.ParseFloat(node.Value, 64)
+		return err == nil && node.Tag != "!!str"
+	case "boolean":
+		return node.Tag == "!!bool"
+	default:
+		return true
+	}
+}
@@ -0,0 +1,138 @@
+package commands
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	acceleratorv1alpha1 "github.com/pivotal/acc-controller/api/v1alpha1"
+	clitesting "github.com/vmware-tanzu/apps-cli-plugin/pkg/cli-runtime/testing"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestValidateLocalCommand(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = acceleratorv1alpha1.AddToScheme(scheme)
+
+	validPath := "testdata/validate-local/valid"
+	invalidPath := "testdata/validate-local/invalid"
+	fragmentPath := "testdata/validate-local/fragment"
+	namespace := "accelerator-system"
+
+	javaVersionFragment := &acceleratorv1alpha1.Fragment{
+		ObjectMeta: v1.ObjectMeta{
+			Namespace: namespace,
+			Name:      "java-version",
+		},
+	}
+
+	table := clitesting.CommandTestSuite{
+		{
+			Name:        "Error too many args",
+			Args:        []string{validPath, invalidPath},
+			ShouldError: true,
+		},
+		{
+			Name:        "Error invalid output format",
+			Args:        []string{validPath, "--output", "yaml"},
+			ShouldError: true,
+		},
+		{
+			Name:         "Validate accelerator with fragment on the cluster",
+			Args:         []string{validPath},
+			GivenObjects: []client.Object{javaVersionFragment},
+			ExpectOutput: "1 file(s) validated, no problems found\n",
+		},
+		{
+			Name:         "Validate accelerator file with fragment on the cluster",
+			Args:         []string{validPath + "/accelerator.yaml"},
+			GivenObjects: []client.Object{javaVersionFragment},
+			ExpectOutput: "1 file(s) validated, no problems found\n",
+		},
+		{
+			Name:         "Validate accelerator with local fragment",
+			Args:         []string{validPath, "--fragment-paths", "java-version=" + fragmentPath, "--offline"},
+			ExpectOutput: "2 file(s) validated, no problems found\n",
+		},
+		{
+			Name:         "Validate accelerator with missing fragment",
+			Args:         []string{validPath},
+			ShouldError:  true,
+			ExpectOutput: "testdata/validate-local/valid/accelerator.yaml:29:13: error: accelerator.imports[0].name: fragment \"java-version\" is not available on the cluster or in --fragment-paths\n1 file(s) validated, 1 error(s), 0 warning(s)\n",
+		},
+		{
+			Name:         "Validate accelerator offline",
+			Args:         []string{validPath, "--offline"},
+			ExpectOutput: "testdata/validate-local/valid/accelerator.yaml:29:13: warning: accelerator.imports[0].name: fragment \"java-version\" could not be checked, it is not provided with --fragment-paths\n1 file(s) validated, 0 error(s), 1 warning(s)\n",
+		},
+		{
+			Name: "Validate accelerator when fragments can't be listed",
+			Args: []string{validPath},
+			WithReactors: []clitesting.ReactionFunc{
+				clitesting.InduceFailure("list", "FragmentList"),
+			},
+			Verify: func(t *testing.T, output string, err error) {
+				for _, expected := range []string{
+					"unable to list accelerator fragments in namespace accelerator-system, imports are not checked against the cluster",
+					"1 file(s) validated, 0 error(s), 1 warning(s)\n",
+				} {
+					if !strings.Contains(output, expected) {
+						t.Errorf("expected output to contain %q, got %q", expected, output)
+					}
+				}
+			},
+		},
+		{
+			Name:         "Validate missing accelerator.yaml",
+			Args:         []string{"testdata/validate-local"},
+			ShouldError:  true,
+			ExpectOutput: "testdata/validate-local/accelerator.yaml: error: open testdata/validate-local/accelerator.yaml: no such file or directory\n1 file(s) validated, 1 error(s), 0 warning(s)\n",
+		},
+		{
+			Name:         "Validate invalid accelerator",
+			Args:         []string{invalidPath},
+			GivenObjects: []client.Object{javaVersionFragment},
+			ShouldError:  true,
+			Verify: func(t *testing.T, output string, err error) {
+				for _, expected := range []string{
+					"accelerator.yaml:6:13: error: accelerator.options[1].name: duplicate option \"greeting\"\n",
+					"accelerator.yaml:8:13: error: accelerator.options[2].name: invalid name \"1port\"",
+					"accelerator.yaml:9:17: error: accelerator.options[2].dataType: unknown data type",
+					"accelerator.yaml:12:21: error: accelerator.options[3].defaultValue: \"yes please\" is not a valid boolean\n",
+					"accelerator.yaml:14:18: error: accelerator.options[4].choices: are required for input type \"radio\"\n",
+					"accelerator.yaml:16:15: error: accelerator.options[4].dependsOn.name: option \"storage\" is not defined\n",
+					"accelerator.yaml:22:11: error: accelerator.options[5].choices[1].value: is required\n",
+					"accelerator.yaml:23:21: error: accelerator.options[5].defaultValue: \"3\" is not one of the choices\n",
+					"accelerator.yaml:25:24: warning: accelerator.options[6].validationRegex: invalid regular expression",
+					"accelerator.yaml:31:13: error: engine.chain[0].type: unknown transform type \"Rewrite\"\n",
+					"accelerator.yaml:33:18: error: engine.chain[1].reference: fragment \"spring-boot\" is not imported\n",
+					"1 file(s) validated, 10 error(s), 1 warning(s)\n",
+				} {
+					if !strings.Contains(output, expected) {
+						t.Errorf("expected output to contain %q, got %q", expected, output)
+					}
+				}
+			},
+		},
+		{
+			Name:        "Validate invalid accelerator with json output",
+			Args:        []string{invalidPath, "--offline", "--output", "json"},
+			ShouldError: true,
+			Verify: func(t *testing.T, output string, err error) {
+				report := validationReport{}
+				if err := json.Unmarshal([]byte(output), &report); err != nil {
+					t.Fatalf("expected json output, got %q: %v", output, err)
+				}
+				if report.Resources != 1 || report.Errors != 10 || report.Warnings != 2 {
+					t.Errorf("expected 1 file with 10 errors and 2 warnings, got %+v", report)
+				}
+				if len(report.Problems) == 0 || report.Problems[0].Line == 0 || report.Problems[0].Column == 0 {
+					t.Errorf("expected problems with line and column, got %+v", report.Problems)
+				}
+			},
+		},
+	}
+	table.Run(t, scheme, ValidateLocalCmd)
+}
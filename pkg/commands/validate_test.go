@@ -0,0 +1,89 @@
+package commands
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	acceleratorv1alpha1 "github.com/pivotal/acc-controller/api/v1alpha1"
+	clitesting "github.com/vmware-tanzu/apps-cli-plugin/pkg/cli-runtime/testing"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestValidateCommand(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = acceleratorv1alpha1.AddToScheme(scheme)
+
+	validFilename := "testdata/validate/valid.yaml"
+	invalidFilename := "testdata/validate/invalid.yaml"
+
+	table := clitesting.CommandTestSuite{
+		{
+			Name:        "Missing filename",
+			Args:        []string{},
+			ShouldError: true,
+		},
+		{
+			Name:        "Error invalid output format",
+			Args:        []string{"--filename", validFilename, "--output", "yaml"},
+			ShouldError: true,
+		},
+		{
+			Name:         "Validate valid manifests",
+			Args:         []string{"--filename", validFilename},
+			ExpectOutput: "2 resource(s) validated, no problems found\n",
+		},
+		{
+			Name:        "Validate missing file",
+			Args:        []string{"--filename", "testdata/validate/missing.yaml"},
+			ShouldError: true,
+			ExpectOutput: `
+testdata/validate/missing.yaml: error: stat testdata/validate/missing.yaml: no such file or directory
+0 resource(s) validated, 1 error(s), 0 warning(s)
+`,
+		},
+		{
+			Name:        "Validate invalid manifests",
+			Args:        []string{"--filename", invalidFilename},
+			ShouldError: true,
+			Verify: func(t *testing.T, output string, err error) {
+				for _, expected := range []string{
+					`testdata/validate/invalid.yaml (document 1): error: Accelerator Test_Accelerator: metadata.name: invalid name "Test_Accelerator"`,
+					`testdata/validate/invalid.yaml (document 1): error: Accelerator Test_Accelerator: metadata.namespace: namespace "default" does not match the namespace "accelerator-system"`,
+					"testdata/validate/invalid.yaml (document 1): error: Accelerator Test_Accelerator: spec: only one of git or source may be specified\n",
+					`testdata/validate/invalid.yaml (document 1): warning: Accelerator Test_Accelerator: spec.git.ref: both branch "main" and tag "v1.0.0" are specified, the tag takes precedence`,
+					"testdata/validate/invalid.yaml (document 1): error: Accelerator Test_Accelerator: spec.git.secretRef: is required for SSH repository URLs\n",
+					`testdata/validate/invalid.yaml (document 1): error: Accelerator Test_Accelerator: spec.git.interval: invalid interval "often"`,
+					"testdata/validate/invalid.yaml (document 2): warning: Fragment test-fragment: spec.git.ref: no branch or tag specified, the default branch of the repository is used\n",
+					`testdata/validate/invalid.yaml (document 2): error: Fragment test-fragment: unknown field "branch"`,
+					"2 resource(s) validated, 6 error(s), 2 warning(s)\n",
+				} {
+					if !strings.Contains(output, expected) {
+						t.Errorf("expected output to contain %q, got %q", expected, output)
+					}
+				}
+			},
+		},
+		{
+			Name:        "Validate directory with json output",
+			Args:        []string{"--filename", "testdata/validate", "--output", "json"},
+			ShouldError: true,
+			Verify: func(t *testing.T, output string, err error) {
+				report := validationReport{}
+				if err := json.Unmarshal([]byte(output), &report); err != nil {
+					t.Fatalf("expected json output, got %q: %v", output, err)
+				}
+				if report.Resources != 4 || report.Errors != 6 || report.Warnings != 2 {
+					t.Errorf("expected 4 resources with 6 errors and 2 warnings, got %+v", report)
+				}
+			},
+		},
+		{
+			Name:         "Validate manifests for another namespace",
+			Args:         []string{"--filename", validFilename, "--namespace", "default"},
+			ShouldError:  true,
+			ExpectOutput: "testdata/validate/valid.yaml (document 2): error: Accelerator test-accelerator: metadata.namespace: namespace \"accelerator-system\" does not match the namespace \"default\", apply requires --namespace=accelerator-system\n2 resource(s) validated, 1 error(s), 0 warning(s)\n",
+		},
+	}
+	table.Run(t, scheme, ValidateCmd)
+}